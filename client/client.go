@@ -21,6 +21,14 @@ type (
 		Do(*http.Request) (*http.Response, error)
 	}
 
+	// ContextDoer is implemented by Doers that make use of the context of the requests they
+	// make, for example to propagate traces. Client calls DoContext instead of Do on Doers that
+	// implement it.
+	ContextDoer interface {
+		Doer
+		DoContext(context.Context, *http.Request) (*http.Response, error)
+	}
+
 	// Client is the common client data structure for all goa service clients.
 	Client struct {
 		// Doer is the underlying http client.
@@ -31,7 +39,8 @@ type (
 		Host string
 		// UserAgent is the user agent set in requests made by the client.
 		UserAgent string
-		// Dump indicates whether to dump request response.
+		// Dump indicates whether to dump request response. Dumps are logged with the debug
+		// level.
 		Dump bool
 	}
 )
@@ -57,7 +66,15 @@ func (c *Client) Do(ctx context.Context, req *http.Request) (*http.Response, err
 	if c.Dump {
 		c.dumpRequest(ctx, req)
 	}
-	resp, err := c.Doer.Do(req)
+	var (
+		resp *http.Response
+		err  error
+	)
+	if cd, ok := c.Doer.(ContextDoer); ok {
+		resp, err = cd.DoContext(ctx, req)
+	} else {
+		resp, err = c.Doer.Do(req)
+	}
 	if err != nil {
 		goa.LogError(ctx, "failed", "err", err)
 		return nil, err
@@ -69,24 +86,27 @@ func (c *Client) Do(ctx context.Context, req *http.Request) (*http.Response, err
 	return resp, err
 }
 
-// Dump request if needed.
+// Dump request if needed. Dumps are logged using the debug level regardless of the context
+// minimum log level.
 func (c *Client) dumpRequest(ctx context.Context, req *http.Request) {
+	ctx = goa.WithLogLevel(ctx, goa.LevelDebug)
 	reqBody, err := dumpReqBody(req)
 	if err != nil {
 		goa.LogError(ctx, "Failed to load request body for dump", "err", err.Error())
 	}
-	goa.LogInfo(ctx, "request headers", headersToSlice(req.Header)...)
+	goa.LogDebug(ctx, "request headers", headersToSlice(req.Header)...)
 	if reqBody != nil {
-		goa.LogInfo(ctx, "request", "body", string(reqBody))
+		goa.LogDebug(ctx, "request", "body", string(reqBody))
 	}
 }
 
 // dumpResponse dumps the response and the request.
 func (c *Client) dumpResponse(ctx context.Context, resp *http.Response) {
+	ctx = goa.WithLogLevel(ctx, goa.LevelDebug)
 	respBody, _ := dumpRespBody(resp)
-	goa.LogInfo(ctx, "response headers", headersToSlice(resp.Header)...)
+	goa.LogDebug(ctx, "response headers", headersToSlice(resp.Header)...)
 	if respBody != nil {
-		goa.LogInfo(ctx, "response", "body", string(respBody))
+		goa.LogDebug(ctx, "response", "body", string(respBody))
 	}
 }
 
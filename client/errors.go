@@ -0,0 +1,66 @@
+package client
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/goadesign/goa"
+)
+
+// DecodeError decodes the error contained in the body of resp using dec. The body may use the
+// default error media type or describe RFC 7807 problem details in which case the error code is
+// read from the last segment of the problem type and the extension members are used as metadata.
+// The resulting error is created using the class indexed by its code in classes if there is one.
+func DecodeError(dec *goa.HTTPDecoder, resp *http.Response, classes map[string]goa.ErrorClass) (*goa.Error, error) {
+	contentType := resp.Header.Get("Content-Type")
+	var e *goa.Error
+	if mt, _, err := mime.ParseMediaType(contentType); err == nil && mt == goa.ProblemMediaIdentifier {
+		var problem map[string]interface{}
+		if err := dec.Decode(&problem, resp.Body, contentType); err != nil {
+			return nil, err
+		}
+		e = problemError(problem)
+	} else {
+		e = new(goa.Error)
+		if err := dec.Decode(e, resp.Body, contentType); err != nil {
+			return nil, err
+		}
+	}
+	if e.Status == 0 {
+		e.Status = resp.StatusCode
+	}
+	class, ok := classes[e.Code]
+	if !ok {
+		return e, nil
+	}
+	res := class("%s", e.Detail)
+	res.MetaValues = e.MetaValues
+	return res, nil
+}
+
+// problemError creates an error from the members of decoded problem details.
+func problemError(problem map[string]interface{}) *goa.Error {
+	e := new(goa.Error)
+	for k, v := range problem {
+		switch k {
+		case "type":
+			code := fmt.Sprintf("%v", v)
+			if idx := strings.LastIndexAny(code, "/#"); idx > -1 {
+				code = code[idx+1:]
+			}
+			e.Code = code
+		case "status":
+			if f, ok := v.(float64); ok {
+				e.Status = int(f)
+			}
+		case "detail":
+			e.Detail = fmt.Sprintf("%v", v)
+		case "title", "instance":
+		default:
+			e.Meta(k, v)
+		}
+	}
+	return e
+}
@@ -0,0 +1,85 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// Event is a server-sent event read from a text/event-stream response body.
+	Event struct {
+		// ID is the value of the last "id" field sent by the server if any.
+		ID string
+		// Name is the value of the event "event" field, "message" if not set.
+		Name string
+		// Retry is the reconnection delay sent by the server if any.
+		Retry time.Duration
+		// Data is the event payload, multiple "data" fields are joined with newlines.
+		Data []byte
+	}
+
+	// EventReader reads server-sent events from a response body.
+	EventReader struct {
+		r      *bufio.Reader
+		lastID string
+	}
+)
+
+// NewEventReader returns a reader that reads server-sent events from r, typically the body of a
+// response with content type text/event-stream.
+func NewEventReader(r io.Reader) *EventReader {
+	return &EventReader{r: bufio.NewReader(r)}
+}
+
+// Next returns the next event of the stream. It returns io.EOF once the stream is closed.
+func (r *EventReader) Next() (*Event, error) {
+	var (
+		ev      = &Event{ID: r.lastID, Name: "message"}
+		data    bytes.Buffer
+		hasData bool
+	)
+	for {
+		line, err := r.r.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			if !hasData {
+				// Events with no data are ignored as per the specification.
+				ev = &Event{ID: r.lastID, Name: "message"}
+				continue
+			}
+			ev.Data = data.Bytes()
+			return ev, nil
+		}
+		if strings.HasPrefix(line, ":") {
+			continue // Comment
+		}
+		field, value := line, ""
+		if idx := strings.Index(line, ":"); idx > -1 {
+			field, value = line[:idx], strings.TrimPrefix(line[idx+1:], " ")
+		}
+		switch field {
+		case "id":
+			r.lastID = value
+			ev.ID = value
+		case "event":
+			ev.Name = value
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil {
+				ev.Retry = time.Duration(ms) * time.Millisecond
+			}
+		case "data":
+			if hasData {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+			hasData = true
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package client
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"mime/multipart"
+	"net/textproto"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/goadesign/goa/uuid"
+)
+
+var (
+	fileHeaderType = reflect.TypeOf(&multipart.FileHeader{})
+	uuidType       = reflect.TypeOf(uuid.UUID{})
+	textMarshaler  = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// NewFileHeader creates a multipart file header with the given filename and the content read from
+// r. The content is held in memory. Use NewFileHeader to set the File attributes of multipart
+// payloads, for example:
+//
+//	f, err := os.Open("report.pdf")
+//	if err != nil {
+//		return err
+//	}
+//	defer f.Close()
+//	fh, err := client.NewFileHeader("report.pdf", f)
+//	if err != nil {
+//		return err
+//	}
+//	payload := &client.UploadReportPayload{File: fh}
+func NewFileHeader(filename string, r io.Reader) (*multipart.FileHeader, error) {
+	// The standard library only creates file headers when reading forms, stream the content
+	// through a single file form so it is only copied once.
+	pr, pw := io.Pipe()
+	w := multipart.NewWriter(pw)
+	go func() {
+		fw, err := w.CreateFormFile("file", filename)
+		if err == nil {
+			_, err = io.Copy(fw, r)
+		}
+		if err == nil {
+			err = w.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+	form, err := multipart.NewReader(pr, w.Boundary()).ReadForm(math.MaxInt64)
+	pr.CloseWithError(io.ErrClosedPipe)
+	if err != nil {
+		return nil, err
+	}
+	return form.File["file"][0], nil
+}
+
+// WriteMultipartForm writes the fields of the struct pointed to by v as the parts of a
+// multipart/form-data body using w. The name of each part is read from the field "form" tag and
+// defaults to the field name. Nil fields are skipped, fields of type *multipart.FileHeader or
+// []*multipart.FileHeader are written as file parts, slices produce one part per element and
+// structs and maps are encoded to JSON. WriteMultipartForm does not close w.
+func WriteMultipartForm(w *multipart.Writer, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("cannot encode %T as a multipart form", v)
+	}
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue // unexported
+		}
+		name := field.Name
+		if tag := field.Tag.Get("form"); tag != "" {
+			name = strings.Split(tag, ",")[0]
+		}
+		if name == "-" {
+			continue
+		}
+		if err := writePart(w, name, rv.Field(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writePart writes the value v as one or more parts with the given name.
+func writePart(w *multipart.Writer, name string, v reflect.Value) error {
+	if v.Type() == fileHeaderType {
+		if v.IsNil() {
+			return nil
+		}
+		return writeFilePart(w, name, v.Interface().(*multipart.FileHeader))
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return writePart(w, name, v.Elem())
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return w.WriteField(name, string(v.Bytes()))
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := writePart(w, name, v.Index(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	val, err := formValue(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode part %#v: %s", name, err)
+	}
+	return w.WriteField(name, val)
+}
+
+// writeFilePart writes the content of fh as a file part with the given name.
+func writeFilePart(w *multipart.Writer, name string, fh *multipart.FileHeader) error {
+	contentType := fh.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name=%q; filename=%q`, name, fh.Filename))
+	h.Set("Content-Type", contentType)
+	pw, err := w.CreatePart(h)
+	if err != nil {
+		return err
+	}
+	f, err := fh.Open()
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(pw, f)
+	return err
+}
+
+// formValue returns the string representation of v.
+func formValue(v reflect.Value) (string, error) {
+	if v.Type() == uuidType {
+		u := v.Interface().(uuid.UUID)
+		return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:]), nil
+	}
+	if v.Type().Implements(textMarshaler) {
+		b, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(b), err
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	default:
+		b, err := json.Marshal(v.Interface())
+		return string(b), err
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"time"
 
 	"golang.org/x/net/context"
 )
@@ -19,6 +20,12 @@ const (
 	logContextKey
 	errKey
 	securityScopesKey
+	userKey
+	logLevelKey
+	actionTimeoutKey
+	fileServerKey
+	holdKey
+	maxBodyLengthKey
 )
 
 type (
@@ -30,6 +37,8 @@ type (
 		Payload interface{}
 		// Params is the path and querystring request parameters.
 		Params url.Values
+
+		user string // Identity of the authenticated user, see WithUser
 	}
 
 	// ResponseData provides access to the underlying HTTP response.
@@ -44,6 +53,8 @@ type (
 		Status int
 		// Length is the response body length.
 		Length int
+
+		metrics Metrics // Metrics used to count responses if not the package-level metrics
 	}
 
 	// key is the type used to store internal values in the context.
@@ -75,6 +86,30 @@ func WithLogger(ctx context.Context, logger LogAdapter) context.Context {
 	return context.WithValue(ctx, logKey, logger)
 }
 
+// WithLogLevel sets the minimum level of the messages logged with the request context and returns
+// the resulting new context.
+func WithLogLevel(ctx context.Context, level LogLevel) context.Context {
+	return context.WithValue(ctx, logLevelKey, level)
+}
+
+// WithActionTimeout sets the timeout of the action handling the request and returns the resulting
+// new context, see Controller.SetActionTimeout.
+func WithActionTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, actionTimeoutKey, timeout)
+}
+
+// WithFileServer records that the request is handled by a file server and returns the resulting
+// new context, see Controller.FileMuxHandler.
+func WithFileServer(ctx context.Context) context.Context {
+	return context.WithValue(ctx, fileServerKey, true)
+}
+
+// WithResponseData sets the response data of the request context and returns the resulting new
+// context. Middleware use it to make handlers write to a response other than the request one.
+func WithResponseData(ctx context.Context, resp *ResponseData) context.Context {
+	return context.WithValue(ctx, respKey, resp)
+}
+
 // WithLogContext instantiates a new logger by appending the given key/value pairs to the context
 // logger and setting the resulting logger in the context.
 func WithLogContext(ctx context.Context, keyvals ...interface{}) context.Context {
@@ -131,6 +166,29 @@ func ContextLogger(ctx context.Context) LogAdapter {
 	return nil
 }
 
+// ContextLogLevel extracts the minimum log level from the given context, it returns LevelInfo if
+// the context does not define one.
+func ContextLogLevel(ctx context.Context) LogLevel {
+	if l := ctx.Value(logLevelKey); l != nil {
+		return l.(LogLevel)
+	}
+	return LevelInfo
+}
+
+// ContextActionTimeout extracts the timeout of the action from the given context, it returns
+// false if the context does not define one.
+func ContextActionTimeout(ctx context.Context) (time.Duration, bool) {
+	t, ok := ctx.Value(actionTimeoutKey).(time.Duration)
+	return t, ok
+}
+
+// ContextFileServer returns true if the request is handled by a file server mounted with
+// ServeFiles or generated from the Files DSL.
+func ContextFileServer(ctx context.Context) bool {
+	files, _ := ctx.Value(fileServerKey).(bool)
+	return files
+}
+
 // ContextError extracts the error from the given context.
 func ContextError(ctx context.Context) error {
 	if err := ctx.Value(errKey); err != nil {
@@ -154,7 +212,7 @@ func (r *ResponseData) Written() bool {
 
 // WriteHeader records the response status code and calls the underlying writer.
 func (r *ResponseData) WriteHeader(status int) {
-	go IncrCounter([]string{"goa", "response", strconv.Itoa(status)}, 1.0)
+	go incrCounter(r.metrics, []string{"goa", "response", strconv.Itoa(status)}, 1.0)
 	r.Status = status
 	r.ResponseWriter.WriteHeader(status)
 }
@@ -164,3 +222,10 @@ func (r *ResponseData) Write(b []byte) (int, error) {
 	r.Length += len(b)
 	return r.ResponseWriter.Write(b)
 }
+
+// Flush sends any buffered data to the client if the underlying writer supports it.
+func (r *ResponseData) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
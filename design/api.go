@@ -124,6 +124,10 @@ var (
 	// Gob by default.
 	GobContentTypes = []string{"application/gob", "application/x-gob"}
 
+	// MultipartFormContentType is the Content-Type of the request bodies of actions that use
+	// MultipartForm.
+	MultipartFormContentType = "multipart/form-data"
+
 	// ErrorMediaIdentifier is the media type identifier used for error responses.
 	ErrorMediaIdentifier = "application/vnd.api.error+json"
 
@@ -147,6 +151,30 @@ var (
 		Views:      map[string]*ViewDefinition{"default": errorMediaView},
 	}
 
+	// ProblemMediaIdentifier is the media type identifier of RFC 7807 problem details.
+	ProblemMediaIdentifier = "application/problem+json"
+
+	// ProblemMedia is the built-in media type describing error responses of APIs that use
+	// ProblemErrors. The error metadata values are rendered as additional members.
+	ProblemMedia = &MediaTypeDefinition{
+		UserTypeDefinition: &UserTypeDefinition{
+			AttributeDefinition: &AttributeDefinition{
+				Type:        problemMediaType,
+				Description: "RFC 7807 problem details error response media type",
+				Example: map[string]interface{}{
+					"type":      "invalid_value",
+					"title":     "Bad Request",
+					"status":    400,
+					"detail":    "Value of ID must be an integer",
+					"timestamp": 1458609066,
+				},
+			},
+			TypeName: "Problem",
+		},
+		Identifier: ProblemMediaIdentifier,
+		Views:      map[string]*ViewDefinition{"default": problemMediaView},
+	}
+
 	errorMediaType = Object{
 		"id": &AttributeDefinition{
 			Type:        String,
@@ -182,6 +210,38 @@ var (
 		AttributeDefinition: &AttributeDefinition{Type: errorMediaType},
 		Name:                "default",
 	}
+
+	problemMediaType = Object{
+		"type": &AttributeDefinition{
+			Type:        String,
+			Description: "a URI reference that identifies the problem type, derived from the application-specific error code.",
+			Example:     "invalid_value",
+		},
+		"title": &AttributeDefinition{
+			Type:        String,
+			Description: "a short, human-readable summary of the problem type.",
+			Example:     "Bad Request",
+		},
+		"status": &AttributeDefinition{
+			Type:        Integer,
+			Description: "the HTTP status code applicable to this problem.",
+			Example:     400,
+		},
+		"detail": &AttributeDefinition{
+			Type:        String,
+			Description: "a human-readable explanation specific to this occurrence of the problem.",
+			Example:     "Value of ID must be an integer",
+		},
+		"instance": &AttributeDefinition{
+			Type:        String,
+			Description: "a URI reference that identifies the specific occurrence of the problem.",
+		},
+	}
+
+	problemMediaView = &ViewDefinition{
+		AttributeDefinition: &AttributeDefinition{Type: problemMediaType},
+		Name:                "default",
+	}
 )
 
 func init() {
@@ -197,6 +257,7 @@ func init() {
 		{MIMETypes: GobContentTypes, PackagePath: goa, Function: "NewGobDecoder"},
 	}
 	errorMediaView.Parent = ErrorMedia
+	problemMediaView.Parent = ProblemMedia
 }
 
 // CanonicalIdentifier returns the media type identifier sans suffix
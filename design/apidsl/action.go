@@ -304,6 +304,122 @@ func OptionalPayload(p interface{}, dsls ...func()) {
 	payload(true, p, dsls...)
 }
 
+// Stream indicates that the request or response body is streamed rather than read or written in
+// one go. Stream may be used in an Action DSL to stream the request body or in a Response DSL to
+// stream the response body.
+//
+// A streamed request body consists of newline delimited JSON elements each described by the action
+// payload, the generated action context exposes an iterator over the elements. If the action
+// defines no payload the context exposes the request body as an io.Reader instead. Similarly a
+// streamed response body consists of newline delimited JSON elements described by the response
+// media type if it is defined in the design. The generated response method writes the response
+// headers and returns a stream used to send the elements. Otherwise the method returns an
+// io.Writer for writing the raw response body. Example:
+//
+//	Action("import", func() {
+//		Routing(POST("/import"))
+//		Payload(BottlePayload)			// Each element of the stream is a BottlePayload
+//		Stream()
+//		Response(NoContent)
+//	})
+//
+//	Action("export", func() {
+//		Routing(GET("/export"))
+//		Response(OK, BottleMedia, func() {	// Each element of the stream is a BottleMedia
+//			Stream()
+//		})
+//	})
+//
+//	Action("upload", func() {
+//		Routing(PUT("/files/:name"))
+//		Stream()				// Request body is exposed as an io.Reader
+//		Response(NoContent)
+//	})
+func Stream() {
+	switch def := dslengine.CurrentDefinition().(type) {
+	case *design.ActionDefinition:
+		def.PayloadStream = true
+	case *design.ResponseDefinition:
+		def.Stream = true
+	default:
+		dslengine.IncompatibleDSL()
+	}
+}
+
+// MultipartForm indicates that the request body is encoded as multipart/form-data. Each part of
+// the body corresponds to a payload attribute, attributes of type File describe uploaded files and
+// are exposed as *multipart.FileHeader by the generated payload struct. Example:
+//
+//	Action("upload", func() {
+//		Routing(POST("/upload"))
+//		MultipartForm()
+//		Payload(func() {
+//			Member("file", File, "The uploaded file")
+//			Member("description", String)
+//			Required("file")
+//		})
+//		Response(NoContent)
+//	})
+func MultipartForm() {
+	if a, ok := actionDefinition(); ok {
+		a.PayloadMultipart = true
+	}
+}
+
+// Idempotent marks an action as idempotent: clients may retry requests made to the action by
+// sending the same Idempotency-Key header, the response to the first request is then replayed
+// instead of handling the request again. The generated code runs the middleware mounted with
+// UseIdempotencyMiddleware for the requests made to the action, see the middleware/idempotency
+// package. The middleware scopes the keys to the authenticated principal and rejects the keys
+// sent to actions that are not secured. Idempotent sets the "idempotent" metadata of the action.
+// Example:
+//
+//	Action("create", func() {
+//		Routing(POST(""))
+//		Payload(OrderPayload)
+//		Idempotent()
+//		Response(Created)
+//	})
+func Idempotent() {
+	if a, ok := actionDefinition(); ok {
+		if a.Metadata == nil {
+			a.Metadata = make(dslengine.MetadataDefinition)
+		}
+		a.Metadata["idempotent"] = []string{"true"}
+	}
+}
+
+// CacheControl sets the value of the Cache-Control header of the successful responses of an
+// action. CacheControl may also appear in a Resource DSL in which case the policy applies to all
+// the resource actions that do not define one. The directives are joined with commas. The policy
+// is stored in the "cache:control" metadata of the definition. Example:
+//
+//	Action("show", func() {
+//		Routing(GET("/:id"))
+//		CacheControl("public", "max-age=60")
+//		Response(OK, BottleMedia)
+//	})
+func CacheControl(directives ...string) {
+	if len(directives) == 0 {
+		dslengine.ReportError("CacheControl requires at least one directive")
+		return
+	}
+	var md *dslengine.MetadataDefinition
+	switch def := dslengine.CurrentDefinition().(type) {
+	case *design.ActionDefinition:
+		md = &def.Metadata
+	case *design.ResourceDefinition:
+		md = &def.Metadata
+	default:
+		dslengine.IncompatibleDSL()
+		return
+	}
+	if *md == nil {
+		*md = make(dslengine.MetadataDefinition)
+	}
+	(*md)["cache:control"] = directives
+}
+
 func payload(isOptional bool, p interface{}, dsls ...func()) {
 	if len(dsls) > 1 {
 		dslengine.ReportError("too many arguments given to Payload")
@@ -2,6 +2,7 @@ package apidsl_test
 
 import (
 	"strconv"
+	"time"
 
 	. "github.com/goadesign/goa/design"
 	. "github.com/goadesign/goa/design/apidsl"
@@ -91,6 +92,159 @@ var _ = Describe("Action", func() {
 		})
 	})
 
+	Context("with a streamed payload", func() {
+		BeforeEach(func() {
+			name = "foo"
+			dsl = func() {
+				Routing(POST("/"))
+				Payload(String)
+				Stream()
+			}
+		})
+
+		It("produces a valid action with a payload stream", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			Ω(action).ShouldNot(BeNil())
+			Ω(action.Validate()).ShouldNot(HaveOccurred())
+			Ω(action.PayloadStream).Should(BeTrue())
+			Ω(action.IsRawStream()).Should(BeFalse())
+		})
+
+		Context("with no payload", func() {
+			BeforeEach(func() {
+				dsl = func() {
+					Routing(POST("/"))
+					Stream()
+				}
+			})
+
+			It("produces a raw stream", func() {
+				Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+				Ω(action.IsRawStream()).Should(BeTrue())
+			})
+		})
+	})
+
+	Context("with a multipart form payload", func() {
+		BeforeEach(func() {
+			name = "upload"
+			dsl = func() {
+				Routing(POST("/"))
+				MultipartForm()
+				Payload(func() {
+					Member("file", File)
+					Member("description", String)
+					Required("file")
+				})
+			}
+		})
+
+		It("produces a valid multipart action", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			Ω(action).ShouldNot(BeNil())
+			Ω(action.Validate()).ShouldNot(HaveOccurred())
+			Ω(action.PayloadMultipart).Should(BeTrue())
+		})
+
+		Context("without MultipartForm", func() {
+			BeforeEach(func() {
+				dsl = func() {
+					Routing(POST("/"))
+					Payload(func() {
+						Member("file", File)
+					})
+				}
+			})
+
+			It("produces an invalid action", func() {
+				Ω(action.Validate()).Should(HaveOccurred())
+			})
+		})
+	})
+
+	Context("with a cache control policy", func() {
+		BeforeEach(func() {
+			name = "show"
+			dsl = func() {
+				Routing(GET("/:id"))
+				CacheControl("public", "max-age=60")
+			}
+		})
+
+		It("records the policy in the action metadata", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			Ω(action).ShouldNot(BeNil())
+			Ω(action.Metadata).Should(HaveKeyWithValue("cache:control", []string{"public", "max-age=60"}))
+			Ω(action.CacheControl()).Should(Equal("public, max-age=60"))
+		})
+	})
+
+	Context("marked idempotent", func() {
+		BeforeEach(func() {
+			name = "create"
+			dsl = func() {
+				Routing(POST(""))
+				Idempotent()
+			}
+		})
+
+		It("records the idempotent metadata", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			Ω(action).ShouldNot(BeNil())
+			Ω(action.Metadata).Should(HaveKey("idempotent"))
+			Ω(action.IsIdempotent()).Should(BeTrue())
+		})
+	})
+
+	Context("with a timeout", func() {
+		BeforeEach(func() {
+			name = "show"
+			dsl = func() {
+				Routing(GET("/:id"))
+				Metadata("timeout", "1m30s")
+			}
+		})
+
+		It("returns the timeout", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			Ω(action).ShouldNot(BeNil())
+			t, ok := action.Timeout()
+			Ω(ok).Should(BeTrue())
+			Ω(t).Should(Equal(90 * time.Second))
+		})
+
+		Context("that is zero", func() {
+			BeforeEach(func() {
+				dsl = func() {
+					Routing(GET("/:id"))
+					Metadata("timeout", "0")
+				}
+			})
+
+			It("disables the timeout", func() {
+				Ω(action.Validate()).ShouldNot(HaveOccurred())
+				t, ok := action.Timeout()
+				Ω(ok).Should(BeTrue())
+				Ω(t).Should(BeZero())
+			})
+		})
+
+		Context("that is not a valid duration", func() {
+			BeforeEach(func() {
+				dsl = func() {
+					Routing(GET("/:id"))
+					Metadata("timeout", "soon")
+				}
+			})
+
+			It("produces an invalid action", func() {
+				_, ok := action.Timeout()
+				Ω(ok).Should(BeFalse())
+				Ω(action.Validate()).Should(HaveOccurred())
+			})
+		})
+	})
+
 	Context("with a name and DSL defining a description, route, headers, payload and responses", func() {
 		const typeName = "typeName"
 		const description = "description"
@@ -305,3 +459,42 @@ var _ = Describe("Payload", func() {
 	})
 
 })
+
+var _ = Describe("CacheControl", func() {
+	var resource *ResourceDefinition
+
+	BeforeEach(func() {
+		dslengine.Reset()
+		resource = Resource("res", func() {
+			CacheControl("private", "max-age=10")
+			Action("show", func() {
+				Routing(GET("/:id"))
+			})
+			Action("list", func() {
+				Routing(GET(""))
+				CacheControl("no-store")
+			})
+		})
+		dslengine.Run()
+	})
+
+	It("applies the resource policy to the actions that do not define one", func() {
+		Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+		Ω(resource.Actions["show"].CacheControl()).Should(Equal("private, max-age=10"))
+		Ω(resource.Actions["list"].CacheControl()).Should(Equal("no-store"))
+	})
+
+	Context("used outside of a resource or action", func() {
+		BeforeEach(func() {
+			dslengine.Reset()
+			Type("type", func() {
+				CacheControl("no-cache")
+			})
+			dslengine.Run()
+		})
+
+		It("reports an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+		})
+	})
+})
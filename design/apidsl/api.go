@@ -50,6 +50,7 @@ import (
 //		Produces("application/json", func() {   // Custom encoder
 //			Package("github.com/goadesign/goa/encoding/json")
 //		})
+//		ProblemErrors()				// Render errors as RFC 7807 problem details
 //		ResponseTemplate("static", func() {	// Response template for use by actions
 //			Description("description")
 //			Status(404)
@@ -115,6 +116,8 @@ func Description(d string) {
 		def.Description = d
 	case *design.SecuritySchemeDefinition:
 		def.Description = d
+	case *design.ErrorDefinition:
+		def.Description = d
 	default:
 		dslengine.IncompatibleDSL()
 	}
@@ -377,6 +380,16 @@ func Produces(args ...interface{}) {
 	}
 }
 
+// ProblemErrors causes the API to render error responses as RFC 7807 problem details using the
+// application/problem+json media type instead of the default application/vnd.api.error+json media
+// type. The generated code configures the service ErrorFormatter accordingly and the generated
+// documentation describes error responses with the ProblemMedia media type.
+func ProblemErrors() {
+	if a, ok := apiDefinition(); ok {
+		a.ProblemErrors = true
+	}
+}
+
 // buildEncodingDefinition builds up an encoding definition.
 func buildEncodingDefinition(encoding bool, args ...interface{}) *design.EncodingDefinition {
 	var dsl func()
@@ -104,6 +104,19 @@ var _ = Describe("API", func() {
 			})
 		})
 
+		Context("with ProblemErrors", func() {
+			BeforeEach(func() {
+				dsl = func() {
+					ProblemErrors()
+				}
+			})
+
+			It("sets the API problem errors flag", func() {
+				Ω(Design.ProblemErrors).Should(BeTrue())
+				Ω(Design.ErrorMediaType()).Should(Equal(ProblemMedia))
+			})
+		})
+
 		Context("with a terms of service", func() {
 			const terms = "terms"
 
@@ -39,6 +39,27 @@ import (
 //
 //        Metadata("swagger:summary", "Short summary of what action does")
 //
+// `cache:control`: sets the Cache-Control header of the action successful responses, the values
+// are joined with commas. The policy set on a resource applies to the actions that do not define
+// one. See CacheControl.
+// Applicable to resources and actions.
+//
+//        Metadata("cache:control", "public", "max-age=60")
+//
+// `idempotent`: marks the action as idempotent so that the generated code runs the idempotency
+// middleware for its requests. See Idempotent.
+// Applicable to actions.
+//
+//        Metadata("idempotent")
+//
+// `timeout`: sets the duration after which the timeout middleware aborts the action requests, see
+// the middleware package StrictTimeout function. The timeout set on a resource applies to the
+// actions that do not define one. The value "0" disables the timeout of the action, e.g. for
+// actions that stream their responses.
+// Applicable to resources and actions.
+//
+//        Metadata("timeout", "30s")
+//
 // The special key names listed above may be used as follows:
 //
 //        var Account = Type("Account", func() {
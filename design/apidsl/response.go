@@ -108,6 +108,58 @@ func Response(name string, paramsAndDSL ...interface{}) {
 	}
 }
 
+// Error declares an error that actions may return. Error takes the name of the error which is
+// used as error code, the HTTP status of the corresponding responses and an optional DSL that may
+// set the error description. Error may appear in the API DSL in which case all the actions may
+// return the error, in a Resource DSL in which case all the resource actions may return the error
+// or in an Action DSL:
+//
+//	Action("show", func() {
+//		Routing(GET("/:id"))
+//		Error("not_found", 404)
+//		Error("archived", 410, func() {
+//			Description("Bottle has been archived")
+//		})
+//		Response(OK, BottleMedia)
+//	})
+//
+// goagen generates an error class in the app package for each error, the class is named after
+// the error (ErrNotFound and ErrArchived in the example above). The action contexts expose a
+// method per error that sends the corresponding error response (NotFoundError and ArchivedError).
+// Actions that may return an error get a response that uses the error media type for the error
+// status if they don't define one already.
+func Error(name string, status int, dsl ...func()) {
+	e := &design.ErrorDefinition{Name: name, Status: status}
+	var errs *map[string]*design.ErrorDefinition
+	switch def := dslengine.CurrentDefinition().(type) {
+	case *design.APIDefinition:
+		errs = &def.Errors
+		e.Parent = def
+	case *design.ResourceDefinition:
+		errs = &def.Errors
+		e.Parent = def
+	case *design.ActionDefinition:
+		errs = &def.Errors
+		e.Parent = def
+	default:
+		dslengine.IncompatibleDSL()
+		return
+	}
+	if *errs == nil {
+		*errs = make(map[string]*design.ErrorDefinition)
+	}
+	if _, ok := (*errs)[name]; ok {
+		dslengine.ReportError("error %s is defined twice", name)
+		return
+	}
+	if len(dsl) > 0 {
+		if !dslengine.Execute(dsl[0], e) {
+			return
+		}
+	}
+	(*errs)[name] = e
+}
+
 // Status sets the Response status.
 func Status(status int) {
 	if r, ok := responseDefinition(); ok {
@@ -115,6 +167,22 @@ func Status(status int) {
 	}
 }
 
+// ServerSentEvents indicates that the response body is a stream of server-sent events (content type
+// text/event-stream). The data of each event is described by the response media type if it is
+// defined in the design and is a string otherwise. The generated response method writes the
+// response headers and returns a stream used to send the events, it may set the event id, name
+// and retry fields. ServerSentEvents must appear in a Response DSL:
+//
+//	Response(OK, ProgressMedia, func() {
+//		ServerSentEvents()
+//	})
+func ServerSentEvents() {
+	if r, ok := responseDefinition(); ok {
+		r.Stream = true
+		r.Events = true
+	}
+}
+
 func executeResponseDSL(name string, paramsAndDSL ...interface{}) *design.ResponseDefinition {
 	var params []string
 	var dsl func()
@@ -76,6 +76,41 @@ var _ = Describe("Response", func() {
 		})
 	})
 
+	Context("with a stream", func() {
+		BeforeEach(func() {
+			name = "foo"
+			dsl = func() {
+				Status(200)
+				Media("application/octet-stream")
+				Stream()
+			}
+		})
+
+		It("produces a valid streamed response definition", func() {
+			Ω(res).ShouldNot(BeNil())
+			Ω(res.Validate()).ShouldNot(HaveOccurred())
+			Ω(res.Stream).Should(BeTrue())
+			Ω(res.IsRawStream()).Should(BeTrue())
+		})
+	})
+
+	Context("with server-sent events", func() {
+		BeforeEach(func() {
+			name = "foo"
+			dsl = func() {
+				Status(200)
+				ServerSentEvents()
+			}
+		})
+
+		It("produces a valid streamed response definition", func() {
+			Ω(res).ShouldNot(BeNil())
+			Ω(res.Validate()).ShouldNot(HaveOccurred())
+			Ω(res.Stream).Should(BeTrue())
+			Ω(res.Events).Should(BeTrue())
+		})
+	})
+
 	Context("with a type override", func() {
 		const status = 201
 
@@ -198,3 +233,125 @@ var _ = Describe("Response", func() {
 	})
 
 })
+
+var _ = Describe("Error", func() {
+	var dsl func()
+	var runErr error
+	var action *ActionDefinition
+	var problems bool
+
+	BeforeEach(func() {
+		dslengine.Reset()
+		dsl = nil
+		runErr = nil
+		action = nil
+		problems = false
+	})
+
+	JustBeforeEach(func() {
+		API("test", func() {
+			Error("unavailable", 503)
+			if problems {
+				ProblemErrors()
+			}
+		})
+		Resource("bottle", func() {
+			Error("archived", 410, func() {
+				Description("Bottle has been archived")
+			})
+			Action("show", func() {
+				Routing(GET("/:id"))
+				if dsl != nil {
+					dsl()
+				}
+			})
+		})
+		runErr = dslengine.Run()
+		if r, ok := Design.Resources["bottle"]; ok {
+			action = r.Actions["show"]
+		}
+	})
+
+	Context("defined at the API, resource and action levels", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				Error("not_found", 404)
+			}
+		})
+
+		It("sets the action errors", func() {
+			Ω(runErr).ShouldNot(HaveOccurred())
+			errs := action.AllErrors()
+			Ω(errs).Should(HaveLen(3))
+			Ω(errs[0].Name).Should(Equal("archived"))
+			Ω(errs[0].Status).Should(Equal(410))
+			Ω(errs[0].Description).Should(Equal("Bottle has been archived"))
+			Ω(errs[1].Name).Should(Equal("not_found"))
+			Ω(errs[2].Name).Should(Equal("unavailable"))
+		})
+
+		It("adds the error responses", func() {
+			Ω(action.Responses).Should(HaveKey("NotFound"))
+			Ω(action.Responses["NotFound"].Status).Should(Equal(404))
+			Ω(action.Responses["NotFound"].MediaType).Should(Equal(ErrorMediaIdentifier))
+			Ω(action.Responses).Should(HaveKey("Gone"))
+			Ω(action.Responses).Should(HaveKey("ServiceUnavailable"))
+		})
+	})
+
+	Context("in an API that uses ProblemErrors", func() {
+		BeforeEach(func() {
+			problems = true
+			dsl = func() {
+				Error("not_found", 404)
+				Response(BadRequest, ErrorMedia)
+			}
+		})
+
+		It("uses the problem media type for the error responses", func() {
+			Ω(runErr).ShouldNot(HaveOccurred())
+			Ω(action.Responses["NotFound"].MediaType).Should(Equal(ProblemMediaIdentifier))
+			Ω(action.Responses["NotFound"].Type).Should(Equal(ProblemMedia))
+			Ω(action.Responses["BadRequest"].MediaType).Should(Equal(ProblemMediaIdentifier))
+			Ω(Design.MediaTypes).Should(HaveKeyWithValue(CanonicalIdentifier(ProblemMediaIdentifier), ProblemMedia))
+		})
+	})
+
+	Context("with a response using the error status", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				Error("not_found", 404)
+				Response(NotFound)
+			}
+		})
+
+		It("keeps the response", func() {
+			Ω(runErr).ShouldNot(HaveOccurred())
+			Ω(action.Responses["NotFound"].MediaType).Should(BeEmpty())
+		})
+	})
+
+	Context("with an invalid status", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				Error("not_found", 200)
+			}
+		})
+
+		It("produces an error", func() {
+			Ω(runErr).Should(HaveOccurred())
+		})
+	})
+
+	Context("with a status that differs from the status of an error with the same name", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				Error("archived", 404)
+			}
+		})
+
+		It("produces an error", func() {
+			Ω(runErr).Should(HaveOccurred())
+		})
+	})
+})
@@ -6,6 +6,7 @@ import (
 	"path"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/dimfeld/httppath"
 	"github.com/goadesign/goa/dslengine"
@@ -71,6 +72,11 @@ type (
 		// resources and actions, unless overridden by Resource or
 		// Action-level Security() calls.
 		Security *SecurityDefinition
+		// ProblemErrors is true if the API renders errors as RFC 7807 problem details, see
+		// ProblemMedia.
+		ProblemErrors bool
+		// Errors that may be returned by all the API actions indexed by name
+		Errors map[string]*ErrorDefinition
 
 		// rand is the random generator used to generate examples.
 		rand *RandomGenerator
@@ -141,6 +147,8 @@ type (
 		// Security defines security requirements for the Resource,
 		// for actions that don't define one themselves.
 		Security *SecurityDefinition
+		// Errors that may be returned by all the resource actions indexed by name
+		Errors map[string]*ErrorDefinition
 	}
 
 	// CORSDefinition contains the definition for a specific origin CORS policy.
@@ -197,6 +205,23 @@ type (
 		Metadata dslengine.MetadataDefinition
 		// Standard is true if the response definition comes from the goa default responses
 		Standard bool
+		// Stream is true if the response body is written incrementally, see IsRawStream.
+		Stream bool
+		// Events is true if the response body is a stream of server-sent events.
+		Events bool
+	}
+
+	// ErrorDefinition defines an error that actions may return. The error name is used as the
+	// error code.
+	ErrorDefinition struct {
+		// Error name, e.g. "not_found"
+		Name string
+		// HTTP status of the error responses
+		Status int
+		// Error description
+		Description string
+		// Parent API, resource or action
+		Parent dslengine.Definition
 	}
 
 	// ResponseTemplateDefinition defines a response template.
@@ -238,12 +263,18 @@ type (
 		Payload *UserTypeDefinition
 		// PayloadOptional is true if the request payload is optional, false otherwise.
 		PayloadOptional bool
+		// PayloadStream is true if the request body is read incrementally, see IsRawStream.
+		PayloadStream bool
+		// PayloadMultipart is true if the request body is encoded as multipart/form-data.
+		PayloadMultipart bool
 		// Request headers that need to be made available to action
 		Headers *AttributeDefinition
 		// Metadata is a list of key/value pairs
 		Metadata dslengine.MetadataDefinition
 		// Security defines security requirements for the action
 		Security *SecurityDefinition
+		// Errors that may be returned by the action indexed by name
+		Errors map[string]*ErrorDefinition
 	}
 
 	// FileServerDefinition defines an endpoint that servers static assets.
@@ -628,6 +659,8 @@ func (a *APIDefinition) Finalize() {
 	if len(a.Produces) == 0 {
 		a.Produces = DefaultEncoders
 	}
+	a.finalizeMultipart()
+	a.finalizeErrors()
 	found := false
 	a.IterateResources(func(r *ResourceDefinition) error {
 		if found {
@@ -638,11 +671,11 @@ func (a *APIDefinition) Finalize() {
 				return nil
 			}
 			for _, resp := range action.Responses {
-				if resp.MediaType == ErrorMediaIdentifier {
+				if mt := a.ErrorMediaType(); resp.MediaType == mt.Identifier {
 					if a.MediaTypes == nil {
 						a.MediaTypes = make(map[string]*MediaTypeDefinition)
 					}
-					a.MediaTypes[CanonicalIdentifier(ErrorMediaIdentifier)] = ErrorMedia
+					a.MediaTypes[CanonicalIdentifier(mt.Identifier)] = mt
 					found = true
 					break
 				}
@@ -652,6 +685,86 @@ func (a *APIDefinition) Finalize() {
 	})
 }
 
+// finalizeErrors adds an error response to the actions that may return errors with a status that
+// none of their responses use. The error responses use the API error media type, responses that
+// use ErrorMedia in APIs that use ProblemErrors are changed to use ProblemMedia.
+func (a *APIDefinition) finalizeErrors() {
+	names := make(map[int]string, len(a.DefaultResponses))
+	for n, r := range a.DefaultResponses {
+		names[r.Status] = n
+	}
+	mt := a.ErrorMediaType()
+	a.IterateResources(func(r *ResourceDefinition) error {
+		return r.IterateActions(func(action *ActionDefinition) error {
+			if mt != ErrorMedia {
+				for _, resp := range action.Responses {
+					if resp.MediaType == ErrorMediaIdentifier {
+						resp.MediaType = mt.Identifier
+						resp.Type = mt
+					}
+				}
+			}
+			for _, e := range action.AllErrors() {
+				found := false
+				for _, resp := range action.Responses {
+					if resp.Status == e.Status {
+						found = true
+						break
+					}
+				}
+				if found {
+					continue
+				}
+				name, ok := names[e.Status]
+				if !ok {
+					name = fmt.Sprintf("Status%d", e.Status)
+				}
+				if action.Responses == nil {
+					action.Responses = make(map[string]*ResponseDefinition)
+				}
+				action.Responses[name] = &ResponseDefinition{
+					Name:        name,
+					Status:      e.Status,
+					Description: http.StatusText(e.Status),
+					MediaType:   mt.Identifier,
+					Type:        mt,
+					Parent:      action,
+				}
+			}
+			return nil
+		})
+	})
+}
+
+// AllErrors returns the errors defined at the API, resource and action levels sorted by name.
+func (a *APIDefinition) AllErrors() []*ErrorDefinition {
+	errs := make(map[string]*ErrorDefinition)
+	for n, e := range a.Errors {
+		errs[n] = e
+	}
+	a.IterateResources(func(r *ResourceDefinition) error {
+		for n, e := range r.Errors {
+			errs[n] = e
+		}
+		return r.IterateActions(func(action *ActionDefinition) error {
+			for n, e := range action.Errors {
+				errs[n] = e
+			}
+			return nil
+		})
+	})
+	return sortErrors(errs)
+}
+
+// ErrorMediaType returns the media type that describes the error responses of the API: ErrorMedia
+// or ProblemMedia if the API uses ProblemErrors.
+func (a *APIDefinition) ErrorMediaType() *MediaTypeDefinition {
+	if a.ProblemErrors {
+		return ProblemMedia
+	}
+	return ErrorMedia
+}
+
 // NewResourceDefinition creates a resource definition but does not
 // execute the DSL.
 func NewResourceDefinition(name string, dsl func()) *ResourceDefinition {
@@ -951,6 +1064,10 @@ func (a *AttributeDefinition) IsPrimitivePointer(attName string) bool {
 	if att == nil {
 		return false
 	}
+	if att.Type.Kind() == FileKind {
+		// File attributes are already pointers (*multipart.FileHeader).
+		return false
+	}
 	if att.Type.IsPrimitive() {
 		return !a.IsRequired(attName) && !a.HasDefaultValue(attName) && !a.IsNonZero(attName)
 	}
@@ -1188,6 +1305,13 @@ func (r *ResponseDefinition) Context() string {
 	return prefix + suffix
 }
 
+// IsRawStream returns true if the response body is streamed as raw bytes. Streamed response bodies
+// consist of newline delimited JSON elements if the response type or media type is defined in the
+// design and of raw bytes otherwise.
+func (r *ResponseDefinition) IsRawStream() bool {
+	return r.Stream && r.Type == nil && Design.MediaTypeWithIdentifier(r.MediaType) == nil
+}
+
 // Finalize sets the response media type from its type if the type is a media type and no media
 // type is already specified.
 func (r *ResponseDefinition) Finalize() {
@@ -1204,6 +1328,37 @@ func (r *ResponseDefinition) Finalize() {
 	r.MediaType = mt.Identifier
 }
 
+// Context returns the generic definition name used in error messages.
+func (e *ErrorDefinition) Context() string {
+	var prefix, suffix string
+	if e.Name != "" {
+		prefix = fmt.Sprintf("error %#v", e.Name)
+	} else {
+		prefix = "unnamed error"
+	}
+	if e.Parent != nil {
+		suffix = fmt.Sprintf(" of %s", e.Parent.Context())
+	}
+	return prefix + suffix
+}
+
+// sortErrors returns the given errors sorted by name.
+func sortErrors(errs map[string]*ErrorDefinition) []*ErrorDefinition {
+	if len(errs) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(errs))
+	for n := range errs {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	res := make([]*ErrorDefinition, len(names))
+	for i, n := range names {
+		res[i] = errs[n]
+	}
+	return res
+}
+
 // Dup returns a copy of the response definition.
 func (r *ResponseDefinition) Dup() *ResponseDefinition {
 	res := ResponseDefinition{
@@ -1211,6 +1366,8 @@ func (r *ResponseDefinition) Dup() *ResponseDefinition {
 		Status:      r.Status,
 		Description: r.Description,
 		MediaType:   r.MediaType,
+		Stream:      r.Stream,
+		Events:      r.Events,
 	}
 	if r.Headers != nil {
 		res.Headers = DupAtt(r.Headers)
@@ -1235,6 +1392,12 @@ func (r *ResponseDefinition) Merge(other *ResponseDefinition) {
 	if r.MediaType == "" {
 		r.MediaType = other.MediaType
 	}
+	if !r.Stream {
+		r.Stream = other.Stream
+	}
+	if !r.Events {
+		r.Events = other.Events
+	}
 	if other.Headers != nil {
 		otherHeaders := other.Headers.Type.ToObject()
 		if len(otherHeaders) > 0 {
@@ -1362,6 +1525,46 @@ func (a *ActionDefinition) EffectiveSchemes() []string {
 	return schemes
 }
 
+// CacheControl returns the value of the Cache-Control header of the action successful responses.
+// The policy is read from the "cache:control" metadata of the action or of its resource if the
+// action does not define one. CacheControl returns an empty string if neither define a policy.
+func (a *ActionDefinition) CacheControl() string {
+	if cc, ok := a.Metadata["cache:control"]; ok {
+		return strings.Join(cc, ", ")
+	}
+	if a.Parent != nil {
+		if cc, ok := a.Parent.Metadata["cache:control"]; ok {
+			return strings.Join(cc, ", ")
+		}
+	}
+	return ""
+}
+
+// IsIdempotent returns true if the action "idempotent" metadata is set, see the Idempotent DSL.
+func (a *ActionDefinition) IsIdempotent() bool {
+	_, ok := a.Metadata["idempotent"]
+	return ok
+}
+
+// Timeout returns the duration after which the generated code lets the timeout middleware abort
+// the action requests. The duration is read from the "timeout" metadata of the action or of its
+// resource if the action does not define one. A zero duration means that the action requests are
+// never aborted. Timeout returns false if neither define a valid duration.
+func (a *ActionDefinition) Timeout() (time.Duration, bool) {
+	t, ok := a.Metadata["timeout"]
+	if !ok && a.Parent != nil {
+		t, ok = a.Parent.Metadata["timeout"]
+	}
+	if !ok || len(t) == 0 {
+		return 0, false
+	}
+	d, err := time.ParseDuration(t[0])
+	if err != nil || d < 0 {
+		return 0, false
+	}
+	return d, true
+}
+
 // WebSocket returns true if the action scheme is "ws" or "wss" or both (directly or inherited
 // from the resource or API)
 func (a *ActionDefinition) WebSocket() bool {
@@ -1377,6 +1580,42 @@ func (a *ActionDefinition) WebSocket() bool {
 	return true
 }
 
+// finalizeMultipart adds the multipart/form-data decoder to the API decoders if any action accepts
+// multipart request bodies and no decoder was explicitly defined for it.
+func (a *APIDefinition) finalizeMultipart() {
+	multipart := false
+	a.IterateResources(func(r *ResourceDefinition) error {
+		return r.IterateActions(func(action *ActionDefinition) error {
+			multipart = multipart || action.PayloadMultipart
+			return nil
+		})
+	})
+	if !multipart {
+		return
+	}
+	for _, dec := range a.Consumes {
+		for _, m := range dec.MIMETypes {
+			if m == MultipartFormContentType {
+				return
+			}
+		}
+	}
+	consumes := make([]*EncodingDefinition, len(a.Consumes), len(a.Consumes)+1)
+	copy(consumes, a.Consumes)
+	a.Consumes = append(consumes, &EncodingDefinition{
+		MIMETypes:   []string{MultipartFormContentType},
+		PackagePath: "github.com/goadesign/goa",
+		Function:    "NewMultipartDecoder",
+	})
+}
+
+// IsRawStream returns true if the action request body is streamed as raw bytes. Streamed request
+// bodies consist of newline delimited JSON elements described by the payload if there is one and
+// of raw bytes otherwise.
+func (a *ActionDefinition) IsRawStream() bool {
+	return a.PayloadStream && a.Payload == nil
+}
+
 // Finalize inherits security scheme and action responses from parent and top level design.
 func (a *ActionDefinition) Finalize() {
 	// Inherit security scheme
@@ -1454,6 +1693,39 @@ func (a *ActionDefinition) IterateResponses(it ResponseIterator) error {
 	return nil
 }
 
+// AllErrors returns the errors the action may return sorted by name: the errors defined by the
+// API, the parent resource and the action itself. Errors defined by the action override the
+// errors of the same name defined by the resource which override the API errors.
+func (a *ActionDefinition) AllErrors() []*ErrorDefinition {
+	errs := make(map[string]*ErrorDefinition)
+	if Design != nil {
+		for n, e := range Design.Errors {
+			errs[n] = e
+		}
+	}
+	if a.Parent != nil {
+		for n, e := range a.Parent.Errors {
+			errs[n] = e
+		}
+	}
+	for n, e := range a.Errors {
+		errs[n] = e
+	}
+	return sortErrors(errs)
+}
+
+// ErrorsWithStatus returns the errors the action may return with the given status sorted by
+// name.
+func (a *ActionDefinition) ErrorsWithStatus(status int) []*ErrorDefinition {
+	var errs []*ErrorDefinition
+	for _, e := range a.AllErrors() {
+		if e.Status == status {
+			errs = append(errs, e)
+		}
+	}
+	return errs
+}
+
 // mergeResponses merges the parent resource and design responses.
 func (a *ActionDefinition) mergeResponses() {
 	for name, resp := range a.Responses {
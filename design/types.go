@@ -155,6 +155,8 @@ const (
 	UserTypeKind
 	// MediaTypeKind represents a media type.
 	MediaTypeKind
+	// FileKind represents a file uploaded in a multipart/form-data request body.
+	FileKind
 )
 
 const (
@@ -180,6 +182,10 @@ const (
 
 	// Any is the type for an arbitrary JSON value (interface{} in Go).
 	Any = Primitive(AnyKind)
+
+	// File is the type for a file part of a multipart/form-data request body (*multipart.FileHeader
+	// in Go). File may only be used in the payload of actions that use MultipartForm.
+	File = Primitive(FileKind)
 )
 
 // DataType implementation
@@ -200,6 +206,8 @@ func (p Primitive) Name() string {
 		return "string"
 	case Any:
 		return "any"
+	case File:
+		return "file"
 	default:
 		panic("unknown primitive type") // bug
 	}
@@ -240,7 +248,7 @@ func (p Primitive) CanHaveDefault() (ok bool) {
 
 // IsCompatible returns true if val is compatible with p.
 func (p Primitive) IsCompatible(val interface{}) bool {
-	if p != Boolean && p != Integer && p != Number && p != String && p != DateTime && p != UUID && p != Any {
+	if p != Boolean && p != Integer && p != Number && p != String && p != DateTime && p != UUID && p != Any && p != File {
 		panic("unknown primitive type") // bug
 	}
 	if p == Any {
@@ -289,6 +297,9 @@ func (p Primitive) GenerateExample(r *RandomGenerator) interface{} {
 	case Any:
 		// to not make it too complicated, pick one of the primitive types
 		return anyPrimitive[r.Int()%len(anyPrimitive)].GenerateExample(r)
+	case File:
+		// File contents can't be represented in examples.
+		return nil
 	default:
 		panic("unknown primitive type") // bug
 	}
@@ -687,7 +698,7 @@ func (m *MediaTypeDefinition) Kind() Kind { return MediaTypeKind }
 
 // IsBuiltIn returns true if the media type is implemented via a goa struct.
 func (m *MediaTypeDefinition) IsBuiltIn() bool {
-	return m.Identifier == ErrorMedia.Identifier
+	return m.Identifier == ErrorMedia.Identifier || m.Identifier == ProblemMedia.Identifier
 }
 
 // ComputeViews returns the media type views recursing as necessary if the media type is a
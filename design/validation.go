@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/goadesign/goa/dslengine"
 )
@@ -72,6 +73,7 @@ func (a *APIDefinition) Validate() error {
 	a.validateLicense(verr)
 	a.validateDocs(verr)
 	a.validateOrigins(verr)
+	a.validateErrors(verr)
 
 	var allRoutes []*routeInfo
 	a.IterateResources(func(r *ResourceDefinition) error {
@@ -156,6 +158,30 @@ func (a *APIDefinition) Validate() error {
 	return err
 }
 
+func (a *APIDefinition) validateErrors(verr *dslengine.ValidationErrors) {
+	statuses := make(map[string]*ErrorDefinition)
+	check := func(errs map[string]*ErrorDefinition) {
+		for _, e := range errs {
+			if e.Status < 400 || e.Status > 599 {
+				verr.Add(e, "invalid status %d, error status must be between 400 and 599", e.Status)
+			}
+			if other, ok := statuses[e.Name]; ok && other.Status != e.Status {
+				verr.Add(e, "status %d differs from status %d of %s", e.Status, other.Status, other.Context())
+				continue
+			}
+			statuses[e.Name] = e
+		}
+	}
+	check(a.Errors)
+	a.IterateResources(func(r *ResourceDefinition) error {
+		check(r.Errors)
+		return r.IterateActions(func(action *ActionDefinition) error {
+			check(action.Errors)
+			return nil
+		})
+	})
+}
+
 func (a *APIDefinition) validateContact(verr *dslengine.ValidationErrors) {
 	if a.Contact != nil && a.Contact.URL != "" {
 		if _, err := url.ParseRequestURI(a.Contact.URL); err != nil {
@@ -209,6 +235,7 @@ func (r *ResourceDefinition) Validate() *dslengine.ValidationErrors {
 	for _, origin := range r.Origins {
 		verr.Merge(origin.Validate())
 	}
+	validateTimeout(r, r.Metadata, verr)
 	return verr.AsError()
 }
 
@@ -347,6 +374,8 @@ func (a *ActionDefinition) Validate() *dslengine.ValidationErrors {
 	if a.Payload != nil {
 		verr.Merge(a.Payload.Validate("action payload", a))
 	}
+	verr.Merge(a.validateMultipart())
+	validateTimeout(a, a.Metadata, verr)
 	if a.Parent == nil {
 		verr.Add(a, "missing parent resource")
 	}
@@ -354,6 +383,54 @@ func (a *ActionDefinition) Validate() *dslengine.ValidationErrors {
 	return verr.AsError()
 }
 
+// validateTimeout checks that the "timeout" metadata if any is a positive duration or "0".
+func validateTimeout(def dslengine.Definition, md dslengine.MetadataDefinition, verr *dslengine.ValidationErrors) {
+	t, ok := md["timeout"]
+	if !ok {
+		return
+	}
+	if len(t) != 1 {
+		verr.Add(def, `"timeout" metadata must have exactly one value`)
+		return
+	}
+	if d, err := time.ParseDuration(t[0]); err != nil || d < 0 {
+		verr.Add(def, `invalid "timeout" metadata value %#v, must be a positive duration such as "5s" or "0"`, t[0])
+	}
+}
+
+// validateMultipart checks that multipart payloads are objects and that File attributes are only
+// used in multipart payloads.
+func (a *ActionDefinition) validateMultipart() *dslengine.ValidationErrors {
+	verr := new(dslengine.ValidationErrors)
+	if !a.PayloadMultipart {
+		if a.Payload != nil && a.Payload.IsObject() {
+			for n, att := range a.Payload.ToObject() {
+				if isFile(att.Type) {
+					verr.Add(a, "payload attribute %s is of type File, the action must use MultipartForm", n)
+				}
+			}
+		}
+		return verr.AsError()
+	}
+	if a.PayloadStream {
+		verr.Add(a, "MultipartForm and Stream cannot be used together")
+	}
+	if a.Payload == nil {
+		verr.Add(a, "MultipartForm requires a payload")
+	} else if !a.Payload.IsObject() {
+		verr.Add(a, "the payload of actions that use MultipartForm must be an object")
+	}
+	return verr.AsError()
+}
+
+// isFile returns true if t is File or an array of File.
+func isFile(t DataType) bool {
+	if a := t.ToArray(); a != nil {
+		return a.ElemType.Type.Kind() == FileKind
+	}
+	return t.Kind() == FileKind
+}
+
 // Validate checks the file server is properly initialized.
 func (f *FileServerDefinition) Validate() *dslengine.ValidationErrors {
 	verr := new(dslengine.ValidationErrors)
@@ -408,6 +485,8 @@ func (a *ActionDefinition) ValidateParams() *dslengine.ValidationErrors {
 			verr.Add(a, `parameter %s cannot be an object, only action payloads may be of type object`, n)
 		} else if p.Type.Kind() == HashKind {
 			verr.Add(a, `parameter %s cannot be a hash, only action payloads may be of type hash`, n)
+		} else if isFile(p.Type) {
+			verr.Add(a, `parameter %s cannot be a file, only multipart payloads may contain files`, n)
 		}
 		ctx := fmt.Sprintf("parameter %s", n)
 		verr.Merge(p.Validate(ctx, a))
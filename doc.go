@@ -108,7 +108,15 @@ Encoding
 The goa design language makes it possible to specify the encodings supported by the API both as
 input (Consumes) and output (Produces). goagen uses that information to registed the corresponding
 packages with the service encoders and decoders via their Register methods. The service exposes the
-DecodeRequest and EncodeResponse that implement a simple content type negotiation algorithm for
-picking the right encoder for the "Content-Type" (decoder) or "Accept" (encoder) request header.
+DecodeRequest and EncodeResponse that implement content type negotiation for picking the right
+decoder for the "Content-Type" request header and the right encoder for the "Accept" request header.
+Encoder negotiation follows RFC 7231: media ranges are weighted by their quality values and more
+specific ranges take precedence over wildcards. The service responds with 406 Not Acceptable when
+none of the registered encoders is acceptable unless a default encoder matching any content type
+is registered.
+
+Actions whose payload is described with the MultipartForm DSL accept multipart/form-data request
+bodies, goagen registers the MultipartDecoder for these automatically. File attributes of such
+payloads are exposed as *multipart.FileHeader values.
 */
 package goa
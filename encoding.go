@@ -7,6 +7,10 @@ import (
 	"fmt"
 	"io"
 	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
@@ -27,6 +31,22 @@ type (
 		Reset(r io.Reader)
 	}
 
+	// ParamsDecoder is implemented by decoders that make use of the parameters of the
+	// Content-Type of the decoded data, for example the boundary of multipart bodies.
+	// HTTPDecoder calls SetParams prior to calling Decode.
+	ParamsDecoder interface {
+		Decoder
+		SetParams(params map[string]string)
+	}
+
+	// RequestDecoder is implemented by decoders that make use of the decoded request, for
+	// example to release the resources allocated while decoding once the request has been
+	// handled. Service.DecodeRequest calls SetRequest prior to calling Decode.
+	RequestDecoder interface {
+		Decoder
+		SetRequest(req *http.Request)
+	}
+
 	// decoderPool smartly determines whether to instantiate a new Decoder or reuse one from a
 	// sync.Pool.
 	decoderPool struct {
@@ -59,15 +79,28 @@ type (
 	// HTTPDecoder is a Decoder that decodes HTTP request or response bodies given a set of
 	// known Content-Type to decoder mapping.
 	HTTPDecoder struct {
+		// Metrics records the decoding durations, the package-level metrics are used if nil.
+		Metrics Metrics
+
 		pools map[string]*decoderPool // Registered decoders
 	}
 
 	// HTTPEncoder is a Encoder that encodes HTTP request or response bodies given a set of
 	// known Content-Type to encoder mapping.
 	HTTPEncoder struct {
+		// Metrics records the encoding durations, the package-level metrics are used if nil.
+		Metrics Metrics
+
 		pools        map[string]*encoderPool // Registered encoders
 		contentTypes []string                // List of content types for type negotiation
 	}
+
+	// mediaRange is a media range parsed from an Accept header.
+	mediaRange struct {
+		typ, subtype string
+		q            float64
+		order        int
+	}
 )
 
 // NewJSONEncoder is an adapter for the encoding package JSON encoder.
@@ -104,15 +137,23 @@ func NewHTTPDecoder() *HTTPDecoder {
 
 // Decode uses registered Decoders to unmarshal a body based on the contentType.
 func (decoder *HTTPDecoder) Decode(v interface{}, body io.Reader, contentType string) error {
+	return decoder.decode(v, body, contentType, nil)
+}
+
+// decode implements Decode, req is the decoded request if any.
+func (decoder *HTTPDecoder) decode(v interface{}, body io.Reader, contentType string, req *http.Request) error {
 	now := time.Now()
-	defer MeasureSince([]string{"goa", "decode", contentType}, now)
-	var p *decoderPool
+	defer measureSince(decoder.Metrics, []string{"goa", "decode", contentType}, now)
+	var (
+		p      *decoderPool
+		params map[string]string
+	)
 	if contentType == "" {
 		// Default to JSON
 		contentType = "application/json"
 	} else {
-		if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
-			contentType = mediaType
+		if mediaType, ps, err := mime.ParseMediaType(contentType); err == nil {
+			contentType, params = mediaType, ps
 		}
 	}
 	p = decoder.pools[contentType]
@@ -126,6 +167,12 @@ func (decoder *HTTPDecoder) Decode(v interface{}, body io.Reader, contentType st
 	// the decoderPool will handle whether or not a pool is actually in use
 	d := p.Get(body)
 	defer p.Put(d)
+	if pd, ok := d.(ParamsDecoder); ok {
+		pd.SetParams(params)
+	}
+	if rd, ok := d.(RequestDecoder); ok {
+		rd.SetRequest(req)
+	}
 	if err := d.Decode(v); err != nil {
 		return err
 	}
@@ -186,22 +233,84 @@ func (p *decoderPool) Put(d Decoder) {
 	p.pool.Put(d)
 }
 
-// Encode uses the registered encoders and given content type to marshal and write the given value
-// using the given writer.
+// Encode uses the registered encoders and given accept header value to marshal and write the
+// given value using the given writer. See Negotiate for details on how the encoder is picked.
 func (encoder *HTTPEncoder) Encode(v interface{}, resp io.Writer, accept string) error {
-	now := time.Now()
+	contentType, err := encoder.Negotiate(accept, "")
+	if err != nil {
+		return err
+	}
+	return encoder.encode(v, resp, contentType)
+}
+
+// Negotiate returns the registered content type that best matches the given Accept and
+// Accept-Charset request header values following RFC 7231. The returned value is "*/*" when the
+// default encoder (the encoder registered for "*/*") should be used, that is when the Accept header
+// is empty or when the best match is due to a "*/*" media range.
+//
+// The content types registered with Register (other than "*/*") make up the list of media types
+// that the service may produce. If none of them is acceptable Negotiate returns "*/*" if a default
+// encoder is registered and an error of class ErrNotAcceptable otherwise. Encoders are assumed to
+// produce UTF-8 encoded content so that an Accept-Charset header that excludes UTF-8 also produces
+// an ErrNotAcceptable error.
+func (encoder *HTTPEncoder) Negotiate(accept, acceptCharset string) (string, error) {
+	_, hasDefault := encoder.pools["*/*"]
+	if len(encoder.pools) == 0 {
+		return "", fmt.Errorf("No encoder registered for %s and no default encoder", accept)
+	}
+	if acceptCharset != "" && !acceptsUTF8(acceptCharset) {
+		return "", ErrNotAcceptable("cannot produce content using charsets %s", acceptCharset).
+			Meta("accept-charset", acceptCharset)
+	}
 	if accept == "" {
 		accept = "*/*"
 	}
-	var contentType string
-	for _, t := range encoder.contentTypes {
-		if accept == "*/*" || accept == t {
-			contentType = accept
-			break
+	ranges := parseAccept(accept)
+	var (
+		best            string
+		bestQ           float64
+		bestSpecificity int
+		bestOrder       int
+	)
+	for _, ct := range encoder.contentTypes {
+		if ct == "*/*" {
+			continue
+		}
+		q, specificity, order := matchMediaRanges(ranges, ct)
+		if q <= 0 {
+			continue
 		}
+		if best == "" || q > bestQ || q == bestQ && (specificity > bestSpecificity ||
+			specificity == bestSpecificity && order < bestOrder) {
+			best, bestQ, bestSpecificity, bestOrder = ct, q, specificity, order
+		}
+	}
+	if hasDefault && (best == "" || bestSpecificity == 1) {
+		return "*/*", nil
 	}
-	defer MeasureSince([]string{"goa", "encode", contentType}, now)
+	if best == "" {
+		available := make([]string, 0, len(encoder.contentTypes))
+		for _, ct := range encoder.contentTypes {
+			if ct != "*/*" {
+				available = append(available, ct)
+			}
+		}
+		return "", ErrNotAcceptable("cannot produce content of type %s, must be one of %s",
+			accept, strings.Join(available, ", ")).Meta("accept", accept)
+	}
+	return best, nil
+}
+
+// encode marshals and writes the given value using the encoder registered for the given content
+// type. JSON based content types that have no registered encoder such as the error media types
+// are encoded with the JSON encoder, other content types fall back to the default encoder.
+func (encoder *HTTPEncoder) encode(v interface{}, resp io.Writer, contentType string) error {
+	now := time.Now()
+	defer measureSince(encoder.Metrics, []string{"goa", "encode", contentType}, now)
 	p := encoder.pools[contentType]
+	if p == nil && isJSON(contentType) {
+		p = jsonEncodePool
+	}
 	if p == nil && contentType != "*/*" {
 		p = encoder.pools["*/*"]
 	}
@@ -236,6 +345,96 @@ func (encoder *HTTPEncoder) Register(f EncoderFunc, contentTypes ...string) {
 	for contentType := range encoder.pools {
 		encoder.contentTypes = append(encoder.contentTypes, contentType)
 	}
+	sort.Strings(encoder.contentTypes)
+}
+
+// parseAccept parses the media ranges listed in an Accept header value. Invalid media ranges are
+// ignored.
+func parseAccept(accept string) []*mediaRange {
+	var ranges []*mediaRange
+	for i, elem := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(elem))
+		if err != nil {
+			continue
+		}
+		slash := strings.Index(mediaType, "/")
+		if slash == -1 {
+			continue
+		}
+		r := &mediaRange{typ: mediaType[:slash], subtype: mediaType[slash+1:], q: 1, order: i}
+		if q, ok := params["q"]; ok {
+			if r.q, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges
+}
+
+// matchMediaRanges returns the quality value of the most specific media range that matches the
+// given content type together with its specificity (3 for type/subtype, 2 for type/* and 1 for
+// */*) and its position in the Accept header. The quality value is 0 if no range matches.
+func matchMediaRanges(ranges []*mediaRange, contentType string) (q float64, specificity, order int) {
+	slash := strings.Index(contentType, "/")
+	if slash == -1 {
+		return 0, 0, 0
+	}
+	typ, subtype := contentType[:slash], contentType[slash+1:]
+	for _, r := range ranges {
+		var s int
+		switch {
+		case r.typ == typ && r.subtype == subtype:
+			s = 3
+		case r.typ == typ && r.subtype == "*":
+			s = 2
+		case r.typ == "*" && r.subtype == "*":
+			s = 1
+		default:
+			continue
+		}
+		if s > specificity {
+			q, specificity, order = r.q, s, r.order
+		}
+	}
+	return
+}
+
+// acceptsUTF8 returns true if the given Accept-Charset header value allows UTF-8 encoded content.
+func acceptsUTF8(acceptCharset string) bool {
+	q := -1.0
+	for _, elem := range strings.Split(acceptCharset, ",") {
+		parts := strings.Split(elem, ";")
+		charset := strings.ToLower(strings.TrimSpace(parts[0]))
+		if charset != "utf-8" && charset != "*" {
+			continue
+		}
+		cq := 1.0
+		for _, p := range parts[1:] {
+			if kv := strings.SplitN(strings.TrimSpace(p), "=", 2); len(kv) == 2 && kv[0] == "q" {
+				if v, err := strconv.ParseFloat(kv[1], 64); err == nil {
+					cq = v
+				}
+			}
+		}
+		if charset == "utf-8" || q < 0 {
+			q = cq
+		}
+		if charset == "utf-8" {
+			break
+		}
+	}
+	return q > 0
+}
+
+// jsonEncodePool is the pool used to encode JSON based content types that have no registered
+// encoder.
+var jsonEncodePool = newEncodePool(NewJSONEncoder)
+
+// isJSON returns true if the given media type is application/json or uses the +json structured
+// syntax suffix.
+func isJSON(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
 }
 
 // newEncodePool checks to see if the EncoderFactory returns reusable encoders and if so, creates
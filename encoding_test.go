@@ -0,0 +1,138 @@
+package goa_test
+
+import (
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("HTTPEncoder", func() {
+	var encoder *goa.HTTPEncoder
+	var accept, acceptCharset string
+	var contentType string
+	var err error
+
+	BeforeEach(func() {
+		encoder = goa.NewHTTPEncoder()
+		encoder.Register(goa.NewJSONEncoder, "application/json", "*/*")
+		encoder.Register(goa.NewXMLEncoder, "application/xml", "text/xml")
+		accept = ""
+		acceptCharset = ""
+	})
+
+	JustBeforeEach(func() {
+		contentType, err = encoder.Negotiate(accept, acceptCharset)
+	})
+
+	Context("with no Accept header", func() {
+		It("uses the default encoder", func() {
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(contentType).Should(Equal("*/*"))
+		})
+	})
+
+	Context("with an exact match", func() {
+		BeforeEach(func() {
+			accept = "application/xml"
+		})
+
+		It("picks the matching content type", func() {
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(contentType).Should(Equal("application/xml"))
+		})
+	})
+
+	Context("with quality values", func() {
+		BeforeEach(func() {
+			accept = "application/json;q=0.5, text/xml;q=0.8, */*;q=0.1"
+		})
+
+		It("picks the content type with the highest quality", func() {
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(contentType).Should(Equal("text/xml"))
+		})
+	})
+
+	Context("with a more specific range excluding a content type", func() {
+		BeforeEach(func() {
+			encoder = goa.NewHTTPEncoder()
+			encoder.Register(goa.NewXMLEncoder, "application/xml", "text/xml")
+			accept = "text/*, text/xml;q=0"
+		})
+
+		It("honors the most specific range", func() {
+			Ω(err).Should(HaveOccurred())
+			Ω(err.(*goa.Error).Status).Should(Equal(406))
+		})
+	})
+
+	Context("with a subtype wildcard", func() {
+		BeforeEach(func() {
+			accept = "application/*;q=0.9, application/xml;q=0.2"
+		})
+
+		It("picks a matching content type", func() {
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(contentType).Should(Equal("application/json"))
+		})
+	})
+
+	Context("with no acceptable content type", func() {
+		BeforeEach(func() {
+			accept = "image/png"
+		})
+
+		It("uses the default encoder", func() {
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(contentType).Should(Equal("*/*"))
+		})
+
+		Context("and no default encoder", func() {
+			BeforeEach(func() {
+				encoder = goa.NewHTTPEncoder()
+				encoder.Register(goa.NewJSONEncoder, "application/json")
+			})
+
+			It("returns a not acceptable error", func() {
+				Ω(err).Should(HaveOccurred())
+				Ω(err.(*goa.Error).Code).Should(Equal("not_acceptable"))
+			})
+		})
+	})
+
+	Context("with only a default encoder", func() {
+		BeforeEach(func() {
+			encoder = goa.NewHTTPEncoder()
+			encoder.Register(goa.NewJSONEncoder, "*/*")
+			accept = "image/png"
+		})
+
+		It("uses the default encoder", func() {
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(contentType).Should(Equal("*/*"))
+		})
+	})
+
+	Context("with an Accept-Charset header", func() {
+		Context("that accepts UTF-8", func() {
+			BeforeEach(func() {
+				acceptCharset = "iso-8859-1;q=0.5, UTF-8"
+			})
+
+			It("succeeds", func() {
+				Ω(err).ShouldNot(HaveOccurred())
+			})
+		})
+
+		Context("that excludes UTF-8", func() {
+			BeforeEach(func() {
+				acceptCharset = "iso-8859-1, *;q=0"
+			})
+
+			It("returns a not acceptable error", func() {
+				Ω(err).Should(HaveOccurred())
+				Ω(err.(*goa.Error).Code).Should(Equal("not_acceptable"))
+			})
+		})
+	})
+})
@@ -14,18 +14,28 @@ Class functions. See http://goa.design/implement/error_handling.html
 The code generated by goagen calls the helper functions exposed in this file when it encounters
 invalid data (wrong type, validation errors etc.) such as InvalidParamTypeError,
 InvalidAttributeTypeError etc. These methods return errors that get merged with any previously
-encountered error via the Error Merge method.
+encountered error via the Error Merge method. The errors record the paths of the invalid fields
+together with the failing validation rules in their metadata, see FieldError. They also record the
+key and the arguments of their message so that the error handler middleware can localize them
+using the service Translator and the request Accept-Language header, see MessageCatalog.
 
 goa includes an error handler middleware that takes care of mapping back any error returned by
 previously called middleware or action handler into HTTP responses. If the error is an instance
 of Error then the corresponding content including the HTTP status is used otherwise an internal
 error is returned. Errors that bubble up all the way to the top (i.e. not handled by the error
 middleware) also generate an internal error response.
+
+Errors created from another error retain it as their cause, the cause is never sent to clients
+but is logged by the error handler middleware together with the rest of the cause chain, see
+ErrorCauses. Set CaptureErrorStack to also record the stack of errors when they are created.
 */
 package goa
 
 import (
+	"bytes"
 	"fmt"
+	"net/http"
+	"runtime"
 	"strings"
 )
 
@@ -58,10 +68,26 @@ var (
 	// ErrNotFound is the error returned to requests that don't match a registered handler.
 	ErrNotFound = NewErrorClass("not_found", 404)
 
+	// ErrMethodNotAllowed is the error returned to requests whose path matches a registered
+	// handler but whose method does not.
+	ErrMethodNotAllowed = NewErrorClass("method_not_allowed", 405)
+
+	// ErrNotAcceptable is the error produced when none of the media types the service
+	// produces satisfy the request Accept or Accept-Charset headers.
+	ErrNotAcceptable = NewErrorClass("not_acceptable", 406)
+
 	// ErrInternal is the class of error used for uncaught errors.
 	ErrInternal = NewErrorClass("internal", 500)
 )
 
+// FieldErrorsMeta is the error metadata key that holds the list of field errors of validation
+// errors, see FieldError.
+const FieldErrorsMeta = "errors"
+
+// CaptureErrorStack causes the errors created by error classes to record the stack of the
+// caller. Capturing stacks has a cost so it is disabled by default.
+var CaptureErrorStack bool
+
 type (
 	// Error contains the details of a error response.
 	Error struct {
@@ -73,66 +99,124 @@ type (
 		Detail string `json:"detail" xml:"detail" form:"detail"`
 		// MetaValues contains additional key/value pairs useful to clients.
 		MetaValues map[string]interface{} `json:"meta,omitempty" xml:"meta,omitempty" form:"meta,omitempty"`
+
+		cause error     // Underlying error if any, not serialized
+		stack []uintptr // Program counters of the stack where the error was created if any
+	}
+
+	// FieldError describes a validation rule that a request parameter, header or payload field
+	// fails to satisfy. The errors produced by the validation helper functions record their
+	// field errors in their metadata under the FieldErrorsMeta key, merging errors concatenates
+	// the lists.
+	FieldError struct {
+		// Path is the path to the field, e.g. "payload.items[2].name".
+		Path string `json:"path" xml:"path" form:"path"`
+		// Rule is the validation rule that fails, one of "required", "type", "enum",
+		// "format", "pattern", "minimum", "maximum", "min_length" or "max_length".
+		Rule string `json:"rule" xml:"rule" form:"rule"`
+		// Expected is the value defined by the rule if any, e.g. the minimum value or the
+		// list of allowed values.
+		Expected interface{} `json:"expected,omitempty" xml:"expected,omitempty" form:"expected,omitempty"`
+	}
+
+	// causer is the interface implemented by errors that wrap another error and expose it via
+	// Cause, such as the errors created by the github.com/pkg/errors package.
+	causer interface {
+		Cause() error
+	}
+
+	// wrapper is the interface implemented by errors that wrap another error and expose it via
+	// Unwrap.
+	wrapper interface {
+		Unwrap() error
 	}
 
 	// ErrorClass is an error generating function.
 	// It accepts a format and values and produces errors with the resulting string.
 	// If the format is a string or a Stringer then the string value is used.
-	// If the format is an error then the string returned by Error() is used.
+	// If the format is an error then the error string is used and the error becomes the cause of
+	// the resulting error, use Wrap to keep the cause message out of the responses.
 	// Otherwise the string produced using fmt.Sprintf("%v") is used.
 	ErrorClass func(fm interface{}, v ...interface{}) *Error
 )
 
 // NewErrorClass creates a new error class.
 // It is the responsability of the client to guarantee uniqueness of code.
+// If the format given to the class function is an error then it is retained as the cause of the
+// resulting error, see ErrorClass.
 func NewErrorClass(code string, status int) ErrorClass {
 	return func(fm interface{}, v ...interface{}) *Error {
-		var f string
+		var (
+			detail string
+			cause  error
+		)
 		switch actual := fm.(type) {
 		case string:
-			f = actual
+			detail = fmt.Sprintf(actual, v...)
+		case *Error:
+			detail, cause = actual.Detail, actual
 		case error:
-			f = actual.Error()
+			detail, cause = actual.Error(), actual
 		case fmt.Stringer:
-			f = actual.String()
+			detail = fmt.Sprintf(actual.String(), v...)
 		default:
-			f = fmt.Sprintf("%v", actual)
+			detail = fmt.Sprintf(fmt.Sprintf("%v", actual), v...)
 		}
-		return &Error{Code: code, Status: status, Detail: fmt.Sprintf(f, v...)}
+		e := &Error{Code: code, Status: status, Detail: detail, cause: cause}
+		if CaptureErrorStack {
+			e.stack = callers(3)
+		}
+		return e
 	}
 }
 
+// Wrap creates an error of the class whose cause is err and whose detail is the HTTP status text
+// of the class so that the cause message is only logged and never sent to clients. Use the class
+// function directly to send the cause message, e.g. ErrBadRequest(err).
+func (class ErrorClass) Wrap(err error) *Error {
+	e := class("")
+	e.Detail = http.StatusText(e.Status)
+	if e.Detail == "" {
+		e.Detail = e.Code
+	}
+	e.cause = err
+	if CaptureErrorStack {
+		e.stack = callers(3)
+	}
+	return e
+}
+
 // MissingPayloadError is the error produced when a request is missing a required payload.
 func MissingPayloadError() *Error {
-	return ErrInvalidRequest("missing required payload")
+	return invalidRequest(MsgMissingPayload).withField("payload", "required", nil)
 }
 
 // InvalidParamTypeError is the error produced when the type of a parameter does not match the type
 // defined in the design.
 func InvalidParamTypeError(name string, val interface{}, expected string) *Error {
-	return ErrInvalidRequest("invalid value %#v for parameter %#v, must be a %s", val, name, expected)
+	return invalidRequest(MsgInvalidParamType, val, name, expected).withField(name, "type", expected)
 }
 
 // MissingParamError is the error produced for requests that are missing path or querystring
 // parameters.
 func MissingParamError(name string) *Error {
-	return ErrInvalidRequest("missing required parameter %#v", name)
+	return invalidRequest(MsgMissingParam, name).withField(name, "required", nil)
 }
 
 // InvalidAttributeTypeError is the error produced when the type of payload field does not match
 // the type defined in the design.
 func InvalidAttributeTypeError(ctx string, val interface{}, expected string) *Error {
-	return ErrInvalidRequest("type of %s must be %s but got value %#v", ctx, expected, val)
+	return invalidRequest(MsgInvalidAttributeType, ctx, expected, val).withField(ctx, "type", expected)
 }
 
 // MissingAttributeError is the error produced when a request payload is missing a required field.
 func MissingAttributeError(ctx, name string) *Error {
-	return ErrInvalidRequest("attribute %#v of %s is missing and required", name, ctx)
+	return invalidRequest(MsgMissingAttribute, name, ctx).withField(ctx+"."+name, "required", nil)
 }
 
 // MissingHeaderError is the error produced when a request is missing a required header.
 func MissingHeaderError(name string) *Error {
-	return ErrInvalidRequest("missing required HTTP header %#v", name)
+	return invalidRequest(MsgMissingHeader, name).withField(name, "required", nil)
 }
 
 // InvalidEnumValueError is the error produced when the value of a parameter or payload field does
@@ -142,39 +226,40 @@ func InvalidEnumValueError(ctx string, val interface{}, allowed []interface{}) *
 	for i, a := range allowed {
 		elems[i] = fmt.Sprintf("%#v", a)
 	}
-	return ErrInvalidRequest("value of %s must be one of %s but got value %#v", ctx, strings.Join(elems, ", "), val)
+	return invalidRequest(MsgInvalidEnumValue, ctx, strings.Join(elems, ", "), val).withField(ctx, "enum", allowed)
 }
 
 // InvalidFormatError is the error produced when the value of a parameter or payload field does not
 // match the format validation defined in the design.
 func InvalidFormatError(ctx, target string, format Format, formatError error) *Error {
-	return ErrInvalidRequest("%s must be formatted as a %s but got value %#v, %s", ctx, format, target, formatError.Error())
+	return invalidRequest(MsgInvalidFormat, ctx, string(format), target, formatError.Error()).
+		withField(ctx, "format", string(format))
 }
 
 // InvalidPatternError is the error produced when the value of a parameter or payload field does
 // not match the pattern validation defined in the design.
 func InvalidPatternError(ctx, target string, pattern string) *Error {
-	return ErrInvalidRequest("%s must match the regexp %#v but got value %#v", ctx, pattern, target)
+	return invalidRequest(MsgInvalidPattern, ctx, pattern, target).withField(ctx, "pattern", pattern)
 }
 
 // InvalidRangeError is the error produced when the value of a parameter or payload field does
 // not match the range validation defined in the design.
 func InvalidRangeError(ctx string, target interface{}, value int, min bool) *Error {
-	comp := "greater or equal"
+	key, rule := MsgInvalidRangeMin, "minimum"
 	if !min {
-		comp = "lesser or equal"
+		key, rule = MsgInvalidRangeMax, "maximum"
 	}
-	return ErrInvalidRequest("%s must be %s than %d but got value %#v", ctx, comp, value, target)
+	return invalidRequest(key, ctx, value, target).withField(ctx, rule, value)
 }
 
 // InvalidLengthError is the error produced when the value of a parameter or payload field does
 // not match the length validation defined in the design.
 func InvalidLengthError(ctx string, target interface{}, ln, value int, min bool) *Error {
-	comp := "greater or equal"
+	key, rule := MsgInvalidLengthMin, "min_length"
 	if !min {
-		comp = "lesser or equal"
+		key, rule = MsgInvalidLengthMax, "max_length"
 	}
-	return ErrInvalidRequest("length of %s must be %s than %d but got value %#v (len=%d)", ctx, comp, value, target, ln)
+	return invalidRequest(key, ctx, value, target, ln).withField(ctx, rule, value)
 }
 
 // NoAuthMiddleware is the error produced when goa is unable to lookup a auth middleware for a
@@ -204,6 +289,96 @@ func (e *Error) Meta(keyvals ...interface{}) *Error {
 	return e
 }
 
+// FieldErrors returns the field errors recorded in the error metadata. FieldErrors also reads the
+// field errors of errors decoded from responses.
+func (e *Error) FieldErrors() []*FieldError {
+	switch fes := e.MetaValues[FieldErrorsMeta].(type) {
+	case []*FieldError:
+		return fes
+	case []interface{}:
+		res := make([]*FieldError, 0, len(fes))
+		for _, fe := range fes {
+			m, ok := fe.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			path, _ := m["path"].(string)
+			rule, _ := m["rule"].(string)
+			res = append(res, &FieldError{Path: path, Rule: rule, Expected: m["expected"]})
+		}
+		return res
+	}
+	return nil
+}
+
+// withField records a field error in the error metadata.
+func (e *Error) withField(path, rule string, expected interface{}) *Error {
+	return e.Meta(FieldErrorsMeta, []*FieldError{{Path: path, Rule: rule, Expected: expected}})
+}
+
+// WithCause sets the underlying cause of the error. The cause is not serialized in responses.
+func (e *Error) WithCause(cause error) *Error {
+	e.cause = cause
+	return e
+}
+
+// Cause returns the underlying cause of the error if any, nil otherwise.
+func (e *Error) Cause() error {
+	return e.cause
+}
+
+// Unwrap returns the underlying cause of the error if any, nil otherwise.
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// WithStack records the stack of the caller, replacing any stack recorded when the error was
+// created.
+func (e *Error) WithStack() *Error {
+	e.stack = callers(3)
+	return e
+}
+
+// Stack returns a human readable representation of the stack recorded when the error was created
+// or when WithStack was called, the empty string if there is none.
+func (e *Error) Stack() string {
+	if len(e.stack) == 0 {
+		return ""
+	}
+	var buf bytes.Buffer
+	frames := runtime.CallersFrames(e.stack)
+	for {
+		frame, more := frames.Next()
+		fmt.Fprintf(&buf, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		if !more {
+			break
+		}
+	}
+	return buf.String()
+}
+
+// ErrorCauses returns the chain of errors that starts with err followed by its cause, the cause
+// of its cause etc. The chain follows the Cause and Unwrap methods of the errors that implement
+// them. ErrorCauses returns nil if err is nil.
+func ErrorCauses(err error) []error {
+	var chain []error
+	for err != nil {
+		chain = append(chain, err)
+		if len(chain) > 100 {
+			break // Guard against cycles
+		}
+		switch actual := err.(type) {
+		case causer:
+			err = actual.Cause()
+		case wrapper:
+			err = actual.Unwrap()
+		default:
+			err = nil
+		}
+	}
+	return chain
+}
+
 // MergeErrors updates an error by merging another into it. It first converts other into an Error
 // if not already one - producing an internal error in that case. The merge algorithm is then:
 //
@@ -213,7 +388,11 @@ func (e *Error) Meta(keyvals ...interface{}) *Error {
 //
 // The Detail field is updated by concatenating the Detail fields of e and other separated
 // by a semi-colon. The MetaValues field of is updated by merging the map of other MetaValues
-// into e's where values in e with identical keys to values in other get overwritten.
+// into e's where values in e with identical keys to values in other get overwritten. The message
+// keys and arguments of e and other are accumulated if both carry messages and removed otherwise
+// so that the merged error can be localized, see Localize. The field errors of e and other are
+// concatenated, see FieldError. The cause of e is set to the cause of
+// other if it has none.
 //
 // Merge returns the updated error. This is useful in case the error was initially nil in
 // which case other is returned.
@@ -240,16 +419,73 @@ func MergeErrors(err, other error) error {
 		e.Code = "bad_request"
 	}
 	e.Detail = e.Detail + "; " + o.Detail
+	ekeys, eargs, eok := e.messages()
+	okeys, oargs, ook := o.messages()
+	efields, ofields := e.FieldErrors(), o.FieldErrors()
+	if len(o.MetaValues) > 0 && e.MetaValues == nil {
+		e.MetaValues = make(map[string]interface{}, len(o.MetaValues))
+	}
 	for n, v := range o.MetaValues {
 		e.MetaValues[n] = v
 	}
+	if eok && ook {
+		e.MetaValues[MessageKeyMeta] = append(append([]string{}, ekeys...), okeys...)
+		e.MetaValues[MessageArgsMeta] = append(append([][]interface{}{}, eargs...), oargs...)
+	} else if eok || ook {
+		delete(e.MetaValues, MessageKeyMeta)
+		delete(e.MetaValues, MessageArgsMeta)
+	}
+	if len(efields) > 0 && len(ofields) > 0 {
+		e.MetaValues[FieldErrorsMeta] = append(append([]*FieldError{}, efields...), ofields...)
+	} else if len(efields) > 0 {
+		e.MetaValues[FieldErrorsMeta] = efields
+	}
+	if e.cause == nil {
+		e.cause = o.cause
+	}
+	if e.stack == nil {
+		e.stack = o.stack
+	}
+	return e
+}
+
+// NestErrors returns err with the paths of its field errors rebased under path: the first segment
+// of each field error path (e.g. "response" in "response.name") is replaced with path. The generated
+// code uses NestErrors to report the errors returned by the Validate methods of nested types
+// relative to the enclosing type. NestErrors returns err as is if it is not an Error.
+func NestErrors(err error, path string) error {
+	e, ok := err.(*Error)
+	if !ok {
+		return err
+	}
+	fes := e.FieldErrors()
+	if len(fes) == 0 {
+		return err
+	}
+	nested := make([]*FieldError, len(fes))
+	for i, fe := range fes {
+		rest := ""
+		if idx := strings.IndexAny(fe.Path, ".["); idx > -1 {
+			rest = fe.Path[idx:]
+		}
+		nested[i] = &FieldError{Path: path + rest, Rule: fe.Rule, Expected: fe.Expected}
+	}
+	e.MetaValues[FieldErrorsMeta] = nested
 	return e
 }
 
 func asError(err error) *Error {
 	e, ok := err.(*Error)
 	if !ok {
-		return &Error{Status: 500, Code: "internal_error", Detail: err.Error()}
+		return &Error{Status: 500, Code: "internal_error", Detail: err.Error(), cause: err}
 	}
 	return e
 }
+
+// callers returns the program counters of the stack of the caller, skip is the number of frames
+// to skip as defined by runtime.Callers.
+func callers(skip int) []uintptr {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(skip, pcs)
+	return pcs[:n]
+}
@@ -422,3 +422,138 @@ var _ = Describe("Merge", func() {
 	})
 
 })
+
+var _ = Describe("Error causes", func() {
+	var cause error
+	var gerr *goa.Error
+
+	BeforeEach(func() {
+		cause = errors.New("connection refused")
+	})
+
+	Context("with an error class called with an error", func() {
+		BeforeEach(func() {
+			gerr = goa.ErrInternal(cause)
+		})
+
+		It("retains the error as cause", func() {
+			Ω(gerr.Cause()).Should(Equal(cause))
+		})
+
+		It("uses the error string as detail", func() {
+			Ω(gerr.Detail).Should(Equal("connection refused"))
+		})
+	})
+
+	Context("with an error wrapped by an error class", func() {
+		BeforeEach(func() {
+			gerr = goa.ErrInternal.Wrap(cause)
+		})
+
+		It("retains the error as cause", func() {
+			Ω(gerr.Code).Should(Equal("internal"))
+			Ω(gerr.Status).Should(Equal(500))
+			Ω(gerr.Cause()).Should(Equal(cause))
+		})
+
+		It("does not expose the cause in the detail", func() {
+			Ω(gerr.Detail).Should(Equal("Internal Server Error"))
+		})
+	})
+
+	Context("with an error class called with an Error", func() {
+		var inner *goa.Error
+
+		BeforeEach(func() {
+			inner = goa.ErrBadRequest("invalid name")
+			gerr = goa.ErrInternal(inner)
+		})
+
+		It("uses the detail of the cause", func() {
+			Ω(gerr.Detail).Should(Equal("invalid name"))
+			Ω(gerr.Cause()).Should(Equal(inner))
+		})
+	})
+
+	Context("with a chain of causes", func() {
+		BeforeEach(func() {
+			inner := goa.ErrBadRequest("inner").WithCause(cause)
+			gerr = goa.ErrInternal("outer").WithCause(inner)
+		})
+
+		It("walks the chain", func() {
+			chain := goa.ErrorCauses(gerr)
+			Ω(chain).Should(HaveLen(3))
+			Ω(chain[0]).Should(Equal(gerr))
+			Ω(chain[1].(*goa.Error).Detail).Should(Equal("inner"))
+			Ω(chain[2]).Should(Equal(cause))
+		})
+
+		It("does not serialize the cause", func() {
+			b, err := json.Marshal(gerr)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(b)).ShouldNot(ContainSubstring("inner"))
+		})
+	})
+
+	Context("with stack capture enabled", func() {
+		BeforeEach(func() {
+			goa.CaptureErrorStack = true
+			gerr = goa.ErrInternal("boom")
+		})
+
+		AfterEach(func() {
+			goa.CaptureErrorStack = false
+		})
+
+		It("records the stack of the caller", func() {
+			Ω(gerr.Stack()).Should(ContainSubstring("error_test.go"))
+		})
+	})
+
+	Context("with stack capture disabled", func() {
+		BeforeEach(func() {
+			gerr = goa.ErrInternal("boom")
+		})
+
+		It("does not record the stack", func() {
+			Ω(gerr.Stack()).Should(BeEmpty())
+			Ω(gerr.WithStack().Stack()).Should(ContainSubstring("error_test.go"))
+		})
+	})
+})
+
+var _ = Describe("FieldErrors", func() {
+	It("records the path and rule of validation errors", func() {
+		err := goa.InvalidRangeError("payload.count", 0, 1, true)
+		Ω(err.FieldErrors()).Should(Equal([]*goa.FieldError{
+			{Path: "payload.count", Rule: "minimum", Expected: 1},
+		}))
+	})
+
+	It("concatenates the field errors of merged errors", func() {
+		err := goa.MergeErrors(goa.MissingAttributeError("payload", "name"), goa.InvalidEnumValueError("payload.kind", "c", []interface{}{"a", "b"}))
+		Ω(err.(*goa.Error).FieldErrors()).Should(Equal([]*goa.FieldError{
+			{Path: "payload.name", Rule: "required"},
+			{Path: "payload.kind", Rule: "enum", Expected: []interface{}{"a", "b"}},
+		}))
+		Ω(err.(*goa.Error).Detail).Should(ContainSubstring("; "))
+	})
+
+	It("reads the field errors of decoded errors", func() {
+		b, err := json.Marshal(goa.InvalidLengthError("payload.tags", []string{}, 0, 1, true))
+		Ω(err).ShouldNot(HaveOccurred())
+		var decoded goa.Error
+		Ω(json.Unmarshal(b, &decoded)).ShouldNot(HaveOccurred())
+		Ω(decoded.FieldErrors()).Should(Equal([]*goa.FieldError{
+			{Path: "payload.tags", Rule: "min_length", Expected: 1.0},
+		}))
+	})
+
+	It("nests the field errors of nested types", func() {
+		err := goa.NestErrors(goa.MissingAttributeError("response", "name"), "payload.items[2]")
+		Ω(err.(*goa.Error).FieldErrors()).Should(Equal([]*goa.FieldError{
+			{Path: "payload.items[2].name", Rule: "required"},
+		}))
+	})
+})
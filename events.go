@@ -0,0 +1,118 @@
+package goa
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// EventStreamContentType is the content type of server-sent events streams.
+const EventStreamContentType = "text/event-stream"
+
+type (
+	// Event contains the optional fields of a server-sent event.
+	Event struct {
+		// ID is written to the event "id" field, clients send it back in the Last-Event-ID
+		// header when reconnecting.
+		ID string
+		// Name is written to the event "event" field, it defaults to "message" on the
+		// client side.
+		Name string
+		// Retry is written to the event "retry" field, it sets the client reconnection
+		// delay.
+		Retry time.Duration
+	}
+
+	// EventStream writes server-sent events to a HTTP response. Each event is flushed to the
+	// client as soon as it is written.
+	EventStream struct {
+		ctx context.Context
+		rw  http.ResponseWriter
+	}
+)
+
+// NewEventStream writes the headers of a server-sent events response with the given status to rw
+// and returns the stream used to send the events. ctx is the action context, the stream is done
+// once it is cancelled e.g. by the service CancelAll or Shutdown methods. The stream is also done
+// when the client disconnects if ctx holds the request data.
+func NewEventStream(ctx context.Context, rw http.ResponseWriter, status int) *EventStream {
+	if req := ContextRequest(ctx); req != nil && req.Request != nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		reqCtx := req.Context()
+		go func() {
+			select {
+			case <-reqCtx.Done():
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+	}
+	rw.Header().Set("Content-Type", EventStreamContentType)
+	rw.Header().Set("Cache-Control", "no-cache")
+	rw.WriteHeader(status)
+	if f, ok := rw.(http.Flusher); ok {
+		f.Flush()
+	}
+	return &EventStream{ctx: ctx, rw: rw}
+}
+
+// Send writes an event with the given data to the stream. The data is written as is if it is a
+// string or a byte slice and encoded to JSON otherwise. e sets the optional event fields and may
+// be nil. Send returns the context error once the client has disconnected.
+func (s *EventStream) Send(data interface{}, e *Event) error {
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+	var raw []byte
+	switch actual := data.(type) {
+	case string:
+		raw = []byte(actual)
+	case []byte:
+		raw = actual
+	default:
+		var err error
+		if raw, err = json.Marshal(data); err != nil {
+			return err
+		}
+	}
+	var buf bytes.Buffer
+	if e != nil {
+		if e.ID != "" {
+			fmt.Fprintf(&buf, "id: %s\n", eventField(e.ID))
+		}
+		if e.Name != "" {
+			fmt.Fprintf(&buf, "event: %s\n", eventField(e.Name))
+		}
+		if e.Retry > 0 {
+			fmt.Fprintf(&buf, "retry: %d\n", e.Retry/time.Millisecond)
+		}
+	}
+	for _, line := range strings.Split(string(raw), "\n") {
+		fmt.Fprintf(&buf, "data: %s\n", strings.TrimSuffix(line, "\r"))
+	}
+	buf.WriteByte('\n')
+	if _, err := s.rw.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	if f, ok := s.rw.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
+
+// Done returns a channel that is closed when the stream context is cancelled or the client
+// disconnects.
+func (s *EventStream) Done() <-chan struct{} {
+	return s.ctx.Done()
+}
+
+// eventField removes the line breaks that would otherwise corrupt a single line event field.
+func eventField(v string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(v)
+}
@@ -0,0 +1,84 @@
+package goa_test
+
+import (
+	"net/http/httptest"
+	"time"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("EventStream", func() {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	var rw *httptest.ResponseRecorder
+	var stream *goa.EventStream
+
+	BeforeEach(func() {
+		ctx, cancel = context.WithCancel(context.Background())
+		rw = httptest.NewRecorder()
+		stream = goa.NewEventStream(ctx, rw, 200)
+	})
+
+	AfterEach(func() {
+		cancel()
+	})
+
+	It("writes the event stream headers", func() {
+		Ω(rw.Code).Should(Equal(200))
+		Ω(rw.Header().Get("Content-Type")).Should(Equal(goa.EventStreamContentType))
+		Ω(rw.Header().Get("Cache-Control")).Should(Equal("no-cache"))
+		Ω(rw.Flushed).Should(BeTrue())
+	})
+
+	It("writes the event fields", func() {
+		ev := &goa.Event{ID: "42", Name: "progress", Retry: 3 * time.Second}
+		Ω(stream.Send("done", ev)).ShouldNot(HaveOccurred())
+		Ω(rw.Body.String()).Should(Equal("id: 42\nevent: progress\nretry: 3000\ndata: done\n\n"))
+	})
+
+	It("splits multi-line data", func() {
+		Ω(stream.Send("foo\nbar", nil)).ShouldNot(HaveOccurred())
+		Ω(rw.Body.String()).Should(Equal("data: foo\ndata: bar\n\n"))
+	})
+
+	It("encodes other data to JSON", func() {
+		Ω(stream.Send(map[string]int{"percent": 50}, nil)).ShouldNot(HaveOccurred())
+		Ω(rw.Body.String()).Should(Equal("data: {\"percent\":50}\n\n"))
+	})
+
+	It("returns an error once the context is cancelled", func() {
+		cancel()
+		Ω(stream.Send("foo", nil)).Should(HaveOccurred())
+		Ω(rw.Body.String()).Should(BeEmpty())
+		Eventually(stream.Done()).Should(BeClosed())
+	})
+
+	Context("with request data in the context", func() {
+		var reqCancel context.CancelFunc
+
+		BeforeEach(func() {
+			var reqCtx context.Context
+			reqCtx, reqCancel = context.WithCancel(context.Background())
+			req := httptest.NewRequest("GET", "/events", nil).WithContext(reqCtx)
+			stream = goa.NewEventStream(goa.NewContext(ctx, rw, req, nil), rw, 200)
+		})
+
+		AfterEach(func() {
+			reqCancel()
+		})
+
+		It("is done once the client is gone", func() {
+			reqCancel()
+			Eventually(stream.Done()).Should(BeClosed())
+			Ω(stream.Send("foo", nil)).Should(HaveOccurred())
+		})
+
+		It("is done once the action context is cancelled", func() {
+			cancel()
+			Eventually(stream.Done()).Should(BeClosed())
+		})
+	})
+})
@@ -111,7 +111,7 @@ func goTypeDefObject(actual design.Object, def *design.AttributeDefinition, tabs
 		WriteTabs(&buffer, tabs+1)
 		field := actual[name]
 		typedef := GoTypeDef(field, tabs+1, jsonTags, private)
-		if (field.Type.IsPrimitive() && private) || field.Type.IsObject() || def.IsPrimitivePointer(name) {
+		if (field.Type.IsPrimitive() && private && field.Type.Kind() != design.FileKind) || field.Type.IsObject() || def.IsPrimitivePointer(name) {
 			typedef = "*" + typedef
 		}
 		fname := name
@@ -239,6 +239,8 @@ func GoNativeType(t design.DataType) string {
 			return "uuid.UUID"
 		case design.AnyKind:
 			return "interface{}"
+		case design.FileKind:
+			return "*multipart.FileHeader"
 		default:
 			panic(fmt.Sprintf("goa bug: unknown primitive type %#v", actual))
 		}
@@ -286,8 +288,11 @@ func GoTypeDesc(t design.DataType, upper bool) string {
 // BuiltInTypeName returns the name of the goa struct corresponding to the media type definition
 // or the empty string if there isn't one.
 func BuiltInTypeName(mt *design.MediaTypeDefinition) string {
-	if mt.Identifier == design.ErrorMedia.Identifier {
+	switch mt.Identifier {
+	case design.ErrorMedia.Identifier:
 		return "goa.Error"
+	case design.ProblemMedia.Identifier:
+		return "goa.Problem"
 	}
 	return ""
 }
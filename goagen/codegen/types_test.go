@@ -374,6 +374,31 @@ var _ = Describe("code generation", func() {
 				})
 			})
 
+			Context("of file types", func() {
+				BeforeEach(func() {
+					object = Object{
+						"file":  &AttributeDefinition{Type: File},
+						"files": &AttributeDefinition{Type: &Array{ElemType: &AttributeDefinition{Type: File}}},
+					}
+					required = &dslengine.ValidationDefinition{
+						Required: []string{"file"},
+					}
+				})
+
+				It("produces the struct go code", func() {
+					expected := "struct {\n" +
+						"	File *multipart.FileHeader `json:\"file\" xml:\"file\" form:\"file\"`\n" +
+						"	Files []*multipart.FileHeader `json:\"files,omitempty\" xml:\"files,omitempty\" form:\"files,omitempty\"`\n" +
+						"}"
+					Ω(st).Should(Equal(expected))
+				})
+
+				It("does not add pointers to private structs", func() {
+					st = codegen.GoTypeDef(att, 0, true, true)
+					Ω(st).Should(ContainSubstring("File *multipart.FileHeader"))
+				})
+			})
+
 		})
 
 		Context("given an array", func() {
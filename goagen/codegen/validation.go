@@ -32,6 +32,8 @@ func init() {
 		"goify":            Goify,
 		"add":              Add,
 		"recursiveChecker": RecursiveChecker,
+		"contextCode":      contextCode,
+		"indexVar":         indexVar,
 	}
 	if arrayValT, err = template.New("array").Funcs(fm).Parse(arrayValTmpl); err != nil {
 		panic(err)
@@ -97,7 +99,7 @@ func RecursiveChecker(att *design.AttributeDefinition, nonzero, required, hasDef
 						}
 						for _, name := range a.Validation.Required {
 							att := a.Type.ToObject()[name]
-							if att != nil && (!att.Type.IsPrimitive() || att.Type.Kind() == design.StringKind) {
+							if att != nil && (!att.Type.IsPrimitive() || att.Type.Kind() == design.StringKind || att.Type.Kind() == design.FileKind) {
 								hasValidations = true
 								return done
 							}
@@ -109,8 +111,9 @@ func RecursiveChecker(att *design.AttributeDefinition, nonzero, required, hasDef
 					validation = RunTemplate(
 						userValT,
 						map[string]interface{}{
-							"depth":  depth,
-							"target": fmt.Sprintf("%s.%s", target, Goify(n, true)),
+							"depth":   depth,
+							"target":  fmt.Sprintf("%s.%s", target, Goify(n, true)),
+							"context": fmt.Sprintf("%s.%s", context, n),
 						},
 					)
 				}
@@ -175,7 +178,7 @@ func RecursiveChecker(att *design.AttributeDefinition, nonzero, required, hasDef
 func ValidationChecker(att *design.AttributeDefinition, nonzero, required, hasDefault bool, target, context string, depth int, private bool) string {
 	t := target
 	isPointer := private || (!required && !hasDefault && !nonzero)
-	if isPointer && att.Type.IsPrimitive() {
+	if isPointer && att.Type.IsPrimitive() && att.Type.Kind() != design.FileKind {
 		t = "*" + t
 	}
 	data := map[string]interface{}{
@@ -257,6 +260,26 @@ func validationsCode(validation *dslengine.ValidationDefinition, data map[string
 	return
 }
 
+// contextCode produces the Go expression of the given validation context. The "[*]" placeholders
+// that stand for array elements are replaced with the index of the elements being validated.
+func contextCode(context string) string {
+	n := strings.Count(context, "[*]")
+	if n == 0 {
+		return "`" + context + "`"
+	}
+	idx := make([]string, n)
+	for i := range idx {
+		idx[i] = fmt.Sprintf("i%d", i)
+	}
+	return fmt.Sprintf("fmt.Sprintf(`%s`, %s)", strings.Replace(context, "[*]", "[%d]", -1), strings.Join(idx, ", "))
+}
+
+// indexVar returns the name of the variable holding the index of the elements of the array
+// validated in the given context.
+func indexVar(context string) string {
+	return fmt.Sprintf("i%d", strings.Count(context, "[*]"))
+}
+
 // oneof produces code that compares target with each element of vals and ORs
 // the result, e.g. "target == 1 || target == 2".
 func oneof(target string, vals []interface{}) string {
@@ -294,39 +317,39 @@ func constant(formatName string) string {
 
 const (
 	arrayValTmpl = `{{$validation := recursiveChecker .elemType false false false "e" (printf "%s[*]" .context) (add .depth 1) .private}}{{/*
-*/}}{{if $validation}}{{tabs .depth}}for _, e := range {{.target}} {
+*/}}{{if $validation}}{{tabs .depth}}for {{indexVar .context}}, e := range {{.target}} {
 {{$validation}}
 {{tabs .depth}}}{{end}}`
 
 	userValTmpl = `{{tabs .depth}}if err2 := {{.target}}.Validate(); err2 != nil {
-{{tabs .depth}}	err = goa.MergeErrors(err, err2)
+{{tabs .depth}}	err = goa.MergeErrors(err, goa.NestErrors(err2, {{contextCode .context}}))
 {{tabs .depth}}}`
 
 	enumValTmpl = `{{$depth := or (and .isPointer (add .depth 1)) .depth}}{{/*
 */}}{{if .isPointer}}{{tabs .depth}}if {{.target}} != nil {
 {{end}}{{tabs $depth}}if !({{oneof .targetVal .values}}) {
-{{tabs $depth}}	err = goa.MergeErrors(err, goa.InvalidEnumValueError(` + "`" + `{{.context}}` + "`" + `, {{.targetVal}}, {{slice .values}}))
+{{tabs $depth}}	err = goa.MergeErrors(err, goa.InvalidEnumValueError({{contextCode .context}}, {{.targetVal}}, {{slice .values}}))
 {{if .isPointer}}{{tabs $depth}}}
 {{end}}{{tabs .depth}}}`
 
 	patternValTmpl = `{{$depth := or (and .isPointer (add .depth 1)) .depth}}{{/*
 */}}{{if .isPointer}}{{tabs .depth}}if {{.target}} != nil {
 {{end}}{{tabs $depth}}if ok := goa.ValidatePattern(` + "`{{.pattern}}`" + `, {{.targetVal}}); !ok {
-{{tabs $depth}}	err = goa.MergeErrors(err, goa.InvalidPatternError(` + "`" + `{{.context}}` + "`" + `, {{.targetVal}}, ` + "`{{.pattern}}`" + `))
+{{tabs $depth}}	err = goa.MergeErrors(err, goa.InvalidPatternError({{contextCode .context}}, {{.targetVal}}, ` + "`{{.pattern}}`" + `))
 {{tabs $depth}}}{{if .isPointer}}
 {{tabs .depth}}}{{end}}`
 
 	formatValTmpl = `{{$depth := or (and .isPointer (add .depth 1)) .depth}}{{/*
 */}}{{if .isPointer}}{{tabs .depth}}if {{.target}} != nil {
 {{end}}{{tabs $depth}}if err2 := goa.ValidateFormat({{constant .format}}, {{.targetVal}}); err2 != nil {
-{{tabs $depth}}		err = goa.MergeErrors(err, goa.InvalidFormatError(` + "`" + `{{.context}}` + "`" + `, {{.targetVal}}, {{constant .format}}, err2))
+{{tabs $depth}}		err = goa.MergeErrors(err, goa.InvalidFormatError({{contextCode .context}}, {{.targetVal}}, {{constant .format}}, err2))
 {{if .isPointer}}{{tabs $depth}}}
 {{end}}{{tabs .depth}}}`
 
 	minMaxValTmpl = `{{$depth := or (and .isPointer (add .depth 1)) .depth}}{{/*
 */}}{{if .isPointer}}{{tabs .depth}}if {{.target}} != nil {
 {{end}}{{tabs .depth}}	if {{.targetVal}} {{if .isMin}}<{{else}}>{{end}} {{if .isMin}}{{.min}}{{else}}{{.max}}{{end}} {
-{{tabs $depth}}	err = goa.MergeErrors(err, goa.InvalidRangeError(` + "`" + `{{.context}}` + "`" + `, {{.targetVal}}, {{if .isMin}}{{.min}}, true{{else}}{{.max}}, false{{end}}))
+{{tabs $depth}}	err = goa.MergeErrors(err, goa.InvalidRangeError({{contextCode .context}}, {{.targetVal}}, {{if .isMin}}{{.min}}, true{{else}}{{.max}}, false{{end}}))
 {{if .isPointer}}{{tabs $depth}}}
 {{end}}{{tabs .depth}}}`
 
@@ -334,16 +357,16 @@ const (
 */}}{{$target := or (and (or (or .array .hash) .nonzero) .target) .targetVal}}{{/*
 */}}{{if .isPointer}}{{tabs .depth}}if {{.target}} != nil {
 {{end}}{{tabs .depth}}	if len({{$target}}) {{if .isMinLength}}<{{else}}>{{end}} {{if .isMinLength}}{{.minLength}}{{else}}{{.maxLength}}{{end}} {
-{{tabs $depth}}	err = goa.MergeErrors(err, goa.InvalidLengthError(` + "`" + `{{.context}}` + "`" + `, {{$target}}, len({{$target}}), {{if .isMinLength}}{{.minLength}}, true{{else}}{{.maxLength}}, false{{end}}))
+{{tabs $depth}}	err = goa.MergeErrors(err, goa.InvalidLengthError({{contextCode .context}}, {{$target}}, len({{$target}}), {{if .isMinLength}}{{.minLength}}, true{{else}}{{.maxLength}}, false{{end}}))
 {{if .isPointer}}{{tabs $depth}}}
 {{end}}{{tabs .depth}}}`
 
 	requiredValTmpl = `{{range $r := .required}}{{$catt := index $.attribute.Type.ToObject $r}}{{/*
 */}}{{if and (not $.private) (eq $catt.Type.Kind 4)}}{{tabs $.depth}}if {{$.target}}.{{goify $r true}} == "" {
-{{tabs $.depth}}	err = goa.MergeErrors(err, goa.MissingAttributeError(` + "`" + `{{$.context}}` + "`" + `, "{{$r}}"))
+{{tabs $.depth}}	err = goa.MergeErrors(err, goa.MissingAttributeError({{contextCode $.context}}, "{{$r}}"))
 {{tabs $.depth}}}
-{{else if or $.private (not $catt.Type.IsPrimitive)}}{{tabs $.depth}}if {{$.target}}.{{goify $r true}} == nil {
-{{tabs $.depth}}	err = goa.MergeErrors(err, goa.MissingAttributeError(` + "`" + `{{$.context}}` + "`" + `, "{{$r}}"))
+{{else if or $.private (not $catt.Type.IsPrimitive) (eq $catt.Type.Kind 13)}}{{tabs $.depth}}if {{$.target}}.{{goify $r true}} == nil {
+{{tabs $.depth}}	err = goa.MergeErrors(err, goa.MissingAttributeError({{contextCode $.context}}, "{{$r}}"))
 {{tabs $.depth}}}
 {{end}}{{end}}`
 )
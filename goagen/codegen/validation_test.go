@@ -122,6 +122,57 @@ var _ = Describe("validation code generation", func() {
 				})
 			})
 
+			Context("of required file", func() {
+				BeforeEach(func() {
+					attType = design.Object{"file": &design.AttributeDefinition{Type: design.File}}
+					validation = &dslengine.ValidationDefinition{
+						Required: []string{"file"},
+					}
+				})
+
+				It("checks the file is not nil", func() {
+					Ω(code).Should(Equal(requiredFileValCode))
+				})
+			})
+
+			Context("of array elements", func() {
+				BeforeEach(func() {
+					elem := &design.AttributeDefinition{
+						Type: design.Object{
+							"name": &design.AttributeDefinition{Type: design.String},
+						},
+						Validation: &dslengine.ValidationDefinition{Required: []string{"name"}},
+					}
+					inner := &design.AttributeDefinition{Type: &design.Array{ElemType: elem}}
+					attType = &design.Array{ElemType: inner}
+					validation = nil
+				})
+
+				It("reports the indices of the invalid elements", func() {
+					Ω(code).Should(Equal(arrayValCode))
+				})
+			})
+
+			Context("of nested user type", func() {
+				BeforeEach(func() {
+					child := &design.UserTypeDefinition{
+						TypeName: "Child",
+						AttributeDefinition: &design.AttributeDefinition{
+							Type: design.Object{
+								"name": &design.AttributeDefinition{Type: design.String},
+							},
+							Validation: &dslengine.ValidationDefinition{Required: []string{"name"}},
+						},
+					}
+					attType = design.Object{"child": &design.AttributeDefinition{Type: child}}
+					validation = nil
+				})
+
+				It("nests the errors of the user type under the field path", func() {
+					Ω(code).Should(Equal(userValCode))
+				})
+			})
+
 		})
 	})
 })
@@ -170,4 +221,24 @@ const (
 			}
 		}
 	}`
+
+	requiredFileValCode = `	if val.File == nil {
+		err = goa.MergeErrors(err, goa.MissingAttributeError(` + "`context`" + `, "file"))
+	}
+`
+
+	userValCode = `	if val.Child != nil {
+	if err2 := val.Child.Validate(); err2 != nil {
+		err = goa.MergeErrors(err, goa.NestErrors(err2, ` + "`context.child`" + `))
+	}
+	}`
+
+	arrayValCode = `	for i0, e := range val {
+	for i1, e := range e {
+		if e.Name == "" {
+			err = goa.MergeErrors(err, goa.MissingAttributeError(fmt.Sprintf(` + "`" + `context[%d][%d]` + "`" + `, i0, i1), "name"))
+		}
+
+	}
+	}`
 )
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"time"
 
 	"github.com/goadesign/goa/design"
 	"github.com/goadesign/goa/goagen/codegen"
@@ -78,6 +79,9 @@ func (g *Generator) Generate(api *design.APIDefinition) (_ []string, err error)
 	if err := g.generateSecurity(api); err != nil {
 		return nil, err
 	}
+	if err := g.generateErrors(api); err != nil {
+		return nil, err
+	}
 	if err := g.generateHrefs(api); err != nil {
 		return nil, err
 	}
@@ -117,9 +121,11 @@ func (g *Generator) generateContexts(api *design.APIDefinition) error {
 	imports := []*codegen.ImportSpec{
 		codegen.SimpleImport("fmt"),
 		codegen.SimpleImport("golang.org/x/net/context"),
+		codegen.SimpleImport("io"),
 		codegen.SimpleImport("strconv"),
 		codegen.SimpleImport("strings"),
 		codegen.SimpleImport("time"),
+		codegen.SimpleImport("mime/multipart"),
 		codegen.SimpleImport("github.com/goadesign/goa"),
 		codegen.NewImport("uuid", "github.com/goadesign/goa/uuid"),
 	}
@@ -144,17 +150,20 @@ func (g *Generator) generateContexts(api *design.APIDefinition) error {
 				}
 			}
 			ctxData := ContextTemplateData{
-				Name:         ctxName,
-				ResourceName: r.Name,
-				ActionName:   a.Name,
-				Payload:      a.Payload,
-				Params:       params,
-				Headers:      headers,
-				Routes:       a.Routes,
-				Responses:    non101,
-				API:          api,
-				DefaultPkg:   g.target,
-				Security:     a.Security,
+				Name:          ctxName,
+				ResourceName:  r.Name,
+				ActionName:    a.Name,
+				Payload:       a.Payload,
+				PayloadStream: a.PayloadStream,
+				Params:        params,
+				Headers:       headers,
+				Routes:        a.Routes,
+				Responses:     non101,
+				API:           api,
+				DefaultPkg:    g.target,
+				Security:      a.Security,
+				Errors:        a.AllErrors(),
+				CacheControl:  a.CacheControl(),
 			}
 			return ctxWr.Execute(&ctxData)
 		})
@@ -178,6 +187,7 @@ func (g *Generator) generateControllers(api *design.APIDefinition) error {
 		codegen.SimpleImport("net/http"),
 		codegen.SimpleImport("fmt"),
 		codegen.SimpleImport("golang.org/x/net/context"),
+		codegen.SimpleImport("time"),
 		codegen.SimpleImport("github.com/goadesign/goa"),
 		codegen.SimpleImport("github.com/goadesign/goa/cors"),
 	}
@@ -227,7 +237,13 @@ func (g *Generator) generateControllers(api *design.APIDefinition) error {
 				"Unmarshal":       unmarshal,
 				"Payload":         a.Payload,
 				"PayloadOptional": a.PayloadOptional,
+				"PayloadStream":   a.PayloadStream,
 				"Security":        a.Security,
+				"Idempotent":      a.IsIdempotent(),
+			}
+			if t, ok := a.Timeout(); ok {
+				action["Timeout"] = durationCode(t)
+				data.Timeouts = true
 			}
 			data.Actions = append(data.Actions, action)
 			return nil
@@ -284,6 +300,34 @@ func (g *Generator) generateSecurity(api *design.APIDefinition) error {
 	return secWr.FormatCode()
 }
 
+// generateErrors generates the error classes of the errors defined in the design.
+func (g *Generator) generateErrors(api *design.APIDefinition) error {
+	errs := api.AllErrors()
+	if len(errs) == 0 {
+		return nil
+	}
+
+	errFile := filepath.Join(g.outDir, "errors.go")
+	errWr, err := NewErrorsWriter(errFile)
+	if err != nil {
+		panic(err) // bug
+	}
+
+	title := fmt.Sprintf("%s: Application Errors", api.Context())
+	imports := []*codegen.ImportSpec{
+		codegen.SimpleImport("github.com/goadesign/goa"),
+	}
+	errWr.WriteHeader(title, g.target, imports)
+
+	g.genfiles = append(g.genfiles, errFile)
+
+	if err = errWr.Execute(errs); err != nil {
+		return err
+	}
+
+	return errWr.FormatCode()
+}
+
 // generateHrefs iterates through the API resources and generates the href factory methods.
 func (g *Generator) generateHrefs(api *design.APIDefinition) error {
 	hrefFile := filepath.Join(g.outDir, "hrefs.go")
@@ -334,6 +378,7 @@ func (g *Generator) generateMediaTypes(api *design.APIDefinition) error {
 		codegen.SimpleImport("github.com/goadesign/goa"),
 		codegen.SimpleImport("fmt"),
 		codegen.SimpleImport("time"),
+		codegen.SimpleImport("mime/multipart"),
 		codegen.NewImport("uuid", "github.com/goadesign/goa/uuid"),
 	}
 	mtWr.WriteHeader(title, g.target, imports)
@@ -366,6 +411,7 @@ func (g *Generator) generateUserTypes(api *design.APIDefinition) error {
 		codegen.SimpleImport("github.com/goadesign/goa"),
 		codegen.SimpleImport("fmt"),
 		codegen.SimpleImport("time"),
+		codegen.SimpleImport("mime/multipart"),
 	}
 	utWr.WriteHeader(title, g.target, imports)
 	err = api.IterateUserTypes(func(t *design.UserTypeDefinition) error {
@@ -377,3 +423,27 @@ func (g *Generator) generateUserTypes(api *design.APIDefinition) error {
 	}
 	return utWr.FormatCode()
 }
+
+// durationCode returns the Go code of an expression whose value is d using the largest unit of
+// the time package that divides it, e.g. "30 * time.Second".
+func durationCode(d time.Duration) string {
+	if d == 0 {
+		return "0"
+	}
+	units := []struct {
+		unit time.Duration
+		name string
+	}{
+		{time.Hour, "Hour"},
+		{time.Minute, "Minute"},
+		{time.Second, "Second"},
+		{time.Millisecond, "Millisecond"},
+		{time.Microsecond, "Microsecond"},
+	}
+	for _, u := range units {
+		if d%u.unit == 0 {
+			return fmt.Sprintf("%d * time.%s", d/u.unit, u.name)
+		}
+	}
+	return fmt.Sprintf("time.Duration(%d)", d)
+}
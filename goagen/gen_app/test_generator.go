@@ -39,6 +39,7 @@ type TestMethod struct {
 	ReturnType     *ObjectType
 	Params         []ObjectType
 	Payload        *ObjectType
+	PayloadStream  bool
 }
 
 // ObjectType structure
@@ -66,6 +67,7 @@ func (g *Generator) generateResourceTest(api *design.APIDefinition) error {
 	imports := []*codegen.ImportSpec{
 		codegen.SimpleImport("bytes"),
 		codegen.SimpleImport("fmt"),
+		codegen.SimpleImport("io"),
 		codegen.SimpleImport("net/http"),
 		codegen.SimpleImport("net/http/httptest"),
 		codegen.SimpleImport("net/url"),
@@ -95,7 +97,7 @@ func (g *Generator) generateResourceTest(api *design.APIDefinition) error {
 				}
 				for routeIndex, route := range action.Routes {
 					mediaType := design.Design.MediaTypeWithIdentifier(response.MediaType)
-					if mediaType == nil {
+					if mediaType == nil || response.Stream {
 						methods = append(methods, g.createTestMethod(res, action, response, route, routeIndex, nil, nil))
 					} else {
 						if err := mediaType.IterateViews(func(view *design.ViewDefinition) error {
@@ -183,7 +185,11 @@ func (g *Generator) createTestMethod(resource *design.ResourceDefinition, action
 		method.Params = params
 	}
 
-	if action.Payload != nil {
+	if action.PayloadStream {
+		// Streamed payloads are given to the test helper as the raw request body.
+		method.Payload = &ObjectType{Name: "payload", Type: "io.Reader"}
+		method.PayloadStream = true
+	} else if action.Payload != nil {
 		payload := ObjectType{}
 		payload.Name = "payload"
 		payload.Type = fmt.Sprintf("%s.%s", g.target, codegen.Goify(action.Payload.TypeName, true))
@@ -247,7 +253,7 @@ func {{ $test.Name }}Ctx(t *testing.T, ctx context.Context, ctrl {{ $test.Contro
 	respSetter := func(r interface{}) { resp = r }
 	service := goatest.Service(&logBuf, respSetter)
 	rw := httptest.NewRecorder()
-	req, err := http.NewRequest("{{ $test.RouteVerb }}", fmt.Sprintf("{{ $test.FullPath }}"{{ range $param := $test.Params }}, {{ $param.Name }}{{ end }}), nil)
+	req, err := http.NewRequest("{{ $test.RouteVerb }}", fmt.Sprintf("{{ $test.FullPath }}"{{ range $param := $test.Params }}, {{ $param.Name }}{{ end }}), {{ if $test.PayloadStream }}{{ $test.Payload.Name }}{{ else }}nil{{ end }})
 	if err != nil {
 		panic("invalid test " + err.Error()) // bug
 	}
@@ -259,7 +265,7 @@ func {{ $test.Name }}Ctx(t *testing.T, ctx context.Context, ctrl {{ $test.Contro
 	if err != nil {
 		panic("invalid test data " + err.Error()) // bug
 	}
-	{{ if $test.Payload }}{{ $test.ContextVarName }}.Payload = {{ $test.Payload.Name }}{{ end }}
+	{{ if and $test.Payload (not $test.PayloadStream) }}{{ $test.ContextVarName }}.Payload = {{ $test.Payload.Name }}{{ end }}
 
 	err = ctrl.{{ $test.ActionName}}({{ $test.ContextVarName }})
 	if err != nil {
@@ -63,32 +63,42 @@ type (
 		UserTypeTmpl *template.Template
 	}
 
+	// ErrorsWriter generate code for the error classes defined in the design.
+	ErrorsWriter struct {
+		*codegen.SourceFile
+		ErrorsTmpl *template.Template
+	}
+
 	// ContextTemplateData contains all the information used by the template to render the context
 	// code for an action.
 	ContextTemplateData struct {
-		Name         string // e.g. "ListBottleContext"
-		ResourceName string // e.g. "bottles"
-		ActionName   string // e.g. "list"
-		Params       *design.AttributeDefinition
-		Payload      *design.UserTypeDefinition
-		Headers      *design.AttributeDefinition
-		Routes       []*design.RouteDefinition
-		Responses    map[string]*design.ResponseDefinition
-		API          *design.APIDefinition
-		DefaultPkg   string
-		Security     *design.SecurityDefinition
+		Name          string // e.g. "ListBottleContext"
+		ResourceName  string // e.g. "bottles"
+		ActionName    string // e.g. "list"
+		Params        *design.AttributeDefinition
+		Payload       *design.UserTypeDefinition
+		PayloadStream bool
+		Headers       *design.AttributeDefinition
+		Routes        []*design.RouteDefinition
+		Responses     map[string]*design.ResponseDefinition
+		API           *design.APIDefinition
+		DefaultPkg    string
+		Security      *design.SecurityDefinition
+		Errors        []*design.ErrorDefinition
+		CacheControl  string // Cache-Control header of successful responses, e.g. "public, max-age=60"
 	}
 
 	// ControllerTemplateData contains the information required to generate an action handler.
 	ControllerTemplateData struct {
 		API            *design.APIDefinition          // API definition
 		Resource       string                         // Lower case plural resource name, e.g. "bottles"
-		Actions        []map[string]interface{}       // Array of actions, each action has keys "Name", "Routes", "Context" and "Unmarshal"
+		Actions        []map[string]interface{}       // Array of actions, each action has keys "Name", "Routes", "Context", "Unmarshal", "PayloadStream", "Idempotent" and "Timeout"
 		FileServers    []*design.FileServerDefinition // File servers
 		Encoders       []*EncoderTemplateData         // Encoder data
 		Decoders       []*EncoderTemplateData         // Decoder data
 		Origins        []*design.CORSDefinition       // CORS policies
 		PreflightPaths []string
+		Timeouts       bool // Whether any action defines a timeout
 	}
 
 	// ResourceData contains the information required to generate the resource GoGenerator
@@ -146,6 +156,12 @@ func (c *ContextTemplateData) MustValidate(name string) bool {
 	return c.Params.IsRequired(name) && !c.IsPathParam(name)
 }
 
+// StreamName returns the name of the type used to send the elements of the response stream with
+// the given response method name, e.g. "ExportBottleOKStream".
+func (c *ContextTemplateData) StreamName(method string) string {
+	return strings.TrimSuffix(c.Name, "Context") + method + "Stream"
+}
+
 // IterateResponses iterates through the responses sorted by status code.
 func (c *ContextTemplateData) IterateResponses(it func(*design.ResponseDefinition) error) error {
 	m := make(map[int]*design.ResponseDefinition, len(c.Responses))
@@ -190,6 +206,11 @@ func (w *ContextsWriter) Execute(data *ContextTemplateData) error {
 		if err := w.ExecuteTemplate("payload", payloadT, nil, data); err != nil {
 			return err
 		}
+		if data.PayloadStream {
+			if err := w.ExecuteTemplate("payloadStream", payloadStreamT, nil, data); err != nil {
+				return err
+			}
+		}
 	}
 	fn = template.FuncMap{
 		"project": func(mt *design.MediaTypeDefinition, v string) *design.MediaTypeDefinition {
@@ -202,6 +223,12 @@ func (w *ContextsWriter) Execute(data *ContextTemplateData) error {
 			"Context":  data,
 			"Response": resp,
 		}
+		if resp.Status >= 200 && resp.Status < 300 {
+			respData["CacheControl"] = data.CacheControl
+		}
+		if resp.Events || resp.Stream && !resp.IsRawStream() {
+			return w.executeStream(data, resp)
+		}
 		if resp.Type != nil {
 			respData["Type"] = resp.Type
 			respData["ContentType"] = resp.MediaType
@@ -231,9 +258,61 @@ func (w *ContextsWriter) Execute(data *ContextTemplateData) error {
 		}
 		return nil
 	})
+	for _, e := range data.Errors {
+		errData := map[string]interface{}{
+			"Context":   data,
+			"Error":     e,
+			"MediaType": data.API.ErrorMediaType(),
+		}
+		if err := w.ExecuteTemplate("error", ctxErrorT, nil, errData); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// executeStream writes the response methods and stream types of a server-sent events response or
+// of a streamed response whose elements are described by a type or a media type.
+func (w *ContextsWriter) executeStream(data *ContextTemplateData, resp *design.ResponseDefinition) error {
+	tmpl := ctxStreamRespT
+	if resp.Events {
+		tmpl = ctxEventsRespT
+	}
+	render := func(method string, elemType design.DataType, required []string) error {
+		streamData := map[string]interface{}{
+			"Context":  data,
+			"Response": resp,
+			"Method":   method,
+			"Stream":   data.StreamName(method),
+			"ElemType": codegen.GoTypeRef(elemType, required, 0, false),
+		}
+		return w.ExecuteTemplate("stream", tmpl, nil, streamData)
+	}
+	name := codegen.Goify(resp.Name, true)
+	if resp.Type != nil {
+		return render(name, resp.Type, nil)
+	}
+	mt := design.Design.MediaTypeWithIdentifier(resp.MediaType)
+	if mt == nil {
+		// Raw server-sent events
+		return render(name, design.String, nil)
+	}
+	return mt.IterateViews(func(view *design.ViewDefinition) error {
+		if view.Name == "link" {
+			return nil
+		}
+		p, _, err := mt.Project(view.Name)
+		if err != nil {
+			return err
+		}
+		method := name
+		if view.Name != "default" {
+			method = codegen.Goify(resp.Name+strings.Title(view.Name), true)
+		}
+		return render(method, p, p.AllRequired())
+	})
+}
+
 // NewControllersWriter returns a handlers code writer.
 // Handlers provide the glue between the underlying request data and the user controller.
 func NewControllersWriter(filename string) (*ControllersWriter, error) {
@@ -278,6 +357,13 @@ func (w *ControllersWriter) Execute(data []*ControllerTemplateData) error {
 			return err
 		}
 	}
+	for _, d := range data {
+		for _, a := range d.Actions {
+			if idempotent, _ := a["Idempotent"].(bool); idempotent {
+				return w.ExecuteTemplate("idempotency", idempotencyT, nil, nil)
+			}
+		}
+	}
 	return nil
 }
 
@@ -365,6 +451,20 @@ func (w *UserTypesWriter) Execute(t *design.UserTypeDefinition) error {
 	return w.ExecuteTemplate("types", userTypeT, nil, t)
 }
 
+// NewErrorsWriter returns an error classes code writer.
+func NewErrorsWriter(filename string) (*ErrorsWriter, error) {
+	file, err := codegen.SourceFileFor(filename)
+	if err != nil {
+		return nil, err
+	}
+	return &ErrorsWriter{SourceFile: file}, nil
+}
+
+// Execute writes the code for the error classes to the writer.
+func (w *ErrorsWriter) Execute(errs []*design.ErrorDefinition) error {
+	return w.ExecuteTemplate("errors", errorsT, nil, errs)
+}
+
 // newCoerceData is a helper function that creates a map that can be given to the "Coerce" template.
 func newCoerceData(name string, att *design.AttributeDefinition, pointer bool, pkg string, depth int) map[string]interface{} {
 	return map[string]interface{}{
@@ -392,7 +492,9 @@ type {{ .Name }} struct {
 	*goa.RequestData
 {{ if .Params }}{{ range $name, $att := .Params.Type.ToObject }}{{/*
 */}}	{{ goify $name true }} {{ if and $att.Type.IsPrimitive ($.Params.IsPrimitivePointer $name) }}*{{ end }}{{ gotyperef .Type nil 0 false }}
-{{ end }}{{ end }}{{ if .Payload }}	Payload {{ gotyperef .Payload nil 0 false }}
+{{ end }}{{ end }}{{ if .PayloadStream }}{{ if .Payload }}	Payload *{{ gotypename .Payload nil 0 false }}Stream
+{{ else }}	Payload io.Reader
+{{ end }}{{ else if .Payload }}	Payload {{ gotyperef .Payload nil 0 false }}
 {{ end }}}
 `
 	// coerceT generates the code that coerces the generic deserialized
@@ -506,42 +608,124 @@ func New{{ .Name }}(ctx context.Context, service *goa.Service) (*{{ .Name }}, er
 */}}{{ $validation := validationChecker $att ($.Params.IsNonZero $name) ($.Params.IsRequired $name) ($.Params.HasDefaultValue $name) (printf "rctx.%s" (goify $name true)) $name 2 false }}{{/*
 */}}{{ if $validation }}{{ $validation }}
 {{ end }}	}
-{{ end }}{{ end }}{{/* if .Params */}}	return &rctx, err
+{{ end }}{{ end }}{{/* if .Params */}}{{ if .PayloadStream }}{{ if .Payload }}	rctx.Payload = &{{ gotypename .Payload nil 0 false }}Stream{dec: goa.NewElementDecoder(req.Body)}
+{{ else }}	rctx.Payload = req.Body
+{{ end }}{{ end }}	return &rctx, err
 }
 `
 
 	// ctxMTRespT generates the response helpers for responses with media types.
 	// template input: map[string]interface{}
-	ctxMTRespT = `{{ $ctx := .Context }}{{ $resp := .Response }}{{ $mt := .MediaType }}{{ $ct := .ContentType }}{{/*
+	ctxMTRespT = `{{ $ctx := .Context }}{{ $resp := .Response }}{{ $mt := .MediaType }}{{ $ct := .ContentType }}{{ $cc := .CacheControl }}{{/*
 */}}{{ range $name, $view := $mt.Views }}{{ if not (eq $name "link") }}{{ $projected := project $mt $name }}
 // {{ respName $resp $name }} sends a HTTP response with status code {{ $resp.Status }}.
 func (ctx *{{ $ctx.Name }}) {{ respName $resp $name }}(r {{ gotyperef $projected $projected.AllRequired 0 false }}) error {
 	ctx.ResponseData.Header().Set("Content-Type", "{{ $ct }}")
-	return ctx.ResponseData.Service.Send(ctx.Context, {{ $resp.Status }}, r)
+{{ if $cc }}	if ctx.ResponseData.Header().Get("Cache-Control") == "" {
+		ctx.ResponseData.Header().Set("Cache-Control", {{ printf "%q" $cc }})
+	}
+{{ end }}	return ctx.ResponseData.Service.Send(ctx.Context, {{ $resp.Status }}, r)
 }
 {{ end }}{{ end }}
 `
 
 	// ctxTRespT generates the response helpers for responses with overridden types.
 	// template input: map[string]interface{}
-	ctxTRespT = `// {{ goify .Response.Name true }} sends a HTTP response with status code {{ .Response.Status }}.
+	ctxTRespT = `{{ $cc := .CacheControl }}// {{ goify .Response.Name true }} sends a HTTP response with status code {{ .Response.Status }}.
 func (ctx *{{ .Context.Name }}) {{ goify .Response.Name true }}(r {{ gotyperef .Type nil 0 false }}) error {
 	ctx.ResponseData.Header().Set("Content-Type", "{{ .ContentType }}")
-	return ctx.ResponseData.Service.Send(ctx.Context, {{ .Response.Status }}, r)
+{{ if $cc }}	if ctx.ResponseData.Header().Get("Cache-Control") == "" {
+		ctx.ResponseData.Header().Set("Cache-Control", {{ printf "%q" $cc }})
+	}
+{{ end }}	return ctx.ResponseData.Service.Send(ctx.Context, {{ .Response.Status }}, r)
+}
+`
+
+	// ctxStreamRespT generates the response helpers and stream type for streamed responses.
+	// template input: map[string]interface{}
+	ctxStreamRespT = `// {{ .Stream }} is the stream used to send the elements of the {{ .Context.ResourceName }} {{ .Context.ActionName }} action {{ .Response.Name }} response.
+type {{ .Stream }} struct {
+	enc *goa.ElementEncoder
+}
+
+// {{ .Method }} sends the headers of a HTTP response with status code {{ .Response.Status }} and returns
+// the stream used to send the response body elements.
+func (ctx *{{ .Context.Name }}) {{ .Method }}() *{{ .Stream }} {
+	ctx.ResponseData.Header().Set("Content-Type", goa.NDJSONContentType)
+	ctx.ResponseData.WriteHeader({{ .Response.Status }})
+	return &{{ .Stream }}{enc: goa.NewElementEncoder(ctx.ResponseData)}
+}
+
+// Send writes the element to the stream and flushes it to the client.
+func (s *{{ .Stream }}) Send(r {{ .ElemType }}) error {
+	return s.enc.Encode(r)
+}
+`
+
+	// ctxEventsRespT generates the response helpers and stream type for server-sent events
+	// responses.
+	// template input: map[string]interface{}
+	ctxEventsRespT = `// {{ .Stream }} is the stream used to send the server-sent events of the {{ .Context.ResourceName }} {{ .Context.ActionName }} action {{ .Response.Name }} response.
+type {{ .Stream }} struct {
+	stream *goa.EventStream
+}
+
+// {{ .Method }} sends the headers of a HTTP response with status code {{ .Response.Status }} and returns
+// the stream used to send the server-sent events.
+func (ctx *{{ .Context.Name }}) {{ .Method }}() *{{ .Stream }} {
+	return &{{ .Stream }}{stream: goa.NewEventStream(ctx, ctx.ResponseData, {{ .Response.Status }})}
+}
+
+// Send writes an event carrying r to the stream and flushes it to the client. e sets the optional
+// event id, name and retry fields and may be nil. Send returns an error once the client disconnects.
+func (s *{{ .Stream }}) Send(r {{ .ElemType }}, e *goa.Event) error {
+	return s.stream.Send(r, e)
+}
+
+// Done returns a channel that is closed when the client disconnects.
+func (s *{{ .Stream }}) Done() <-chan struct{} {
+	return s.stream.Done()
 }
 `
 
 	// ctxNoMTRespT generates the response helpers for responses with no known media type.
 	// template input: *ContextTemplateData
-	ctxNoMTRespT = `
+	ctxNoMTRespT = `{{ $cc := .CacheControl }}{{ if .Response.Stream }}
+// {{ goify .Response.Name true }} sends the headers of a HTTP response with status code {{ .Response.Status }} and returns the
+// writer used to write the response body. The writer implements http.Flusher.
+func (ctx *{{ .Context.Name }}) {{ goify .Response.Name true }}() io.Writer {
+{{ if .Response.MediaType }}	ctx.ResponseData.Header().Set("Content-Type", "{{ .Response.MediaType }}")
+{{ end }}{{ if $cc }}	if ctx.ResponseData.Header().Get("Cache-Control") == "" {
+		ctx.ResponseData.Header().Set("Cache-Control", {{ printf "%q" $cc }})
+	}
+{{ end }}	ctx.ResponseData.WriteHeader({{ .Response.Status }})
+	return ctx.ResponseData
+}
+{{ else }}
 // {{ goify .Response.Name true }} sends a HTTP response with status code {{ .Response.Status }}.
 func (ctx *{{ .Context.Name }}) {{ goify .Response.Name true }}({{ if .Response.MediaType }}resp []byte{{ end }}) error {
 {{ if .Response.MediaType }}	ctx.ResponseData.Header().Set("Content-Type", "{{ .Response.MediaType }}")
+{{ end }}{{ if $cc }}	if ctx.ResponseData.Header().Get("Cache-Control") == "" {
+		ctx.ResponseData.Header().Set("Cache-Control", {{ printf "%q" $cc }})
+	}
 {{ end }}	ctx.ResponseData.WriteHeader({{ .Response.Status }}){{ if .Response.MediaType }}
 	_, err := ctx.ResponseData.Write(resp)
 	return err{{ else }}
 	return nil{{ end }}
 }
+{{ end }}`
+
+	// ctxErrorT generates the helper that sends an error defined in the design.
+	// template input: map[string]interface{}
+	ctxErrorT = `{{ $name := goify .Error.Name true }}
+// {{ $name }}Error sends a {{ printf "%q" .Error.Name }} error response with status code {{ .Error.Status }}.
+{{ if .Error.Description }}{{ comment .Error.Description }}
+{{ end }}func (ctx *{{ .Context.Name }}) {{ $name }}Error(format interface{}, v ...interface{}) error {
+	e := Err{{ $name }}(format, v...)
+	ctx.ResponseData.ErrorCode = e.Code
+	ctx.ResponseData.Header().Set("Content-Type", "{{ .MediaType.Identifier }}")
+	return ctx.ResponseData.Service.Send(ctx.Context, {{ .Error.Status }}, e)
+}
 `
 
 	// payloadT generates the payload type definition GoGenerator
@@ -577,12 +761,39 @@ func (payload {{ gotyperef .Payload .Payload.AllRequired 0 false }}) Validate()
 	return
 }{{ end }}
 `
+	// payloadStreamT generates the payload stream type definition.
+	// template input: *ContextTemplateData
+	payloadStreamT = `{{ $stream := printf "%sStream" (gotypename .Payload nil 0 false) }}
+// {{ $stream }} iterates over the elements of the {{ .ResourceName }} {{ .ActionName }} action payload stream.
+type {{ $stream }} struct {
+	dec *goa.ElementDecoder
+}
+
+// Next reads and validates the next element of the stream. It returns io.EOF once all the elements
+// have been read.
+func (s *{{ $stream }}) Next() ({{ gotyperef .Payload nil 0 false }}, error) {
+	{{ if .Payload.IsObject }}payload := &{{ gotypename .Payload nil 1 true }}{}
+	if err := s.dec.Decode(payload); err != nil {
+		return nil, err
+	}{{ $assignment := recursiveFinalizer .Payload.AttributeDefinition "payload" 1 }}{{ if $assignment }}
+	payload.Finalize(){{ end }}{{ else }}var payload {{ gotypename .Payload nil 1 false }}
+	if err := s.dec.Decode(&payload); err != nil {
+		return payload, err
+	}{{ end }}{{ $validation := recursiveValidate .Payload.AttributeDefinition false false false "payload" "raw" 1 .Payload.IsObject }}{{ if $validation }}
+	if err := payload.Validate(); err != nil {
+		return {{ if .Payload.IsObject }}nil{{ else }}payload{{ end }}, err
+	}{{ end }}
+	return payload{{ if .Payload.IsObject }}.Publicize(){{ end }}, nil
+}
+`
+
 	// ctrlT generates the controller interface for a given resource.
 	// template input: *ControllerTemplateData
 	ctrlT = `// {{ .Resource }}Controller is the controller interface for the {{ .Resource }} actions.
 type {{ .Resource }}Controller interface {
 	goa.Muxer
 {{ if .FileServers }}	goa.FileServer
+{{ end }}{{ if .Timeouts }}	goa.ActionTimeoutSetter
 {{ end }}{{ range .Actions }}	{{ .Name }}(*{{ .Context }}) error
 {{ end }}}
 `
@@ -604,7 +815,10 @@ func initService(service *goa.Service) {
 */}}	service.Encoder.Register({{ .PackageName }}.{{ .Function }}, "*/*")
 {{ end }}{{ end }}{{ range .Decoders }}{{ if .Default }}{{/*
 */}}	service.Decoder.Register({{ .PackageName }}.{{ .Function }}, "*/*")
-{{ end }}{{ end }}}
+{{ end }}{{ end }}{{ if .API.ProblemErrors }}
+	// Render errors as RFC 7807 problem details
+	service.ErrorFormatter = goa.NewProblemErrorFormatter("")
+{{ end }}}
 `
 
 	// mountT generates the code for a resource "Mount" function.
@@ -615,7 +829,8 @@ func Mount{{ .Resource }}Controller(service *goa.Service, ctrl {{ .Resource }}Co
 	initService(service)
 	var h goa.Handler
 {{ $res := .Resource }}{{ if .Origins }}{{ range .PreflightPaths }}{{/*
-*/}}	service.Mux.Handle("OPTIONS", "{{ . }}", ctrl.MuxHandler("preflight", handle{{ $res }}Origin(cors.HandlePreflight()), nil))
+*/}}	service.HandleRoute(&goa.Route{Method: "OPTIONS", Path: "{{ . }}", Controller: "{{ $res }}", Action: "preflight"}, ctrl.MuxHandler("preflight", handle{{ $res }}Origin(cors.HandlePreflight()), nil))
+{{ end }}{{ end }}{{ range .Actions }}{{ if .Timeout }}	ctrl.SetActionTimeout({{ printf "%q" .Name }}, {{ .Timeout }})
 {{ end }}{{ end }}{{ range .Actions }}{{ $action := . }}
 	h = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
 		// Check if there was an error loading the request
@@ -627,7 +842,7 @@ func Mount{{ .Resource }}Controller(service *goa.Service, ctrl {{ .Resource }}Co
 		if err != nil {
 			return err
 		}
-{{ if .Payload }}		// Build the payload
+{{ if and .Payload (not .PayloadStream) }}		// Build the payload
 		if rawPayload := goa.ContextRequest(ctx).Payload; rawPayload != nil {
 			rctx.Payload = rawPayload.({{ gotyperef .Payload nil 1 false }})
 {{ if not .PayloadOptional }}		} else {
@@ -636,16 +851,43 @@ func Mount{{ .Resource }}Controller(service *goa.Service, ctrl {{ .Resource }}Co
 {{ end }}		return ctrl.{{ .Name }}(rctx)
 	}
 {{ if $.Origins }}	h = handle{{ $res }}Origin(h)
+{{ end }}{{ if .Idempotent }}	h = handleIdempotency(h)
 {{ end }}{{ if .Security }}	h = handleSecurity({{ printf "%q" .Security.Scheme.SchemeName }}, h{{ range .Security.Scopes }}, {{ printf "%q" . }}{{ end }})
-{{ end }}{{ range .Routes }}	service.Mux.Handle("{{ .Verb }}", {{ printf "%q" .FullPath }}, ctrl.MuxHandler({{ printf "%q" $action.Name }}, h, {{ if $action.Payload }}{{ $action.Unmarshal }}{{ else }}nil{{ end }}))
+{{ end }}{{ range .Routes }}	service.HandleRoute(&goa.Route{Method: "{{ .Verb }}", Path: {{ printf "%q" .FullPath }}, Controller: {{ printf "%q" $res }}, Action: {{ printf "%q" $action.Name }}}, {{ if $action.PayloadStream }}ctrl.StreamMuxHandler({{ printf "%q" $action.Name }}, h){{ else }}ctrl.MuxHandler({{ printf "%q" $action.Name }}, h, {{ if $action.Payload }}{{ $action.Unmarshal }}{{ else }}nil{{ end }}){{ end }})
 	service.LogInfo("mount", "ctrl", {{ printf "%q" $res }}, "action", {{ printf "%q" $action.Name }}, "route", {{ printf "%q" (printf "%s %s" .Verb .FullPath) }}{{ with $action.Security }}, "security", {{ printf "%q" .Scheme.SchemeName }}{{ end }})
 {{ end }}{{ end }}{{ range .FileServers }}
 	h = ctrl.FileHandler("{{ .RequestPath }}", "{{ .FilePath }}")
 {{ if $.Origins }}	h = handle{{ $res }}Origin(h)
 {{ end }}{{ if .Security }}	h = handleSecurity({{ printf "%q" .Security.Scheme.SchemeName }}, h{{ range .Security.Scopes }}, {{ printf "%q" . }}{{ end }})
-{{ end }}	service.Mux.Handle("GET", "{{ .RequestPath }}", ctrl.MuxHandler("serve", h, nil))
+{{ end }}	service.HandleRoute(&goa.Route{Method: "GET", Path: "{{ .RequestPath }}", Controller: {{ printf "%q" $res }}, Action: "serve"}, ctrl.FileMuxHandler("serve", h))
 	service.LogInfo("mount", "ctrl", {{ printf "%q" $res }}, "files", {{ printf "%q" .FilePath }}, "route", {{ printf "%q" (printf "GET %s" .RequestPath) }}{{ with .Security }}, "security", {{ printf "%q" .Scheme.SchemeName }}{{ end }})
 {{ end }}}
+`
+
+	// idempotencyT generates the code that runs the idempotency middleware for the requests made
+	// to the idempotent actions.
+	// template input: nil
+	idempotencyT = `
+type (
+	// Private type used to store the idempotency middleware in the service context
+	idempotencyMiddlewareKey string
+)
+
+// UseIdempotencyMiddleware mounts the middleware that handles the requests made to the idempotent
+// actions onto the service. The middleware must be mounted before the controllers are created.
+func UseIdempotencyMiddleware(service *goa.Service, middleware goa.Middleware) {
+	service.Context = context.WithValue(service.Context, idempotencyMiddlewareKey("idempotency"), middleware)
+}
+
+// handleIdempotency creates a handler that runs the idempotency middleware if one is mounted.
+func handleIdempotency(h goa.Handler) goa.Handler {
+	return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		if m, ok := ctx.Value(idempotencyMiddlewareKey("idempotency")).(goa.Middleware); ok {
+			return m(h)(ctx, rw, req)
+		}
+		return h(ctx, rw, req)
+	}
+}
 `
 
 	// handleCORST generates the code that checks whether a CORS request is authorized
@@ -680,7 +922,7 @@ func handle{{ .Resource }}Origin(h goa.Handler) goa.Handler {
 
 	// unmarshalT generates the code for an action payload unmarshal function.
 	// template input: *ControllerTemplateData
-	unmarshalT = `{{ range .Actions }}{{ if .Payload }}
+	unmarshalT = `{{ range .Actions }}{{ if and .Payload (not .PayloadStream) }}
 // {{ .Unmarshal }} unmarshals the request body into the context request data Payload field.
 func {{ .Unmarshal }}(ctx context.Context, service *goa.Service, req *http.Request) error {
 	{{ if .Payload.IsObject }}payload := &{{ gotypename .Payload nil 1 true }}{}
@@ -762,6 +1004,15 @@ func (ut {{ gotyperef . .AllRequired 0 false }}) Validate() (err error) {
 {{ $validation }}
 	return
 }{{ end }}
+`
+
+	// errorsT generates the error classes defined in the design.
+	// template input: []*design.ErrorDefinition
+	errorsT = `var (
+{{ range . }}	// Err{{ goify .Name true }} is the class of {{ printf "%q" .Name }} errors, their status is {{ .Status }}.
+{{ if .Description }}{{ comment .Description }}
+{{ end }}	Err{{ goify .Name true }} = goa.NewErrorClass({{ printf "%q" .Name }}, {{ .Status }})
+{{ end }})
 `
 
 	// securitySchemesT generates the code for the security module.
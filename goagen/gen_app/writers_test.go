@@ -48,7 +48,10 @@ var _ = Describe("ContextsWriter", func() {
 		Context("with data", func() {
 			var params, headers *design.AttributeDefinition
 			var payload *design.UserTypeDefinition
+			var payloadStream bool
 			var responses map[string]*design.ResponseDefinition
+			var errs []*design.ErrorDefinition
+			var cacheControl string
 
 			var data *genapp.ContextTemplateData
 
@@ -56,21 +59,27 @@ var _ = Describe("ContextsWriter", func() {
 				params = nil
 				headers = nil
 				payload = nil
+				payloadStream = false
 				responses = nil
+				errs = nil
+				cacheControl = ""
 				data = nil
 			})
 
 			JustBeforeEach(func() {
 				data = &genapp.ContextTemplateData{
-					Name:         "ListBottleContext",
-					ResourceName: "bottles",
-					ActionName:   "list",
-					Params:       params,
-					Payload:      payload,
-					Headers:      headers,
-					Responses:    responses,
-					API:          design.Design,
-					DefaultPkg:   "",
+					Name:          "ListBottleContext",
+					ResourceName:  "bottles",
+					ActionName:    "list",
+					Params:        params,
+					Payload:       payload,
+					PayloadStream: payloadStream,
+					Headers:       headers,
+					Responses:     responses,
+					API:           design.Design,
+					DefaultPkg:    "",
+					Errors:        errs,
+					CacheControl:  cacheControl,
 				}
 			})
 
@@ -281,6 +290,139 @@ var _ = Describe("ContextsWriter", func() {
 				})
 			})
 
+			Context("with a streamed response", func() {
+				BeforeEach(func() {
+					design.Design = &design.APIDefinition{}
+					responses = map[string]*design.ResponseDefinition{
+						"OK": {
+							Name:      "OK",
+							Status:    200,
+							MediaType: "application/octet-stream",
+							Stream:    true,
+						},
+					}
+				})
+
+				It("writes the response writer code", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(rawStreamResponse))
+				})
+			})
+
+			Context("with a streamed response of elements", func() {
+				BeforeEach(func() {
+					responses = map[string]*design.ResponseDefinition{
+						"OK": {
+							Name:   "OK",
+							Status: 200,
+							Type:   design.String,
+							Stream: true,
+						},
+					}
+				})
+
+				It("writes the response stream code", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(elemStreamResponse))
+				})
+			})
+
+			Context("with a server-sent events response", func() {
+				BeforeEach(func() {
+					responses = map[string]*design.ResponseDefinition{
+						"OK": {
+							Name:   "OK",
+							Status: 200,
+							Stream: true,
+							Events: true,
+						},
+					}
+				})
+
+				It("writes the event stream code", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring("goa.NewEventStream(ctx, ctx.ResponseData, 200)"))
+					Ω(written).Should(ContainSubstring("Send(r string, e *goa.Event) error"))
+				})
+			})
+
+			Context("with a cache control policy", func() {
+				BeforeEach(func() {
+					cacheControl = "public, max-age=60"
+					responses = map[string]*design.ResponseDefinition{
+						"OK": {
+							Name:   "OK",
+							Status: 200,
+							Type:   design.String,
+						},
+						"NotFound": {
+							Name:   "NotFound",
+							Status: 404,
+						},
+					}
+				})
+
+				It("sets the Cache-Control header of the successful responses", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(cacheControlResponse))
+					Ω(written).Should(ContainSubstring(uncachedResponse))
+				})
+			})
+
+			Context("with errors", func() {
+				BeforeEach(func() {
+					errs = []*design.ErrorDefinition{
+						{Name: "not_found", Status: 404, Description: "Bottle not found"},
+					}
+				})
+
+				It("writes the error helpers", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(errorHelper))
+				})
+
+				Context("in an API that uses problem errors", func() {
+					var api *design.APIDefinition
+
+					BeforeEach(func() {
+						api = design.Design
+						design.Design = &design.APIDefinition{Name: "test", ProblemErrors: true}
+					})
+
+					AfterEach(func() {
+						design.Design = api
+					})
+
+					It("sets the problem media type", func() {
+						err := writer.Execute(data)
+						Ω(err).ShouldNot(HaveOccurred())
+						b, err := ioutil.ReadFile(filename)
+						Ω(err).ShouldNot(HaveOccurred())
+						Ω(string(b)).Should(ContainSubstring(`ctx.ResponseData.Header().Set("Content-Type", "application/problem+json")`))
+					})
+				})
+			})
+
 			Context("with a simple payload", func() {
 				BeforeEach(func() {
 					payload = &design.UserTypeDefinition{
@@ -331,6 +473,41 @@ var _ = Describe("ContextsWriter", func() {
 					Ω(written).Should(ContainSubstring(payloadObjContext))
 				})
 
+				Context("that is streamed", func() {
+					BeforeEach(func() {
+						payloadStream = true
+					})
+
+					It("writes the payload stream code", func() {
+						err := writer.Execute(data)
+						Ω(err).ShouldNot(HaveOccurred())
+						b, err := ioutil.ReadFile(filename)
+						Ω(err).ShouldNot(HaveOccurred())
+						written := string(b)
+						Ω(written).Should(ContainSubstring(payloadStreamContext))
+						Ω(written).Should(ContainSubstring(payloadStreamContextFactory))
+						Ω(written).Should(ContainSubstring(payloadStreamNext))
+					})
+				})
+
+				Context("with a file attribute", func() {
+					BeforeEach(func() {
+						payload.Type.ToObject()["file"] = &design.AttributeDefinition{Type: design.File}
+						payload.Validation.Required = append(payload.Validation.Required, "file")
+					})
+
+					It("writes the payload code", func() {
+						err := writer.Execute(data)
+						Ω(err).ShouldNot(HaveOccurred())
+						b, err := ioutil.ReadFile(filename)
+						Ω(err).ShouldNot(HaveOccurred())
+						written := string(b)
+						Ω(written).Should(MatchRegexp(`File\s+\*multipart.FileHeader`))
+						Ω(written).Should(ContainSubstring("pub.File = payload.File"))
+						Ω(written).Should(ContainSubstring("if payload.File == nil {"))
+					})
+				})
+
 				var _ = Describe("IterateResponses", func() {
 					var resps []*design.ResponseDefinition
 					var testIt = func(r *design.ResponseDefinition) error {
@@ -391,12 +568,17 @@ var _ = Describe("ControllersWriter", func() {
 		Context("with data", func() {
 			var actions, verbs, paths, contexts, unmarshals []string
 			var payloads []*design.UserTypeDefinition
+			var payloadStream, idempotent bool
+			var timeout string
 			var encoders, decoders []*genapp.EncoderTemplateData
 			var origins []*design.CORSDefinition
 
 			var data []*genapp.ControllerTemplateData
 
 			BeforeEach(func() {
+				payloadStream = false
+				idempotent = false
+				timeout = ""
 				actions = nil
 				verbs = nil
 				paths = nil
@@ -432,9 +614,15 @@ var _ = Describe("ControllersWriter", func() {
 								Verb: verbs[i],
 								Path: paths[i],
 							}},
-						"Context":   contexts[i],
-						"Unmarshal": unmarshal,
-						"Payload":   payload,
+						"Context":       contexts[i],
+						"Unmarshal":     unmarshal,
+						"Payload":       payload,
+						"PayloadStream": payloadStream,
+						"Idempotent":    idempotent,
+					}
+					if timeout != "" {
+						as[i]["Timeout"] = timeout
+						d.Timeouts = true
 					}
 				}
 				if len(as) > 0 {
@@ -476,6 +664,8 @@ var _ = Describe("ControllersWriter", func() {
 					Ω(written).ShouldNot(BeEmpty())
 					Ω(written).Should(ContainSubstring(simpleController))
 					Ω(written).Should(ContainSubstring(simpleMount))
+					Ω(written).ShouldNot(ContainSubstring("Idempotency"))
+					Ω(written).ShouldNot(ContainSubstring("Timeout"))
 				})
 			})
 
@@ -509,6 +699,99 @@ var _ = Describe("ControllersWriter", func() {
 					Ω(written).Should(ContainSubstring(payloadNoValidationsObjUnmarshal))
 				})
 			})
+			Context("with actions that stream the payload", func() {
+				BeforeEach(func() {
+					actions = []string{"Import"}
+					verbs = []string{"POST"}
+					paths = []string{"/bottles/import"}
+					contexts = []string{"ImportBottleContext"}
+					unmarshals = []string{"unmarshalImportBottlePayload"}
+					payloads = []*design.UserTypeDefinition{
+						{
+							TypeName: "ImportBottlePayload",
+							AttributeDefinition: &design.AttributeDefinition{
+								Type: design.Object{
+									"id": &design.AttributeDefinition{
+										Type: design.String,
+									},
+								},
+							},
+						},
+					}
+					payloadStream = true
+				})
+
+				It("mounts a stream handler and does not unmarshal the payload", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(streamMount))
+					Ω(written).ShouldNot(ContainSubstring("unmarshalImportBottlePayload"))
+				})
+			})
+
+			Context("with idempotent actions", func() {
+				BeforeEach(func() {
+					actions = []string{"Create"}
+					verbs = []string{"POST"}
+					paths = []string{"/bottles"}
+					contexts = []string{"CreateBottleContext"}
+					idempotent = true
+				})
+
+				It("runs the idempotency middleware", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring("\th = handleIdempotency(h)\n\tservice.HandleRoute(&goa.Route{Method: \"POST\", Path: \"/bottles\""))
+					Ω(written).Should(ContainSubstring("func UseIdempotencyMiddleware(service *goa.Service, middleware goa.Middleware) {"))
+					Ω(written).Should(ContainSubstring("func handleIdempotency(h goa.Handler) goa.Handler {"))
+				})
+			})
+
+			Context("with actions that define a timeout", func() {
+				BeforeEach(func() {
+					actions = []string{"List"}
+					verbs = []string{"GET"}
+					paths = []string{"/accounts/:accountID/bottles"}
+					contexts = []string{"ListBottleContext"}
+					timeout = "30 * time.Second"
+				})
+
+				It("sets the action timeouts", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring("\tgoa.Muxer\n\tgoa.ActionTimeoutSetter\n"))
+					Ω(written).Should(ContainSubstring("\tvar h goa.Handler\n\tctrl.SetActionTimeout(\"List\", 30 * time.Second)\n"))
+				})
+			})
+
+			Context("with actions that disable the timeout", func() {
+				BeforeEach(func() {
+					actions = []string{"List"}
+					verbs = []string{"GET"}
+					paths = []string{"/accounts/:accountID/bottles"}
+					contexts = []string{"ListBottleContext"}
+					timeout = "0"
+				})
+
+				It("sets a zero action timeout", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring("\tvar h goa.Handler\n\tctrl.SetActionTimeout(\"List\", 0)\n"))
+				})
+			})
+
 			Context("with actions that take a payload with a required validation", func() {
 				BeforeEach(func() {
 					actions = []string{"List"}
@@ -636,6 +919,42 @@ var _ = Describe("ControllersWriter", func() {
 	})
 })
 
+var _ = Describe("ErrorsWriter", func() {
+	var writer *genapp.ErrorsWriter
+	var workspace *codegen.Workspace
+	var filename string
+
+	BeforeEach(func() {
+		var err error
+		workspace, err = codegen.NewWorkspace("test")
+		Ω(err).ShouldNot(HaveOccurred())
+		pkg, err := workspace.NewPackage("errors")
+		Ω(err).ShouldNot(HaveOccurred())
+		src := pkg.CreateSourceFile("test.go")
+		filename = src.Abs()
+		writer, err = genapp.NewErrorsWriter(filename)
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		workspace.Delete()
+	})
+
+	It("writes the error classes", func() {
+		errs := []*design.ErrorDefinition{
+			{Name: "not_found", Status: 404},
+			{Name: "archived", Status: 410, Description: "Bottle has been archived"},
+		}
+		err := writer.Execute(errs)
+		Ω(err).ShouldNot(HaveOccurred())
+		b, err := ioutil.ReadFile(filename)
+		Ω(err).ShouldNot(HaveOccurred())
+		written := string(b)
+		Ω(written).Should(ContainSubstring(`ErrNotFound = goa.NewErrorClass("not_found", 404)`))
+		Ω(written).Should(ContainSubstring("// Bottle has been archived\n\tErrArchived = goa.NewErrorClass(\"archived\", 410)"))
+	})
+})
+
 var _ = Describe("HrefWriter", func() {
 	var writer *genapp.ResourcesWriter
 	var workspace *codegen.Workspace
@@ -1041,6 +1360,78 @@ type ListBottleContext struct {
 	*goa.RequestData
 	Payload *ListBottlePayload
 }
+`
+
+	payloadStreamContext = `
+type ListBottleContext struct {
+	context.Context
+	*goa.ResponseData
+	*goa.RequestData
+	Payload *ListBottlePayloadStream
+}
+`
+
+	payloadStreamContextFactory = `
+	rctx := ListBottleContext{Context: ctx, ResponseData: resp, RequestData: req}
+	rctx.Payload = &ListBottlePayloadStream{dec: goa.NewElementDecoder(req.Body)}
+	return &rctx, err
+}
+`
+
+	payloadStreamNext = `
+func (s *ListBottlePayloadStream) Next() (*ListBottlePayload, error) {
+	payload := &listBottlePayload{}
+	if err := s.dec.Decode(payload); err != nil {
+		return nil, err
+	}
+	if err := payload.Validate(); err != nil {
+		return nil, err
+	}
+	return payload.Publicize(), nil
+}
+`
+
+	rawStreamResponse = `
+func (ctx *ListBottleContext) OK() io.Writer {
+	ctx.ResponseData.Header().Set("Content-Type", "application/octet-stream")
+	ctx.ResponseData.WriteHeader(200)
+	return ctx.ResponseData
+}
+`
+
+	streamMount = `
+	h = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		// Check if there was an error loading the request
+		if err := goa.ContextError(ctx); err != nil {
+			return err
+		}
+		// Build the context
+		rctx, err := NewImportBottleContext(ctx, service)
+		if err != nil {
+			return err
+		}
+		return ctrl.Import(rctx)
+	}
+	service.HandleRoute(&goa.Route{Method: "POST", Path: "/bottles/import", Controller: "Bottles", Action: "Import"}, ctrl.StreamMuxHandler("Import", h))
+`
+
+	elemStreamResponse = `
+type ListBottleOKStream struct {
+	enc *goa.ElementEncoder
+}
+
+// OK sends the headers of a HTTP response with status code 200 and returns
+// the stream used to send the response body elements.
+func (ctx *ListBottleContext) OK() *ListBottleOKStream {
+	ctx.ResponseData.Header().Set("Content-Type", goa.NDJSONContentType)
+	ctx.ResponseData.WriteHeader(200)
+	return &ListBottleOKStream{enc: goa.NewElementEncoder(ctx.ResponseData)}
+}
+
+// Send writes the element to the stream and flushes it to the client.
+func (s *ListBottleOKStream) Send(r string) error {
+	return s.enc.Encode(r)
+}
 `
 
 	payloadObjUnmarshal = `
@@ -1076,7 +1467,7 @@ type BottlesController interface {
 
 	originsIntegration = `}
 	h = handleBottlesOrigin(h)
-	service.Mux.Handle`
+	service.HandleRoute`
 
 	originsHandler = `// handleBottlesOrigin applies the CORS response headers corresponding to the origin.
 func handleBottlesOrigin(h goa.Handler) goa.Handler {
@@ -1135,7 +1526,7 @@ func MountBottlesController(service *goa.Service, ctrl BottlesController) {
 		}
 		return ctrl.List(rctx)
 	}
-	service.Mux.Handle("GET", "/accounts/:accountID/bottles", ctrl.MuxHandler("List", h, nil))
+	service.HandleRoute(&goa.Route{Method: "GET", Path: "/accounts/:accountID/bottles", Controller: "Bottles", Action: "List"}, ctrl.MuxHandler("List", h, nil))
 	service.LogInfo("mount", "ctrl", "Bottles", "action", "List", "route", "GET /accounts/:accountID/bottles")
 }
 `
@@ -1156,7 +1547,7 @@ func MountBottlesController(service *goa.Service, ctrl BottlesController) {
 		}
 		return ctrl.List(rctx)
 	}
-	service.Mux.Handle("GET", "/accounts/:accountID/bottles", ctrl.MuxHandler("List", h, nil))
+	service.HandleRoute(&goa.Route{Method: "GET", Path: "/accounts/:accountID/bottles", Controller: "Bottles", Action: "List"}, ctrl.MuxHandler("List", h, nil))
 	service.LogInfo("mount", "ctrl", "Bottles", "action", "List", "route", "GET /accounts/:accountID/bottles")
 }
 `
@@ -1185,7 +1576,7 @@ type BottlesController interface {
 		}
 		return ctrl.List(rctx)
 	}
-	service.Mux.Handle("GET", "/accounts/:accountID/bottles", ctrl.MuxHandler("List", h, nil))
+	service.HandleRoute(&goa.Route{Method: "GET", Path: "/accounts/:accountID/bottles", Controller: "Bottles", Action: "List"}, ctrl.MuxHandler("List", h, nil))
 	service.LogInfo("mount", "ctrl", "Bottles", "action", "List", "route", "GET /accounts/:accountID/bottles")
 
 	h = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
@@ -1200,7 +1591,7 @@ type BottlesController interface {
 		}
 		return ctrl.Show(rctx)
 	}
-	service.Mux.Handle("GET", "/accounts/:accountID/bottles/:id", ctrl.MuxHandler("Show", h, nil))
+	service.HandleRoute(&goa.Route{Method: "GET", Path: "/accounts/:accountID/bottles/:id", Controller: "Bottles", Action: "Show"}, ctrl.MuxHandler("Show", h, nil))
 	service.LogInfo("mount", "ctrl", "Bottles", "action", "Show", "route", "GET /accounts/:accountID/bottles/:id")
 }
 `
@@ -1208,5 +1599,32 @@ type BottlesController interface {
 	simpleResourceHref = `func BottleHref(id interface{}) string {
 	return fmt.Sprintf("/bottles/%v", id)
 }
+`
+
+	errorHelper = `// NotFoundError sends a "not_found" error response with status code 404.
+// Bottle not found
+func (ctx *ListBottleContext) NotFoundError(format interface{}, v ...interface{}) error {
+	e := ErrNotFound(format, v...)
+	ctx.ResponseData.ErrorCode = e.Code
+	ctx.ResponseData.Header().Set("Content-Type", "application/vnd.api.error+json")
+	return ctx.ResponseData.Service.Send(ctx.Context, 404, e)
+}
+`
+
+	cacheControlResponse = `// OK sends a HTTP response with status code 200.
+func (ctx *ListBottleContext) OK(r string) error {
+	ctx.ResponseData.Header().Set("Content-Type", "")
+	if ctx.ResponseData.Header().Get("Cache-Control") == "" {
+		ctx.ResponseData.Header().Set("Cache-Control", "public, max-age=60")
+	}
+	return ctx.ResponseData.Service.Send(ctx.Context, 200, r)
+}
+`
+
+	uncachedResponse = `// NotFound sends a HTTP response with status code 404.
+func (ctx *ListBottleContext) NotFound() error {
+	ctx.ResponseData.WriteHeader(404)
+	return nil
+}
 `
 )
@@ -98,6 +98,7 @@ func (g *Generator) generateCommands(commandsFile string, clientPkg string, func
 	funcs["defaultRouteTemplate"] = defaultRouteTemplate
 	funcs["joinNames"] = joinNames
 	funcs["routes"] = routes
+	funcs["fileParts"] = fileParts
 
 	commandTypesTmpl := template.Must(template.New("commandTypes").Funcs(funcs).Parse(commandTypesTmpl))
 	commandsTmpl := template.Must(template.New("commands").Funcs(funcs).Parse(commandsTmpl))
@@ -109,6 +110,7 @@ func (g *Generator) generateCommands(commandsFile string, clientPkg string, func
 		codegen.SimpleImport("encoding/json"),
 		codegen.SimpleImport("fmt"),
 		codegen.SimpleImport("log"),
+		codegen.SimpleImport("mime/multipart"),
 		codegen.SimpleImport("os"),
 		codegen.SimpleImport("path"),
 		codegen.SimpleImport("path/filepath"),
@@ -145,11 +147,15 @@ func (g *Generator) generateCommands(commandsFile string, clientPkg string, func
 
 	actions := make(map[string][]*design.ActionDefinition)
 	hasDownloads := false
+	hasFileParts := false
 	api.IterateResources(func(res *design.ResourceDefinition) error {
 		if len(res.FileServers) > 0 {
 			hasDownloads = true
 		}
 		return res.IterateActions(func(action *design.ActionDefinition) error {
+			if len(fileParts(action)) > 0 {
+				hasFileParts = true
+			}
 			if as, ok := actions[action.Name]; ok {
 				actions[action.Name] = append(as, action)
 			} else {
@@ -170,6 +176,9 @@ func (g *Generator) generateCommands(commandsFile string, clientPkg string, func
 	if err := file.ExecuteTemplate("registerCmds", registerCmdsT, funcs, data); err != nil {
 		return err
 	}
+	if hasFileParts {
+		file.Write([]byte(openFileHeaderT))
+	}
 
 	err = api.IterateResources(func(res *design.ResourceDefinition) error {
 		if res.FileServers != nil {
@@ -287,6 +296,26 @@ func joinNames(atts ...*design.AttributeDefinition) string {
 	return strings.Join(elems, ", ")
 }
 
+// fileParts returns the attributes of the action multipart payload that are of type File or array
+// of File. The CLI sets these from the content of the files given on the command line as they
+// cannot be decoded from the JSON payload.
+func fileParts(a *design.ActionDefinition) design.Object {
+	if !a.PayloadMultipart || a.Payload == nil || !a.Payload.IsObject() {
+		return nil
+	}
+	files := make(design.Object)
+	for n, att := range a.Payload.ToObject() {
+		t := att.Type
+		if arr := t.ToArray(); arr != nil {
+			t = arr.ElemType.Type
+		}
+		if t.Kind() == design.FileKind {
+			files[n] = att
+		}
+	}
+	return files
+}
+
 // routes create the action command "Use" suffix.
 func routes(action *design.ActionDefinition) string {
 	var buf bytes.Buffer
@@ -436,6 +465,8 @@ const commandTypesTmpl = `{{ $cmdName := goify (printf "%s%s%s" .Name (title .Pa
 	{{ $cmdName }} struct {
 {{ if .Payload }}		Payload string
 		ContentType string
+{{ end }}{{ range $name, $att := fileParts . }}		// {{ goify $name true }}Path is the path to the file{{ if $att.Type.IsArray }}s{{ end }} sent in the {{ $name }} part{{ if $att.Type.IsArray }}s{{ end }}.
+		{{ goify $name true }}Path {{ if $att.Type.IsArray }}[]string{{ else }}string{{ end }}
 {{ end }}{{ $params := defaultRouteParams . }}{{ if $params }}{{ range $name, $att := $params.Type.ToObject }}{{ if $att.Description }}		{{ multiComment $att.Description }}
 {{ end }}		{{ goify $name true }} {{ cmdFieldType $att.Type false }}
 {{ end }}{{ end }}{{ $params := .QueryParams }}{{ if $params }}{{ range $name, $att := $params.Type.ToObject }}{{ if $att.Description }}		{{ multiComment $att.Description }}
@@ -532,8 +563,11 @@ found:
 const registerTmpl = `{{ $cmdName := goify (printf "%s%sCommand" .Action.Name (title .Resource.Name)) true }}// RegisterFlags registers the command flags with the command line.
 func (cmd *{{ $cmdName }}) RegisterFlags(cc *cobra.Command, c *{{ .Package }}.Client) {
 {{ if .Action.Payload }}	cc.Flags().StringVar(&cmd.Payload, "payload", "", "Request body encoded in JSON")
-	cc.Flags().StringVar(&cmd.ContentType, "content", "", "Request content type override, e.g. 'application/x-www-form-urlencoded'")
-{{ end }}{{ $pparams := defaultRouteParams .Action }}{{ if $pparams }}{{ range $pname, $pparam := $pparams.Type.ToObject }}{{ $tmp := goify $pname false }}{{/*
+{{ if not .Action.PayloadMultipart }}	cc.Flags().StringVar(&cmd.ContentType, "content", "", "Request content type override, e.g. 'application/x-www-form-urlencoded'")
+{{ end }}{{ end }}{{ range $name, $att := fileParts .Action }}{{ if $att.Type.IsArray }}{{/*
+*/}}	cc.Flags().StringSliceVar(&cmd.{{ goify $name true }}Path, "{{ $name }}", nil, "Paths to the files sent in the {{ $name }} parts")
+{{ else }}	cc.Flags().StringVar(&cmd.{{ goify $name true }}Path, "{{ $name }}", "", "Path to the file sent in the {{ $name }} part")
+{{ end }}{{ end }}{{ $pparams := defaultRouteParams .Action }}{{ if $pparams }}{{ range $pname, $pparam := $pparams.Type.ToObject }}{{ $tmp := goify $pname false }}{{/*
 */}}{{ if not $pparam.DefaultValue }}	var {{ $tmp }} {{ cmdFieldType $pparam.Type false }}
 {{ end }}	cc.Flags().{{ flagType $pparam }}Var(&cmd.{{ goify $pname true }}, "{{ $pname }}", {{/*
 */}}{{ if $pparam.DefaultValue }}{{ printf "%#v" $pparam.DefaultValue }}{{ else }}{{ $tmp }}{{ end }}, ` + "`" + `{{ escapeBackticks $pparam.Description }}` + "`" + `)
@@ -564,12 +598,27 @@ func (cmd *{{ $cmdName }}) Run(c *{{ .Package }}.Client, args []string) error {
 {{ else }}			return fmt.Errorf("failed to deserialize payload: %s", err)
 {{ end }}		}
 	}
-{{ end }}	logger := goa.NewLogger(log.New(os.Stderr, "", log.LstdFlags))
+{{ range $name, $att := fileParts .Action }}{{ $field := goify $name true }}{{ if $att.Type.IsArray }}{{/*
+*/}}	for _, p := range cmd.{{ $field }}Path {
+		fh, err := openFileHeader(p)
+		if err != nil {
+			return err
+		}
+		payload.{{ $field }} = append(payload.{{ $field }}, fh)
+	}
+{{ else }}	if cmd.{{ $field }}Path != "" {
+		fh, err := openFileHeader(cmd.{{ $field }}Path)
+		if err != nil {
+			return err
+		}
+		payload.{{ $field }} = fh
+	}
+{{ end }}{{ end }}{{ end }}	logger := goa.NewLogger(log.New(os.Stderr, "", log.LstdFlags))
 	ctx := goa.WithLogger(context.Background(), logger)
 	resp, err := c.{{ goify (printf "%s%s" .Action.Name (title .Resource.Name)) true }}(ctx, path{{ if .Action.Payload }}, {{/*
 	*/}}{{ if or .Action.Payload.Type.IsObject .Action.Payload.IsPrimitive }}&{{ end }}payload{{ else }}{{ end }}{{/*
 	*/}}{{ $params := joinNames .Action.QueryParams .Action.Headers }}{{ if $params }}, {{ $params }}{{ end }}{{/*
-	*/}}{{ if .Action.Payload }}, cmd.ContentType{{ end }})
+	*/}}{{ if and .Action.Payload (not .Action.PayloadMultipart) }}, cmd.ContentType{{ end }})
 	if err != nil {
 		goa.LogError(ctx, "failed", "err", err)
 		return err
@@ -580,6 +629,19 @@ func (cmd *{{ $cmdName }}) Run(c *{{ .Package }}.Client, args []string) error {
 }
 `
 
+const openFileHeaderT = `
+
+// openFileHeader reads the file at the given path into a multipart file header.
+func openFileHeader(p string) (*multipart.FileHeader, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return goaclient.NewFileHeader(filepath.Base(p), f)
+}
+`
+
 // Takes map[string][]*design.ActionDefinition as input
 const registerCmdsT = `// RegisterCommands registers the resource action CLI commands.
 func RegisterCommands(app *cobra.Command, c *{{ .Package }}.Client) {
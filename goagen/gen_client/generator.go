@@ -110,6 +110,7 @@ func (g *Generator) Generate(api *design.APIDefinition) (_ []string, err error)
 		funcs = template.FuncMap{
 			"add":             func(a, b int) int { return a + b },
 			"cmdFieldType":    cmdFieldType,
+			"decodesErrors":   decodesErrors(api),
 			"defaultPath":     defaultPath,
 			"escapeBackticks": escapeBackticks,
 			"flagType":        flagType,
@@ -246,6 +247,7 @@ func (g *Generator) generateClient(clientFile string, clientPkg string, funcs te
 func (g *Generator) generateClientResources(pkgDir, clientPkg string, funcs template.FuncMap, api *design.APIDefinition) error {
 	userTypeTmpl := template.Must(template.New("userType").Funcs(funcs).Parse(userTypeTmpl))
 	typeDecodeTmpl := template.Must(template.New("typeDecode").Funcs(funcs).Parse(typeDecodeTmpl))
+	eventDecodeTmpl := template.Must(template.New("eventDecode").Funcs(funcs).Parse(eventDecodeTmpl))
 
 	err := api.IterateResources(func(res *design.ResourceDefinition) error {
 		return g.generateResourceClient(pkgDir, res, funcs)
@@ -266,10 +268,13 @@ func (g *Generator) generateClientResources(pkgDir, clientPkg string, funcs temp
 	}
 	imports := []*codegen.ImportSpec{
 		codegen.SimpleImport("github.com/goadesign/goa"),
+		codegen.NewImport("goaclient", "github.com/goadesign/goa/client"),
+		codegen.SimpleImport("encoding/json"),
 		codegen.SimpleImport("fmt"),
 		codegen.SimpleImport("io"),
 		codegen.SimpleImport("net/http"),
 		codegen.SimpleImport("time"),
+		codegen.SimpleImport("mime/multipart"),
 		codegen.NewImport("uuid", "github.com/goadesign/goa/uuid"),
 	}
 	if err := file.WriteHeader("User Types", g.target, imports); err != nil {
@@ -293,10 +298,17 @@ func (g *Generator) generateClientResources(pkgDir, clientPkg string, funcs temp
 	}
 
 	// Generate media types used by action responses and their load helpers
+	events := make(map[string]bool)
 	err = api.IterateResources(func(res *design.ResourceDefinition) error {
 		return res.IterateActions(func(a *design.ActionDefinition) error {
 			return a.IterateResponses(func(r *design.ResponseDefinition) error {
 				if mt := api.MediaTypeWithIdentifier(r.MediaType); mt != nil {
+					if r.Events && !events[mt.TypeName] {
+						events[mt.TypeName] = true
+						if err := eventDecodeTmpl.Execute(file, mt); err != nil {
+							return err
+						}
+					}
 					if _, ok := g.generatedTypes[mt.TypeName]; !ok {
 						g.generatedTypes[mt.TypeName] = true
 						if !mt.IsBuiltIn() {
@@ -370,8 +382,10 @@ func (g *Generator) generateResourceClient(pkgDir string, res *design.ResourceDe
 		codegen.SimpleImport("strconv"),
 		codegen.SimpleImport("strings"),
 		codegen.SimpleImport("time"),
+		codegen.SimpleImport("mime/multipart"),
 		codegen.SimpleImport("golang.org/x/net/context"),
 		codegen.SimpleImport("golang.org/x/net/websocket"),
+		codegen.NewImport("goaclient", "github.com/goadesign/goa/client"),
 		codegen.NewImport("uuid", "github.com/goadesign/goa/uuid"),
 	}
 	if err := file.WriteHeader("", g.target, imports); err != nil {
@@ -531,6 +545,7 @@ func (g *Generator) generateActionClient(action *design.ActionDefinition, file *
 		Description     string
 		Routes          []*design.RouteDefinition
 		HasPayload      bool
+		Multipart       bool
 		Params          string
 		ParamNames      string
 		CanonicalScheme string
@@ -543,6 +558,7 @@ func (g *Generator) generateActionClient(action *design.ActionDefinition, file *
 		Description:     action.Description,
 		Routes:          action.Routes,
 		HasPayload:      action.Payload != nil,
+		Multipart:       action.PayloadMultipart,
 		Params:          strings.Join(params, ", "),
 		ParamNames:      strings.Join(names, ", "),
 		CanonicalScheme: action.CanonicalScheme(),
@@ -772,6 +788,16 @@ func pathParamNames(r *design.RouteDefinition) string {
 	return strings.Join(goified, ", ")
 }
 
+// decodesErrors returns a template function that returns true if the decode function generated
+// for the given media type must use the error classes defined in the design.
+func decodesErrors(api *design.APIDefinition) func(*design.MediaTypeDefinition) bool {
+	hasErrors := len(api.AllErrors()) > 0
+	errorMedia := api.ErrorMediaType()
+	return func(mt *design.MediaTypeDefinition) bool {
+		return hasErrors && mt.Identifier == errorMedia.Identifier
+	}
+}
+
 func typeName(mt *design.MediaTypeDefinition) string {
 	name := codegen.GoTypeName(mt, mt.AllRequired(), 1, false)
 	if mt.IsBuiltIn() {
@@ -813,10 +839,22 @@ type {{ gotypename . .AllRequired 1 false }} {{ gotypedef . 0 true false }}
 `
 
 const typeDecodeTmpl = `{{ $typeName := typeName . }}{{ $funcName := printf "Decode%s" $typeName }}// {{ $funcName }} decodes the {{ $typeName }} instance encoded in resp body.
-func (c *Client) {{ $funcName }}(resp *http.Response) ({{ gotyperef . .AllRequired 0 false }}, error) {
-	var decoded {{ gotypename . .AllRequired 0 false }}
+{{ if decodesErrors . }}// The error is created with the class defined in the design that corresponds to its code if there
+// is one.
+{{ end }}func (c *Client) {{ $funcName }}(resp *http.Response) ({{ if decodesErrors . }}*goa.Error{{ else }}{{ gotyperef . .AllRequired 0 false }}{{ end }}, error) {
+{{ if decodesErrors . }}	return goaclient.DecodeError(c.Decoder, resp, errorClasses)
+{{ else }}	var decoded {{ gotypename . .AllRequired 0 false }}
 	err := c.Decoder.Decode(&decoded, resp.Body, resp.Header.Get("Content-Type"))
 	return {{ if .IsObject }}&{{ end }}decoded, err
+{{ end }}}
+`
+
+const eventDecodeTmpl = `{{ $typeName := typeName . }}{{ $funcName := printf "Decode%sEvent" $typeName }}// {{ $funcName }} decodes the {{ $typeName }} instance sent in the data of a server-sent event.
+// Use goaclient.NewEventReader to read the events from the response body.
+func (c *Client) {{ $funcName }}(e *goaclient.Event) ({{ gotyperef . .AllRequired 0 false }}, error) {
+	var decoded {{ gotypename . .AllRequired 0 false }}
+	err := json.Unmarshal(e.Data, &decoded)
+	return {{ if .IsObject }}&{{ end }}decoded, err
 }
 `
 
@@ -830,8 +868,8 @@ func {{ $funcName }}({{ pathParams . }}) string {
 const clientsTmpl = `{{ $funcName := goify (printf "%s%s" .Name (title .ResourceName)) true }}{{ $desc := .Description }}{{/*
 */}}{{ if $desc }}{{ multiComment $desc }}{{ else }}{{/*
 */}}// {{ $funcName }} makes a request to the {{ .Name }} action endpoint of the {{ .ResourceName }} resource{{ end }}
-func (c *Client) {{ $funcName }}(ctx context.Context, path string{{ if .Params}},  {{ .Params }}{{ end }}{{ if .HasPayload }}, contentType string{{ end }}) (*http.Response, error) {
-	req, err := c.New{{ $funcName }}Request(ctx, path{{ if .ParamNames }}, {{ .ParamNames }}{{ end }}{{ if .HasPayload }}, contentType{{ end }})
+func (c *Client) {{ $funcName }}(ctx context.Context, path string{{ if .Params}},  {{ .Params }}{{ end }}{{ if and .HasPayload (not .Multipart) }}, contentType string{{ end }}) (*http.Response, error) {
+	req, err := c.New{{ $funcName }}Request(ctx, path{{ if .ParamNames }}, {{ .ParamNames }}{{ end }}{{ if and .HasPayload (not .Multipart) }}, contentType{{ end }})
 	if err != nil {
 		return nil, err
 	}
@@ -896,8 +934,16 @@ func (c * Client) {{ .Name }}(ctx context.Context, {{ if .DirName }}filename, {{
 
 const requestsTmpl = `{{ $funcName := goify (printf "New%s%sRequest" (title .Name) (title .ResourceName)) true }}{{/*
 */}}// {{ $funcName }} create the request corresponding to the {{ .Name }} action endpoint of the {{ .ResourceName }} resource.
-func (c *Client) {{ $funcName }}(ctx context.Context, path string{{ if .Params }}, {{ .Params }}{{ end }}{{ if .HasPayload }}, contentType string{{ end }}) (*http.Request, error) {
-{{ if .HasPayload }}	var body bytes.Buffer
+func (c *Client) {{ $funcName }}(ctx context.Context, path string{{ if .Params }}, {{ .Params }}{{ end }}{{ if and .HasPayload (not .Multipart) }}, contentType string{{ end }}) (*http.Request, error) {
+{{ if .Multipart }}	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	if err := goaclient.WriteMultipartForm(w, payload); err != nil {
+		return nil, fmt.Errorf("failed to encode body: %s", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode body: %s", err)
+	}
+{{ else if .HasPayload }}	var body bytes.Buffer
 	if contentType == "" {
 		contentType = "*/*" // Use default encoder
 	}
@@ -923,7 +969,8 @@ func (c *Client) {{ $funcName }}(ctx context.Context, path string{{ if .Params }
 		return nil, err
 	}
 {{ if or .Headers .HasPayload }}	header := req.Header
-{{ if .HasPayload }}	if contentType != "*/*" {
+{{ if .Multipart }}	header.Set("Content-Type", w.FormDataContentType())
+{{ else if .HasPayload }}	if contentType != "*/*" {
 		header.Set("Content-Type", contentType)
 	}
 {{ end }}{{ range .Headers }}{{ if .CheckNil }}	if {{ .VarName }} != nil {
@@ -976,5 +1023,20 @@ func New(c goaclient.Doer) *Client {
 func (c *Client) Set{{ $name }}(signer goaclient.Signer) {
 	c.{{ $name }} = signer
 }
-{{ end }}{{ end }}
-`
+{{ end }}{{ end }}{{ with .API.AllErrors }}
+var (
+{{ range . }}	// Err{{ goify .Name true }} is the class of {{ printf "%q" .Name }} errors, their status is {{ .Status }}.
+	Err{{ goify .Name true }} = goa.NewErrorClass({{ printf "%q" .Name }}, {{ .Status }})
+{{ end }})
+
+// errorClasses indexes the classes of the errors defined in the design by error code.
+var errorClasses = map[string]goa.ErrorClass{
+{{ range . }}	{{ printf "%q" .Name }}: Err{{ goify .Name true }},
+{{ end }}}
+{{ range . }}
+// Is{{ goify .Name true }}Error returns true if err is a {{ printf "%q" .Name }} error.
+func Is{{ goify .Name true }}Error(err error) bool {
+	e, ok := err.(*goa.Error)
+	return ok && e.Code == {{ printf "%q" .Name }}
+}
+{{ end }}{{ end }}`
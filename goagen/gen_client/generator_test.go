@@ -145,6 +145,200 @@ var _ = Describe("Generate", func() {
 		})
 	})
 
+	Context("with an action sending server-sent events", func() {
+		BeforeEach(func() {
+			ut := &design.UserTypeDefinition{
+				AttributeDefinition: &design.AttributeDefinition{
+					Type: design.Object{
+						"percent": &design.AttributeDefinition{Type: design.Integer},
+					},
+				},
+				TypeName: "Progress",
+			}
+			mt := &design.MediaTypeDefinition{
+				UserTypeDefinition: ut,
+				Identifier:         "application/vnd.progress",
+				Views: map[string]*design.ViewDefinition{
+					"default": {
+						AttributeDefinition: ut.AttributeDefinition,
+						Name:                "default",
+					},
+				},
+			}
+			design.Design = &design.APIDefinition{
+				Name:       "testapi",
+				MediaTypes: map[string]*design.MediaTypeDefinition{mt.Identifier: mt},
+				Resources: map[string]*design.ResourceDefinition{
+					"foo": {
+						Name: "foo",
+						Actions: map[string]*design.ActionDefinition{
+							"progress": {
+								Name: "progress",
+								Routes: []*design.RouteDefinition{
+									{
+										Verb: "GET",
+										Path: "/progress",
+									},
+								},
+								Responses: map[string]*design.ResponseDefinition{
+									"OK": {
+										Name:      "OK",
+										Status:    200,
+										MediaType: mt.Identifier,
+										Stream:    true,
+										Events:    true,
+									},
+								},
+							},
+						},
+					},
+				},
+			}
+			fooRes := design.Design.Resources["foo"]
+			progressAct := fooRes.Actions["progress"]
+			progressAct.Parent = fooRes
+			progressAct.Routes[0].Parent = progressAct
+		})
+
+		It("generates the event decode function", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "client", "datatypes.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(content).Should(ContainSubstring("func (c *Client) DecodeProgressEvent(e *goaclient.Event) (*Progress, error) {"))
+		})
+	})
+
+	Context("with an action accepting a multipart form", func() {
+		BeforeEach(func() {
+			payload := &design.UserTypeDefinition{
+				AttributeDefinition: &design.AttributeDefinition{
+					Type: design.Object{
+						"file":        &design.AttributeDefinition{Type: design.File},
+						"description": &design.AttributeDefinition{Type: design.String},
+					},
+				},
+				TypeName: "UploadFooPayload",
+			}
+			design.Design = &design.APIDefinition{
+				Name: "testapi",
+				Resources: map[string]*design.ResourceDefinition{
+					"foo": {
+						Name: "foo",
+						Actions: map[string]*design.ActionDefinition{
+							"upload": {
+								Name: "upload",
+								Routes: []*design.RouteDefinition{
+									{
+										Verb: "POST",
+										Path: "/upload",
+									},
+								},
+								Payload:          payload,
+								PayloadMultipart: true,
+							},
+						},
+					},
+				},
+			}
+			fooRes := design.Design.Resources["foo"]
+			uploadAct := fooRes.Actions["upload"]
+			uploadAct.Parent = fooRes
+			uploadAct.Routes[0].Parent = uploadAct
+		})
+
+		It("generates a multipart request builder", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "client", "foo.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(content)).Should(MatchRegexp(`File\s+\*multipart.FileHeader`))
+			Ω(content).Should(ContainSubstring("func (c *Client) NewUploadFooRequest(ctx context.Context, path string, payload *UploadFooPayload) (*http.Request, error) {"))
+			Ω(content).Should(ContainSubstring("goaclient.WriteMultipartForm(w, payload)"))
+			Ω(content).Should(ContainSubstring(`header.Set("Content-Type", w.FormDataContentType())`))
+		})
+
+		It("generates a CLI flag reading the file part content from a path", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "tool", "cli", "commands.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(content)).Should(MatchRegexp(`FilePath\s+string`))
+			Ω(content).Should(ContainSubstring(`cc.Flags().StringVar(&cmd.FilePath, "file", "", "Path to the file sent in the file part")`))
+			Ω(content).Should(ContainSubstring("fh, err := openFileHeader(cmd.FilePath)"))
+			Ω(content).Should(ContainSubstring("payload.File = fh"))
+			Ω(content).Should(ContainSubstring("return goaclient.NewFileHeader(filepath.Base(p), f)"))
+		})
+	})
+
+	Context("with an action that may return errors defined in the design", func() {
+		BeforeEach(func() {
+			design.Design = &design.APIDefinition{
+				Name: "testapi",
+				Resources: map[string]*design.ResourceDefinition{
+					"foo": {
+						Name: "foo",
+						Actions: map[string]*design.ActionDefinition{
+							"show": {
+								Name: "show",
+								Routes: []*design.RouteDefinition{
+									{
+										Verb: "GET",
+										Path: "",
+									},
+								},
+								Errors: map[string]*design.ErrorDefinition{
+									"not_found": {Name: "not_found", Status: 404},
+								},
+								Responses: map[string]*design.ResponseDefinition{
+									"NotFound": {
+										Name:      "NotFound",
+										Status:    404,
+										MediaType: design.ErrorMediaIdentifier,
+									},
+								},
+							},
+						},
+					},
+				},
+				MediaTypes: map[string]*design.MediaTypeDefinition{
+					design.CanonicalIdentifier(design.ErrorMediaIdentifier): design.ErrorMedia,
+				},
+			}
+			fooRes := design.Design.Resources["foo"]
+			showAct := fooRes.Actions["show"]
+			showAct.Parent = fooRes
+			showAct.Routes[0].Parent = showAct
+		})
+
+		It("generates the error classes and decodes errors with them", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "client", "client.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(content).Should(ContainSubstring(`ErrNotFound = goa.NewErrorClass("not_found", 404)`))
+			Ω(content).Should(MatchRegexp(`"not_found":\s+ErrNotFound,`))
+			Ω(content).Should(ContainSubstring("func IsNotFoundError(err error) bool {"))
+			content, err = ioutil.ReadFile(filepath.Join(outDir, "client", "datatypes.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(content).Should(ContainSubstring("return goaclient.DecodeError(c.Decoder, resp, errorClasses)"))
+		})
+
+		Context("in an API that uses problem errors", func() {
+			BeforeEach(func() {
+				design.Design.ProblemErrors = true
+				design.Design.Resources["foo"].Actions["show"].Responses["NotFound"].MediaType = design.ProblemMediaIdentifier
+				design.Design.MediaTypes = map[string]*design.MediaTypeDefinition{
+					design.CanonicalIdentifier(design.ProblemMediaIdentifier): design.ProblemMedia,
+				}
+			})
+
+			It("decodes the problem details with the error classes", func() {
+				Ω(genErr).Should(BeNil())
+				content, err := ioutil.ReadFile(filepath.Join(outDir, "client", "datatypes.go"))
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(content).Should(ContainSubstring("func (c *Client) DecodeProblem(resp *http.Response) (*goa.Error, error) {"))
+				Ω(content).Should(ContainSubstring("return goaclient.DecodeError(c.Decoder, resp, errorClasses)"))
+			})
+		})
+	})
+
 	Context("with an action with security configured", func() {
 		BeforeEach(func() {
 			codegen.TempCount = 0
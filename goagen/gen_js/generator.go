@@ -151,11 +151,17 @@ func (g *Generator) generateJS(jsFile string, api *design.APIDefinition) (_ *des
 	sort.Strings(keys)
 	for _, n := range keys {
 		for _, a := range actions[n] {
+			data := map[string]interface{}{"Action": a}
+			funcs := template.FuncMap{"params": params}
+			if sendsEvents(a) {
+				if err = file.ExecuteTemplate("jsEvents", jsEventsT, funcs, data); err != nil {
+					return
+				}
+				continue
+			}
 			if exampleAction == nil && a.Routes[0].Verb == "GET" {
 				exampleAction = a
 			}
-			data := map[string]interface{}{"Action": a}
-			funcs := template.FuncMap{"params": params}
 			if err = file.ExecuteTemplate("jsFuncs", jsFuncsT, funcs, data); err != nil {
 				return
 			}
@@ -277,6 +283,16 @@ func params(action *design.ActionDefinition) []string {
 	return params
 }
 
+// sendsEvents returns true if the action responds with a stream of server-sent events.
+func sendsEvents(action *design.ActionDefinition) bool {
+	for _, r := range action.Responses {
+		if r.Events {
+			return true
+		}
+	}
+	return false
+}
+
 const moduleT = `// This module exports functions that give access to the {{.API.Name}} API hosted at {{.API.Host}}.
 // It uses the axios javascript library for making the actual HTTP requests.
 define(['axios'] , function (axios) {
@@ -331,6 +347,35 @@ const jsFuncsT = `{{$params := params .Action}}
   }
 `
 
+const jsEventsT = `{{$params := params .Action}}
+  {{$name := printf "%s%s" .Action.Name (title .Action.Parent.Name)}}// {{if .Action.Description}}{{.Action.Description}}{{else}}{{$name}} subscribes to the server-sent events of the {{.Action.Name}} action of the {{.Action.Parent.Name}} resource.{{end}}
+  // path is the request path, the format is "{{(index .Action.Routes 0).FullPath}}"
+  {{if $params}}// {{join $params ", "}} {{if gt (len $params) 1}}are{{else}}is{{end}} used to build the request query string.
+  {{end}}// onEvent is called with each event sent by the server, the event data attribute is decoded from
+  // JSON when possible.
+  // config is an optional object given to the EventSource constructor, e.g. {withCredentials: true}.
+  // This function returns the EventSource so that the caller may listen to named events or close it.
+  client.{{$name}} = function (path{{if $params}}, {{join $params ", "}}{{end}}, onEvent, config) {
+    var url = urlPrefix + path;
+{{if $params}}    var query = [];
+{{range $params}}    if ({{.}} !== undefined && {{.}} !== null) {
+      query.push('{{.}}=' + encodeURIComponent({{.}}));
+    }
+{{end}}    if (query.length > 0) {
+      url += '?' + query.join('&');
+    }
+{{end}}    var source = new EventSource(url, config);
+    source.onmessage = function (e) {
+      var data = e.data;
+      try {
+        data = JSON.parse(data);
+      } catch (err) {}
+      onEvent({id: e.lastEventId, event: e.type, data: data});
+    };
+    return source;
+  }
+`
+
 const exampleT = `<!doctype html>
 <html>
   <head>
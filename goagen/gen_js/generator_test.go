@@ -54,6 +54,42 @@ var _ = Describe("Generate", func() {
 		})
 	})
 
+	Context("with an action sending server-sent events", func() {
+		BeforeEach(func() {
+			action := &design.ActionDefinition{
+				Name: "progress",
+				Routes: []*design.RouteDefinition{{
+					Verb: "GET",
+					Path: "/progress",
+				}},
+				Responses: map[string]*design.ResponseDefinition{
+					"OK": {Name: "OK", Status: 200, Stream: true, Events: true},
+				},
+			}
+			design.Design = &design.APIDefinition{
+				Name: "testapi",
+				Resources: map[string]*design.ResourceDefinition{
+					"job": {
+						Name: "job",
+						Actions: map[string]*design.ActionDefinition{
+							"progress": action,
+						},
+					},
+				},
+			}
+			action.Parent = design.Design.Resources["job"]
+			action.Routes[0].Parent = action
+		})
+
+		It("generates a function that subscribes to the events", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "js", "client.js"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(content)).Should(ContainSubstring("client.progressJob = function (path, onEvent, config) {"))
+			Ω(string(content)).Should(ContainSubstring("new EventSource(url, config)"))
+		})
+	})
+
 	Context("with an example action with query parameters", func() {
 		BeforeEach(func() {
 			action := &design.ActionDefinition{
@@ -6,6 +6,7 @@ import (
 	"net/url"
 	"reflect"
 	"strconv"
+	"strings"
 
 	"github.com/goadesign/goa/design"
 )
@@ -86,7 +87,7 @@ const (
 	JSONObject = "object"
 	// JSONString represents a JSON string.
 	JSONString = "string"
-	// JSONFile is an extension used by Swagger to represent a file download.
+	// JSONFile is an extension used by Swagger to represent a file download or upload.
 	JSONFile = "file"
 )
 
@@ -210,6 +211,7 @@ func GenerateResourceDefinition(api *design.APIDefinition, r *design.ResourceDef
 		for i, r := range a.Routes {
 			link := JSONLink{
 				Title:        a.Name,
+				Description:  errorsDescription(a),
 				Rel:          a.Name,
 				Href:         toSchemaHref(api, r),
 				Method:       r.Verb,
@@ -230,6 +232,24 @@ func GenerateResourceDefinition(api *design.APIDefinition, r *design.ResourceDef
 	})
 }
 
+// errorsDescription returns the description of the action link listing the error codes the
+// action may return, the empty string if the design does not define any.
+func errorsDescription(a *design.ActionDefinition) string {
+	errs := a.AllErrors()
+	if len(errs) == 0 {
+		return ""
+	}
+	codes := make([]string, len(errs))
+	for i, e := range errs {
+		codes[i] = fmt.Sprintf("%s (%d)", e.Name, e.Status)
+	}
+	desc := "Error codes: " + strings.Join(codes, ", ")
+	if a.Description != "" {
+		desc = a.Description + "\n\n" + desc
+	}
+	return desc
+}
+
 // MediaTypeRef produces the JSON reference to the media type definition.
 func MediaTypeRef(api *design.APIDefinition, mt *design.MediaTypeDefinition) string {
 	if _, ok := Definitions[mt.TypeName]; !ok {
@@ -255,6 +275,10 @@ func GenerateMediaTypeDefinition(api *design.APIDefinition, mt *design.MediaType
 	s.Title = fmt.Sprintf("Mediatype identifier: %s", mt.Identifier)
 	Definitions[mt.TypeName] = s
 	buildMediaTypeSchema(api, mt, s)
+	if mt == design.ProblemMedia {
+		// Problem details extension members
+		s.AdditionalProperties = true
+	}
 }
 
 // GenerateTypeDefinition produces the JSON schema corresponding to the given type.
@@ -547,6 +547,28 @@ func paramsFromHeaders(action *design.ActionDefinition) []*Parameter {
 	return params
 }
 
+// hasParam returns true if params contains a parameter with the given location and name. Header
+// names are compared case insensitively.
+func hasParam(params []*Parameter, in, name string) bool {
+	for _, p := range params {
+		if p.In == in && (p.Name == name || in == "header" && strings.EqualFold(p.Name, name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// paramsFromMultipartPayload returns the formData parameters corresponding to the attributes of a
+// multipart payload.
+func paramsFromMultipartPayload(payload *design.UserTypeDefinition) []*Parameter {
+	var params []*Parameter
+	payload.Type.ToObject().IterateAttributes(func(n string, at *design.AttributeDefinition) error {
+		params = append(params, paramFor(at, n, "formData", payload.IsRequired(n)))
+		return nil
+	})
+	return params
+}
+
 func paramFor(at *design.AttributeDefinition, name, in string, required bool) *Parameter {
 	p := &Parameter{
 		In:          in,
@@ -650,6 +672,22 @@ func responseFromDefinition(s *Swagger, api *design.APIDefinition, r *design.Res
 	return response, nil
 }
 
+// errorsDescription appends the list of the given errors codes to the response description desc.
+func errorsDescription(desc string, errs []*design.ErrorDefinition) string {
+	lines := make([]string, len(errs))
+	for i, e := range errs {
+		lines[i] = fmt.Sprintf("* `%s`", e.Name)
+		if e.Description != "" {
+			lines[i] += ": " + e.Description
+		}
+	}
+	list := "Error codes:\n\n" + strings.Join(lines, "\n")
+	if desc == "" {
+		return list
+	}
+	return desc + "\n\n" + list
+}
+
 func headersFromDefinition(headers *design.AttributeDefinition) (map[string]*Header, error) {
 	if headers == nil {
 		return nil, nil
@@ -692,7 +730,7 @@ func buildPathFromFileServer(s *Swagger, api *design.APIDefinition, fs *design.F
 		},
 	}
 	if len(wcs) > 0 {
-		schema := genschema.TypeSchema(api, design.ErrorMedia)
+		schema := genschema.TypeSchema(api, api.ErrorMediaType())
 		responses["404"] = &Response{Description: "File not found", Schema: schema}
 	}
 
@@ -741,6 +779,14 @@ func buildPathFromDefinition(s *Swagger, api *design.APIDefinition, route *desig
 	}
 
 	params = append(params, paramsFromHeaders(action)...)
+	if action.IsIdempotent() && !hasParam(params, "header", "Idempotency-Key") {
+		params = append(params, &Parameter{
+			In:          "header",
+			Name:        "Idempotency-Key",
+			Description: "Unique key of the request, retries sent with the same key get the response to the first request",
+			Type:        "string",
+		})
+	}
 
 	responses := make(map[string]*Response, len(action.Responses))
 	for _, r := range action.Responses {
@@ -748,10 +794,27 @@ func buildPathFromDefinition(s *Swagger, api *design.APIDefinition, route *desig
 		if err != nil {
 			return err
 		}
+		if errs := action.ErrorsWithStatus(r.Status); len(errs) > 0 {
+			resp.Description = errorsDescription(resp.Description, errs)
+		}
+		if cc := action.CacheControl(); cc != "" && r.Status >= 200 && r.Status < 300 {
+			if resp.Headers == nil {
+				resp.Headers = make(map[string]*Header)
+			}
+			resp.Headers["Cache-Control"] = &Header{
+				Description: "Cache policy of the response",
+				Type:        "string",
+				Default:     cc,
+			}
+		}
 		responses[strconv.Itoa(r.Status)] = resp
 	}
 
-	if action.Payload != nil {
+	var consumes []string
+	if action.Payload != nil && action.PayloadMultipart {
+		params = append(params, paramsFromMultipartPayload(action.Payload)...)
+		consumes = []string{design.MultipartFormContentType}
+	} else if action.Payload != nil {
 		payloadSchema := genschema.TypeSchema(api, action.Payload)
 		pp := &Parameter{
 			Name:        "payload",
@@ -786,6 +849,7 @@ func buildPathFromDefinition(s *Swagger, api *design.APIDefinition, route *desig
 		Summary:      summaryFromDefinition(action.Name, action.Metadata),
 		ExternalDocs: docsFromDefinition(action.Docs),
 		OperationID:  operationID,
+		Consumes:     consumes,
 		Parameters:   params,
 		Responses:    responses,
 		Schemes:      schemes,
@@ -0,0 +1,290 @@
+package goatest
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/goadesign/goa"
+)
+
+// TestServeMux runs the conformance test suite against the goa.ServeMux implementation created
+// by newMux. The suite checks that the behavior of the mux is the one expected by the code
+// generated by goagen so that implementations can be swapped without changing it:
+//
+//	func TestMyMux(t *testing.T) {
+//		goatest.TestServeMux(t, NewMyMux)
+//	}
+func TestServeMux(t *testing.T, newMux func() goa.ServeMux) {
+	for _, c := range muxCases {
+		t.Run(c.name, func(t *testing.T) {
+			c.run(t, newMux())
+		})
+	}
+}
+
+type (
+	// muxCase is a single mux conformance test case.
+	muxCase struct {
+		name string
+		run  func(*testing.T, goa.ServeMux)
+	}
+
+	// muxRecorder records the invocations of a mux handler.
+	muxRecorder struct {
+		called bool
+		method string
+		path   string
+		body   string
+		params url.Values
+	}
+)
+
+var muxCases = []muxCase{
+	{"static path", func(t *testing.T, mux goa.ServeMux) {
+		var rec muxRecorder
+		mux.Handle("POST", "/foo", rec.handle)
+		serveMux(mux, "POST", "/foo", "some body")
+		rec.expect(t, "POST", "/foo", url.Values{})
+		if rec.body != "some body" {
+			t.Errorf("got body %#v, expected %#v", rec.body, "some body")
+		}
+	}},
+	{"path parameters", func(t *testing.T, mux goa.ServeMux) {
+		var rec muxRecorder
+		mux.Handle("GET", "/accounts/:account/bottles/:id", rec.handle)
+		serveMux(mux, "GET", "/accounts/1/bottles/42", "")
+		rec.expect(t, "GET", "/accounts/1/bottles/42", url.Values{"account": {"1"}, "id": {"42"}})
+	}},
+	{"querystring parameters", func(t *testing.T, mux goa.ServeMux) {
+		var rec muxRecorder
+		mux.Handle("GET", "/bottles/:id", rec.handle)
+		serveMux(mux, "GET", "/bottles/42?sort=asc&tag=a&tag=b", "")
+		rec.expect(t, "GET", "/bottles/42", url.Values{"id": {"42"}, "sort": {"asc"}, "tag": {"a", "b"}})
+	}},
+	{"wildcard", func(t *testing.T, mux goa.ServeMux) {
+		var rec muxRecorder
+		mux.Handle("GET", "/assets/*filepath", rec.handle)
+		serveMux(mux, "GET", "/assets/js/app.js", "")
+		rec.expect(t, "GET", "/assets/js/app.js", url.Values{"filepath": {"js/app.js"}})
+	}},
+	{"static precedence", func(t *testing.T, mux goa.ServeMux) {
+		var static, param muxRecorder
+		mux.Handle("GET", "/bottles/:id", param.handle)
+		mux.Handle("GET", "/bottles/latest", static.handle)
+		serveMux(mux, "GET", "/bottles/latest", "")
+		static.expect(t, "GET", "/bottles/latest", url.Values{})
+		if param.called {
+			t.Errorf("path parameter handler called for static path")
+		}
+	}},
+	{"methods", func(t *testing.T, mux goa.ServeMux) {
+		var get, put muxRecorder
+		mux.Handle("GET", "/bottles/:id", get.handle)
+		mux.Handle("PUT", "/bottles/:id", put.handle)
+		serveMux(mux, "PUT", "/bottles/42", "")
+		put.expect(t, "PUT", "/bottles/42", url.Values{"id": {"42"}})
+		if get.called {
+			t.Errorf("GET handler called for PUT request")
+		}
+	}},
+	{"not found", func(t *testing.T, mux goa.ServeMux) {
+		var rec, nf muxRecorder
+		mux.Handle("GET", "/foo", rec.handle)
+		mux.HandleNotFound(nf.handle)
+		serveMux(mux, "GET", "/bar", "")
+		nf.expect(t, "GET", "/bar", nil)
+		if rec.called {
+			t.Errorf("handler called for unknown path")
+		}
+	}},
+	{"default not found", func(t *testing.T, mux goa.ServeMux) {
+		rw := serveMux(mux, "GET", "/bar", "")
+		if rw.Code != 404 {
+			t.Errorf("got status %d, expected 404", rw.Code)
+		}
+	}},
+	{"method not allowed", func(t *testing.T, mux goa.ServeMux) {
+		var rec, nf muxRecorder
+		var allowed []string
+		mux.Handle("PUT", "/foo", rec.handle)
+		mux.Handle("GET", "/foo", rec.handle)
+		mux.HandleNotFound(nf.handle)
+		mna, ok := mux.(goa.MethodNotAllowedMux)
+		if !ok {
+			t.Skip("mux does not implement goa.MethodNotAllowedMux")
+		}
+		mna.HandleMethodNotAllowed(func(rw http.ResponseWriter, req *http.Request, methods []string) {
+			allowed = methods
+		})
+		serveMux(mux, "POST", "/foo", "")
+		if rec.called || nf.called {
+			t.Errorf("handler called for disallowed method")
+		}
+		if strings.Join(allowed, ",") != "GET,HEAD,PUT" {
+			t.Errorf("got allowed methods %v, expected [GET HEAD PUT]", allowed)
+		}
+	}},
+	{"default method not allowed", func(t *testing.T, mux goa.ServeMux) {
+		if _, ok := mux.(goa.MethodNotAllowedMux); !ok {
+			t.Skip("mux does not implement goa.MethodNotAllowedMux")
+		}
+		var rec muxRecorder
+		mux.Handle("PUT", "/foo", rec.handle)
+		rw := serveMux(mux, "POST", "/foo", "")
+		if rw.Code != 405 {
+			t.Errorf("got status %d, expected 405", rw.Code)
+		}
+		if allow := rw.Header().Get("Allow"); allow != "PUT" {
+			t.Errorf("got Allow header %#v, expected %#v", allow, "PUT")
+		}
+	}},
+	{"head", func(t *testing.T, mux goa.ServeMux) {
+		var rec muxRecorder
+		mux.Handle("GET", "/foo", rec.handle)
+		serveMux(mux, "HEAD", "/foo", "")
+		rec.expect(t, "HEAD", "/foo", url.Values{})
+	}},
+	{"routes", func(t *testing.T, mux goa.ServeMux) {
+		rm, ok := mux.(goa.RouteMux)
+		if !ok {
+			t.Skip("mux does not implement goa.RouteMux")
+		}
+		var rec muxRecorder
+		show := &goa.Route{Method: "GET", Path: "/bottles/:id", Controller: "bottle", Action: "show"}
+		rm.HandleRoute(show, rec.handle)
+		mux.Handle("POST", "/bottles", rec.handle)
+		serveMux(mux, "GET", "/bottles/42", "")
+		rec.expect(t, "GET", "/bottles/42", url.Values{"id": {"42"}})
+		var routes []*goa.Route
+		rm.IterateRoutes(func(r *goa.Route) error {
+			routes = append(routes, r)
+			return nil
+		})
+		expected := []goa.Route{
+			{Method: "GET", Path: "/bottles/:id", Controller: "bottle", Action: "show"},
+			{Method: "POST", Path: "/bottles"},
+		}
+		if len(routes) != len(expected) {
+			t.Fatalf("got %d routes, expected %d", len(routes), len(expected))
+		}
+		for i, r := range routes {
+			if *r != expected[i] {
+				t.Errorf("got route %+v, expected %+v", *r, expected[i])
+			}
+		}
+	}},
+	{"service not found", func(t *testing.T, mux goa.ServeMux) {
+		service := newMuxService(mux)
+		rw := serveMux(mux, "GET", "/bar", "")
+		if rw.Code != 404 {
+			t.Errorf("got status %d, expected 404", rw.Code)
+		}
+		if !strings.Contains(rw.Body.String(), `"code":"not_found"`) {
+			t.Errorf("got body %#v, expected a not_found error", rw.Body.String())
+		}
+		if service.Mux != mux {
+			t.Errorf("service mux not set")
+		}
+	}},
+	{"service method not allowed", func(t *testing.T, mux goa.ServeMux) {
+		if _, ok := mux.(goa.MethodNotAllowedMux); !ok {
+			t.Skip("mux does not implement goa.MethodNotAllowedMux")
+		}
+		newMuxService(mux)
+		var rec muxRecorder
+		mux.Handle("PUT", "/foo", rec.handle)
+		rw := serveMux(mux, "POST", "/foo", "")
+		if rw.Code != 405 {
+			t.Errorf("got status %d, expected 405", rw.Code)
+		}
+		if allow := rw.Header().Get("Allow"); allow != "PUT, OPTIONS" {
+			t.Errorf("got Allow header %#v, expected %#v", allow, "PUT, OPTIONS")
+		}
+		if !strings.Contains(rw.Body.String(), `"code":"method_not_allowed"`) {
+			t.Errorf("got body %#v, expected a method_not_allowed error", rw.Body.String())
+		}
+	}},
+	{"service options", func(t *testing.T, mux goa.ServeMux) {
+		if _, ok := mux.(goa.MethodNotAllowedMux); !ok {
+			t.Skip("mux does not implement goa.MethodNotAllowedMux")
+		}
+		newMuxService(mux)
+		var rec muxRecorder
+		mux.Handle("PUT", "/foo", rec.handle)
+		rw := serveMux(mux, "OPTIONS", "/foo", "")
+		if rw.Code != 200 {
+			t.Errorf("got status %d, expected 200", rw.Code)
+		}
+		if allow := rw.Header().Get("Allow"); allow != "PUT, OPTIONS" {
+			t.Errorf("got Allow header %#v, expected %#v", allow, "PUT, OPTIONS")
+		}
+		if rec.called {
+			t.Errorf("handler called for OPTIONS request")
+		}
+	}},
+	{"lookup", func(t *testing.T, mux goa.ServeMux) {
+		var rec muxRecorder
+		mux.Handle("GET", "/bottles/:id", rec.handle)
+		if mux.Lookup("GET", "/bottles/:id") == nil {
+			t.Errorf("lookup of registered handler returned nil")
+		}
+		if mux.Lookup("POST", "/bottles/:id") != nil {
+			t.Errorf("lookup of unknown method returned a handler")
+		}
+		if mux.Lookup("GET", "/bottles") != nil {
+			t.Errorf("lookup of unknown path returned a handler")
+		}
+	}},
+}
+
+// newMuxService returns a service that uses mux and encodes responses in JSON.
+func newMuxService(mux goa.ServeMux) *goa.Service {
+	service := goa.New("conformance")
+	service.WithLogger(nil)
+	service.Encoder.Register(goa.NewJSONEncoder, "*/*")
+	service.SetMux(mux)
+	return service
+}
+
+// serveMux sends a request with the given method, path and body to mux.
+func serveMux(mux goa.ServeMux, method, path, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, path, strings.NewReader(body))
+	rw := httptest.NewRecorder()
+	mux.ServeHTTP(rw, req)
+	return rw
+}
+
+func (r *muxRecorder) handle(rw http.ResponseWriter, req *http.Request, params url.Values) {
+	r.called = true
+	r.method = req.Method
+	r.path = req.URL.Path
+	r.params = params
+	b, _ := ioutil.ReadAll(req.Body)
+	r.body = string(b)
+}
+
+func (r *muxRecorder) expect(t *testing.T, method, path string, params url.Values) {
+	if !r.called {
+		t.Errorf("handler not called")
+		return
+	}
+	if r.method != method {
+		t.Errorf("got method %#v, expected %#v", r.method, method)
+	}
+	if r.path != path {
+		t.Errorf("got path %#v, expected %#v", r.path, path)
+	}
+	if len(r.params) != len(params) {
+		t.Errorf("got params %v, expected %v", r.params, params)
+		return
+	}
+	for k, v := range params {
+		if strings.Join(r.params[k], ",") != strings.Join(v, ",") {
+			t.Errorf("got param %s values %v, expected %v", k, r.params[k], v)
+		}
+	}
+}
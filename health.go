@@ -0,0 +1,155 @@
+package goa
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/net/context"
+)
+
+const (
+	// LivenessPath is the request path of the liveness endpoint mounted by New.
+	LivenessPath = "/healthz"
+
+	// ReadinessPath is the request path of the readiness endpoint mounted by New.
+	ReadinessPath = "/readyz"
+)
+
+// ErrUnhealthy is the class of errors reported by the readiness endpoint for failed health
+// checks.
+var ErrUnhealthy = NewErrorClass("unhealthy", 503)
+
+type (
+	// HealthCheck is a function that reports whether a service dependency is healthy. It
+	// should return promptly once the given context is done.
+	HealthCheck func(context.Context) error
+
+	// HealthReport is the result of the health checks returned by CheckHealth.
+	HealthReport struct {
+		// Status is "ok" if the service is healthy, "unavailable" if any health check
+		// failed and "shutting_down" once Shutdown has been called.
+		Status string `json:"status" xml:"status" form:"status"`
+		// Checks lists the result of each health check indexed by name, a nil value
+		// indicates a successful check.
+		Checks map[string]*Error `json:"checks,omitempty" xml:"checks,omitempty" form:"checks,omitempty"`
+	}
+
+	// healthResponse is the response body written by the liveness and readiness endpoints.
+	// The check errors are rendered with the service ErrorFormatter.
+	healthResponse struct {
+		Status string                 `json:"status" xml:"status" form:"status"`
+		Checks map[string]interface{} `json:"checks,omitempty" xml:"checks,omitempty" form:"checks,omitempty"`
+	}
+
+	// healthCheck is a named health check.
+	healthCheck struct {
+		name  string
+		check HealthCheck
+	}
+)
+
+// AddHealthCheck registers a health check run by the readiness endpoint. Each check runs
+// concurrently with the others and is given HealthCheckTimeout to complete.
+func (service *Service) AddHealthCheck(name string, check HealthCheck) {
+	service.healthMu.Lock()
+	defer service.healthMu.Unlock()
+	service.healthChecks = append(service.healthChecks, &healthCheck{name: name, check: check})
+}
+
+// mountHealth mounts the liveness and readiness endpoints onto the service mux under
+// LivenessPath and ReadinessPath.
+func (service *Service) mountHealth() {
+	ctrl := service.NewController("Health")
+	liveness := &Route{Method: "GET", Path: LivenessPath, Controller: ctrl.Name, Action: "liveness"}
+	service.HandleRoute(liveness, ctrl.MuxHandler("liveness", service.liveness, nil))
+	readiness := &Route{Method: "GET", Path: ReadinessPath, Controller: ctrl.Name, Action: "readiness"}
+	service.HandleRoute(readiness, ctrl.MuxHandler("readiness", service.readiness, nil))
+}
+
+// CheckHealth runs all the registered health checks and returns the aggregated report.
+// The report status is "shutting_down" and no check is run once Shutdown has been called, see
+// ReadinessDelay.
+func (service *Service) CheckHealth(ctx context.Context) *HealthReport {
+	if atomic.LoadInt32(&service.shuttingDown) == 1 {
+		return &HealthReport{Status: "shutting_down"}
+	}
+	service.healthMu.Lock()
+	checks := service.healthChecks
+	service.healthMu.Unlock()
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		results = make(map[string]*Error, len(checks))
+		status  = "ok"
+	)
+	for _, hc := range checks {
+		wg.Add(1)
+		go func(hc *healthCheck) {
+			defer wg.Done()
+			err := service.runHealthCheck(ctx, hc)
+			mu.Lock()
+			defer mu.Unlock()
+			results[hc.name] = err
+			if err != nil {
+				status = "unavailable"
+			}
+		}(hc)
+	}
+	wg.Wait()
+
+	return &HealthReport{Status: status, Checks: results}
+}
+
+// runHealthCheck runs a single health check and returns the resulting error if any.
+func (service *Service) runHealthCheck(ctx context.Context, hc *healthCheck) *Error {
+	if service.HealthCheckTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, service.HealthCheckTimeout)
+		defer cancel()
+	}
+	done := make(chan error, 1)
+	go func() { done <- hc.check(ctx) }()
+	select {
+	case err := <-done:
+		if err == nil {
+			return nil
+		}
+		if e, ok := err.(*Error); ok {
+			return e
+		}
+		return ErrUnhealthy("%s", err).WithCause(err).Meta("check", hc.name)
+	case <-ctx.Done():
+		return ErrUnhealthy("health check timed out: %s", ctx.Err()).Meta("check", hc.name)
+	}
+}
+
+// liveness is the handler of the liveness endpoint, it reports the service as alive as long as
+// it is able to handle requests.
+func (service *Service) liveness(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+	return service.Send(ctx, 200, &healthResponse{Status: "ok"})
+}
+
+// readiness is the handler of the readiness endpoint, it runs the registered health checks and
+// writes the aggregated report. The errors of the failed checks are localized and rendered like
+// the errors sent by Send.
+func (service *Service) readiness(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+	report := service.CheckHealth(ctx)
+	code := 200
+	if report.Status != "ok" {
+		code = 503
+	}
+	resp := &healthResponse{Status: report.Status}
+	if len(report.Checks) > 0 {
+		resp.Checks = make(map[string]interface{}, len(report.Checks))
+		for name, e := range report.Checks {
+			if e == nil {
+				resp.Checks[name] = nil
+				continue
+			}
+			resp.Checks[name] = service.formatError(req, e)
+		}
+	}
+	return service.Send(ctx, code, resp)
+}
@@ -0,0 +1,163 @@
+package goa_test
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Health", func() {
+	var s *goa.Service
+	var rw *TestResponseWriter
+
+	BeforeEach(func() {
+		s = goa.New("health")
+		s.Encoder.Register(goa.NewJSONEncoder, "*/*")
+		s.HealthCheckTimeout = 10 * time.Millisecond
+		rw = &TestResponseWriter{ParentHeader: make(http.Header)}
+	})
+
+	serve := func(path string) {
+		req, err := http.NewRequest("GET", path, nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		s.Mux.ServeHTTP(rw, req)
+	}
+
+	Context("with no health check", func() {
+		It("reports the service as alive", func() {
+			serve(goa.LivenessPath)
+			Ω(rw.Status).Should(Equal(200))
+			Ω(string(rw.Body)).Should(Equal(`{"status":"ok"}` + "\n"))
+		})
+
+		It("reports the service as ready", func() {
+			serve(goa.ReadinessPath)
+			Ω(rw.Status).Should(Equal(200))
+			Ω(string(rw.Body)).Should(Equal(`{"status":"ok"}` + "\n"))
+		})
+	})
+
+	Context("with passing health checks", func() {
+		BeforeEach(func() {
+			s.AddHealthCheck("db", func(context.Context) error { return nil })
+		})
+
+		It("reports the service as alive", func() {
+			serve(goa.LivenessPath)
+			Ω(rw.Status).Should(Equal(200))
+			Ω(string(rw.Body)).Should(Equal(`{"status":"ok"}` + "\n"))
+		})
+
+		It("reports the service as ready", func() {
+			serve(goa.ReadinessPath)
+			Ω(rw.Status).Should(Equal(200))
+			Ω(string(rw.Body)).Should(Equal(`{"status":"ok","checks":{"db":null}}` + "\n"))
+		})
+
+		Context("and a failing health check", func() {
+			BeforeEach(func() {
+				s.AddHealthCheck("cache", func(context.Context) error { return errors.New("boom") })
+			})
+
+			It("reports the failure", func() {
+				serve(goa.ReadinessPath)
+				Ω(rw.Status).Should(Equal(503))
+				report := s.CheckHealth(context.Background())
+				Ω(report.Status).Should(Equal("unavailable"))
+				Ω(report.Checks).Should(HaveKey("db"))
+				Ω(report.Checks["db"]).Should(BeNil())
+				Ω(report.Checks["cache"].Code).Should(Equal("unhealthy"))
+				Ω(report.Checks["cache"].Detail).Should(Equal("boom"))
+			})
+
+			Context("with problem errors", func() {
+				BeforeEach(func() {
+					s.ErrorFormatter = goa.NewProblemErrorFormatter("https://example.com/errors/")
+				})
+
+				It("renders the failure with the service error formatter", func() {
+					serve(goa.ReadinessPath)
+					Ω(rw.Status).Should(Equal(503))
+					Ω(string(rw.Body)).Should(ContainSubstring(`"type":"https://example.com/errors/unhealthy"`))
+					Ω(string(rw.Body)).ShouldNot(ContainSubstring(`"code"`))
+				})
+			})
+		})
+
+		Context("and a slow health check", func() {
+			BeforeEach(func() {
+				s.AddHealthCheck("slow", func(ctx context.Context) error {
+					<-ctx.Done()
+					time.Sleep(time.Second)
+					return nil
+				})
+			})
+
+			It("times out the check", func() {
+				report := s.CheckHealth(context.Background())
+				Ω(report.Status).Should(Equal("unavailable"))
+				Ω(report.Checks["slow"].Detail).Should(ContainSubstring("timed out"))
+			})
+		})
+
+		Context("during shutdown", func() {
+			BeforeEach(func() {
+				s.DrainTimeout = 0
+				s.ReadinessDelay = 0
+				Ω(s.Shutdown(context.Background())).ShouldNot(HaveOccurred())
+			})
+
+			It("reports the service as not ready", func() {
+				serve(goa.ReadinessPath)
+				Ω(rw.Status).Should(Equal(503))
+				Ω(string(rw.Body)).Should(Equal(`{"status":"shutting_down"}` + "\n"))
+			})
+		})
+
+		Context("with a server listening", func() {
+			var l net.Listener
+			var url string
+
+			BeforeEach(func() {
+				var err error
+				l, err = net.Listen("tcp", "127.0.0.1:0")
+				Ω(err).ShouldNot(HaveOccurred())
+				url = "http://" + l.Addr().String() + goa.ReadinessPath
+				s.Server.Handler = s.Mux
+				go s.Server.Serve(l)
+				s.DrainTimeout = 0
+				s.ReadinessDelay = 200 * time.Millisecond
+			})
+
+			It("reports the service as shutting down before closing the listener", func() {
+				resp, err := http.Get(url)
+				Ω(err).ShouldNot(HaveOccurred())
+				resp.Body.Close()
+				Ω(resp.StatusCode).Should(Equal(200))
+
+				done := make(chan error, 1)
+				go func() { done <- s.Shutdown(context.Background()) }()
+				Eventually(func() int {
+					resp, err := http.Get(url)
+					if err != nil {
+						return 0
+					}
+					defer resp.Body.Close()
+					return resp.StatusCode
+				}).Should(Equal(503))
+				Consistently(done, 50*time.Millisecond).ShouldNot(Receive())
+
+				Eventually(done).Should(Receive(BeNil()))
+				_, err = http.Get(url)
+				Ω(err).Should(HaveOccurred())
+			})
+		})
+	})
+})
@@ -0,0 +1,240 @@
+package goa
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// MessageKeyMeta is the error metadata key that holds the catalog key of the error message.
+	// The value is a string or a []string for errors resulting from merging errors with messages.
+	MessageKeyMeta = "msg_key"
+	// MessageArgsMeta is the error metadata key that holds the arguments of the error message.
+	// The value is a []interface{} or a [][]interface{} for errors resulting from merging errors
+	// with messages.
+	MessageArgsMeta = "msg_args"
+)
+
+// Keys of the messages used by the validation error helper functions.
+const (
+	MsgMissingPayload       = "missing_payload"
+	MsgInvalidParamType     = "invalid_param_type"
+	MsgMissingParam         = "missing_param"
+	MsgInvalidAttributeType = "invalid_attribute_type"
+	MsgMissingAttribute     = "missing_attribute"
+	MsgMissingHeader        = "missing_header"
+	MsgInvalidEnumValue     = "invalid_enum_value"
+	MsgInvalidFormat        = "invalid_format"
+	MsgInvalidPattern       = "invalid_pattern"
+	MsgInvalidRangeMin      = "invalid_range_min"
+	MsgInvalidRangeMax      = "invalid_range_max"
+	MsgInvalidLengthMin     = "invalid_length_min"
+	MsgInvalidLengthMax     = "invalid_length_max"
+)
+
+type (
+	// Translator produces the localized messages of errors. The service Send method uses the
+	// service Translator to localize the Detail of errors that carry a message key.
+	Translator interface {
+		// Translate returns the message identified by key formatted with args in the first
+		// language of langs it supports. langs is ordered by preference. ok is false if the
+		// message cannot be produced.
+		Translate(langs []string, key string, args []interface{}) (msg string, ok bool)
+	}
+
+	// MessageCatalog is a Translator that formats messages using fmt format strings indexed by
+	// language and key. Formats may use explicit argument indexes (e.g. "%[2]s") to reorder the
+	// message arguments.
+	MessageCatalog struct {
+		// DefaultLanguage is the language used when none of the requested languages is
+		// supported, "en" for catalogs created with NewMessageCatalog.
+		DefaultLanguage string
+
+		mu      sync.RWMutex
+		formats map[string]map[string]string
+	}
+
+	// weightedLanguage is a language tag listed in an Accept-Language header with its quality.
+	weightedLanguage struct {
+		tag string
+		q   float64
+	}
+
+	// byQuality sorts weighted languages by decreasing quality.
+	byQuality []weightedLanguage
+)
+
+// englishMessages contains the formats used to produce the Detail of validation errors.
+var englishMessages = map[string]string{
+	MsgMissingPayload:       "missing required payload",
+	MsgInvalidParamType:     "invalid value %#v for parameter %#v, must be a %s",
+	MsgMissingParam:         "missing required parameter %#v",
+	MsgInvalidAttributeType: "type of %s must be %s but got value %#v",
+	MsgMissingAttribute:     "attribute %#v of %s is missing and required",
+	MsgMissingHeader:        "missing required HTTP header %#v",
+	MsgInvalidEnumValue:     "value of %s must be one of %s but got value %#v",
+	MsgInvalidFormat:        "%s must be formatted as a %s but got value %#v, %s",
+	MsgInvalidPattern:       "%s must match the regexp %#v but got value %#v",
+	MsgInvalidRangeMin:      "%s must be greater or equal than %d but got value %#v",
+	MsgInvalidRangeMax:      "%s must be lesser or equal than %d but got value %#v",
+	MsgInvalidLengthMin:     "length of %s must be greater or equal than %d but got value %#v (len=%d)",
+	MsgInvalidLengthMax:     "length of %s must be lesser or equal than %d but got value %#v (len=%d)",
+}
+
+// NewMessageCatalog returns a message catalog that contains the English messages of the
+// validation errors. Use Add to register messages in other languages.
+func NewMessageCatalog() *MessageCatalog {
+	c := &MessageCatalog{DefaultLanguage: "en", formats: make(map[string]map[string]string)}
+	c.Add("en", englishMessages)
+	return c
+}
+
+// Add registers the message formats indexed by key for the given language, overriding any
+// existing format with the same key. Languages are matched case insensitively, a region specific
+// language (e.g. "fr-CH") falls back to the base language ("fr") when it has no format for a key.
+func (c *MessageCatalog) Add(lang string, formats map[string]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.formats == nil {
+		c.formats = make(map[string]map[string]string)
+	}
+	lang = strings.ToLower(lang)
+	fs, ok := c.formats[lang]
+	if !ok {
+		fs = make(map[string]string, len(formats))
+		c.formats[lang] = fs
+	}
+	for k, f := range formats {
+		fs[k] = f
+	}
+}
+
+// Translate formats the message identified by key using the format of the first language of
+// langs that defines it, falling back to the catalog default language.
+func (c *MessageCatalog) Translate(langs []string, key string, args []interface{}) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, lang := range langs {
+		if f, ok := c.format(lang, key); ok {
+			return fmt.Sprintf(f, args...), true
+		}
+	}
+	if f, ok := c.format(c.DefaultLanguage, key); ok {
+		return fmt.Sprintf(f, args...), true
+	}
+	return "", false
+}
+
+// format returns the format of the message with the given key in the given language or in its
+// base language.
+func (c *MessageCatalog) format(lang, key string) (string, bool) {
+	lang = strings.ToLower(lang)
+	for lang != "" {
+		if f, ok := c.formats[lang][key]; ok {
+			return f, true
+		}
+		idx := strings.LastIndex(lang, "-")
+		if idx == -1 {
+			break
+		}
+		lang = lang[:idx]
+	}
+	return "", false
+}
+
+// ParseAcceptLanguage returns the language tags listed in the given Accept-Language header value
+// ordered by decreasing quality. The wildcard and the languages with a quality of 0 are omitted.
+func ParseAcceptLanguage(header string) []string {
+	var tags byQuality
+	for _, elem := range strings.Split(header, ",") {
+		parts := strings.Split(elem, ";")
+		tag := strings.TrimSpace(parts[0])
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		for _, param := range parts[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+		tags = append(tags, weightedLanguage{tag, q})
+	}
+	sort.Stable(tags)
+	langs := make([]string, len(tags))
+	for i, t := range tags {
+		langs[i] = t.tag
+	}
+	return langs
+}
+
+func (b byQuality) Len() int           { return len(b) }
+func (b byQuality) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byQuality) Less(i, j int) bool { return b[i].q > b[j].q }
+
+// Localize returns a copy of the error whose Detail is produced by t in the first language of
+// langs t supports. The message key and arguments are removed from the metadata of the copy.
+// The Detail is left unchanged if t is nil or if it cannot translate all the messages of the
+// error. Localize returns e if it does not carry a message key.
+func (e *Error) Localize(t Translator, langs []string) *Error {
+	keys, args, ok := e.messages()
+	if !ok {
+		return e
+	}
+	res := *e
+	res.MetaValues = nil
+	for k, v := range e.MetaValues {
+		if k != MessageKeyMeta && k != MessageArgsMeta {
+			res.Meta(k, v)
+		}
+	}
+	if t == nil {
+		return &res
+	}
+	msgs := make([]string, len(keys))
+	for i, key := range keys {
+		msg, ok := t.Translate(langs, key, args[i])
+		if !ok {
+			return &res
+		}
+		msgs[i] = msg
+	}
+	res.Detail = strings.Join(msgs, "; ")
+	return &res
+}
+
+// messages returns the message keys and arguments recorded in the error metadata. ok is false
+// if the error does not carry a message key.
+func (e *Error) messages() (keys []string, args [][]interface{}, ok bool) {
+	switch k := e.MetaValues[MessageKeyMeta].(type) {
+	case string:
+		a, _ := e.MetaValues[MessageArgsMeta].([]interface{})
+		return []string{k}, [][]interface{}{a}, true
+	case []string:
+		a, _ := e.MetaValues[MessageArgsMeta].([][]interface{})
+		if len(a) != len(k) {
+			return nil, nil, false
+		}
+		return k, a, true
+	}
+	return nil, nil, false
+}
+
+// invalidRequest creates an ErrInvalidRequest error whose Detail is the English message with the
+// given key and that records the key and arguments in its metadata.
+func invalidRequest(key string, args ...interface{}) *Error {
+	e := ErrInvalidRequest(englishMessages[key], args...).Meta(MessageKeyMeta, key)
+	if len(args) > 0 {
+		e.Meta(MessageArgsMeta, args)
+	}
+	return e
+}
@@ -0,0 +1,113 @@
+package goa_test
+
+import (
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MessageCatalog", func() {
+	var catalog *goa.MessageCatalog
+	var langs []string
+
+	var msg string
+	var ok bool
+
+	BeforeEach(func() {
+		catalog = goa.NewMessageCatalog()
+		catalog.Add("fr", map[string]string{goa.MsgMissingParam: "paramètre obligatoire %#v manquant"})
+		catalog.Add("de-AT", map[string]string{goa.MsgMissingParam: "Parameter %#v fehlt"})
+		langs = nil
+	})
+
+	JustBeforeEach(func() {
+		msg, ok = catalog.Translate(langs, goa.MsgMissingParam, []interface{}{"id"})
+	})
+
+	It("defaults to English", func() {
+		Ω(ok).Should(BeTrue())
+		Ω(msg).Should(Equal(goa.MissingParamError("id").Detail))
+	})
+
+	Context("with a supported language", func() {
+		BeforeEach(func() {
+			langs = []string{"it", "FR"}
+		})
+
+		It("uses the first supported language", func() {
+			Ω(ok).Should(BeTrue())
+			Ω(msg).Should(Equal(`paramètre obligatoire "id" manquant`))
+		})
+	})
+
+	Context("with a region specific language", func() {
+		BeforeEach(func() {
+			langs = []string{"fr-CA"}
+		})
+
+		It("falls back to the base language", func() {
+			Ω(msg).Should(Equal(`paramètre obligatoire "id" manquant`))
+		})
+	})
+
+	Context("with an unknown key", func() {
+		JustBeforeEach(func() {
+			msg, ok = catalog.Translate(langs, "unknown", nil)
+		})
+
+		It("does not translate", func() {
+			Ω(ok).Should(BeFalse())
+		})
+	})
+})
+
+var _ = Describe("ParseAcceptLanguage", func() {
+	It("orders the languages by quality", func() {
+		langs := goa.ParseAcceptLanguage("en;q=0.5, fr-CH, *;q=0.1, de;q=0.8, it;q=0")
+		Ω(langs).Should(Equal([]string{"fr-CH", "de", "en"}))
+	})
+
+	It("returns no language for an empty header", func() {
+		Ω(goa.ParseAcceptLanguage("")).Should(BeEmpty())
+	})
+})
+
+var _ = Describe("Localize", func() {
+	var catalog *goa.MessageCatalog
+	var gerr *goa.Error
+
+	BeforeEach(func() {
+		catalog = goa.NewMessageCatalog()
+		catalog.Add("fr", map[string]string{
+			goa.MsgMissingParam:    "paramètre obligatoire %#v manquant",
+			goa.MsgInvalidRangeMin: "%s doit être supérieur ou égal à %d, valeur reçue %#v",
+		})
+		gerr = goa.MissingParamError("id")
+	})
+
+	It("records the message key and arguments", func() {
+		Ω(gerr.MetaValues).Should(HaveKeyWithValue(goa.MessageKeyMeta, goa.MsgMissingParam))
+		Ω(gerr.MetaValues).Should(HaveKeyWithValue(goa.MessageArgsMeta, []interface{}{"id"}))
+	})
+
+	It("translates the detail and removes the message metadata", func() {
+		loc := gerr.Localize(catalog, []string{"fr"})
+		Ω(loc.Detail).Should(Equal(`paramètre obligatoire "id" manquant`))
+		Ω(loc.MetaValues).ShouldNot(HaveKey(goa.MessageKeyMeta))
+		Ω(loc.MetaValues).ShouldNot(HaveKey(goa.MessageArgsMeta))
+		Ω(gerr.MetaValues).Should(HaveKey(goa.MessageKeyMeta))
+	})
+
+	It("translates merged errors", func() {
+		err := goa.MergeErrors(gerr, goa.InvalidRangeError("payload.count", 0, 1, true))
+		loc := err.(*goa.Error).Localize(catalog, []string{"fr"})
+		Ω(loc.Detail).Should(Equal(`paramètre obligatoire "id" manquant; payload.count doit être supérieur ou égal à 1, valeur reçue 0`))
+	})
+
+	It("keeps the detail of merged errors without messages", func() {
+		err := goa.MergeErrors(gerr, goa.ErrInvalidRequest("boom"))
+		Ω(err.(*goa.Error).MetaValues).ShouldNot(HaveKey(goa.MessageKeyMeta))
+		loc := err.(*goa.Error).Localize(catalog, []string{"fr"})
+		Ω(loc.Detail).Should(Equal(`missing required parameter "id"; boom`))
+	})
+})
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"log"
+	"strings"
 
 	"golang.org/x/net/context"
 )
@@ -11,6 +12,18 @@ import (
 // ErrMissingLogValue is the value used to log keys with missing values
 const ErrMissingLogValue = "MISSING"
 
+// Log levels ordered by increasing severity.
+const (
+	// LevelDebug is the level of the messages that help troubleshooting such as request payloads.
+	LevelDebug LogLevel = iota
+	// LevelInfo is the level of informational messages, it is the default minimum level.
+	LevelInfo
+	// LevelWarn is the level of the messages that describe unexpected but handled conditions.
+	LevelWarn
+	// LevelError is the level of error messages.
+	LevelError
+)
+
 type (
 	// LogAdapter is the logger interface used by goa to log informational and error messages.
 	// Adapters to different logging backends are provided in the logging sub-packages.
@@ -25,6 +38,22 @@ type (
 		New(keyvals ...interface{}) LogAdapter
 	}
 
+	// LeveledLogAdapter is implemented by the log adapters that also support the debug and
+	// warning levels. All the adapters provided in the logging sub-packages implement it.
+	// LogDebug and LogWarn log with Info and Error respectively when the logger does not.
+	LeveledLogAdapter interface {
+		LogAdapter
+		// Debug logs a debug message.
+		Debug(msg string, keyvals ...interface{})
+		// Warn logs a warning.
+		Warn(msg string, keyvals ...interface{})
+	}
+
+	// LogLevel is the severity of a log message. The LogDebug, LogInfo, LogWarn and LogError
+	// functions discard the messages whose level is lower than the minimum level stored in the
+	// context, see WithLogLevel.
+	LogLevel int
+
 	// adapter is the stdlib logger adapter.
 	adapter struct {
 		*log.Logger
@@ -46,12 +75,20 @@ func Logger(ctx context.Context) *log.Logger {
 	return nil
 }
 
+func (a *adapter) Debug(msg string, keyvals ...interface{}) {
+	a.logit(msg, keyvals, "DBUG")
+}
+
 func (a *adapter) Info(msg string, keyvals ...interface{}) {
-	a.logit(msg, keyvals, false)
+	a.logit(msg, keyvals, "INFO")
+}
+
+func (a *adapter) Warn(msg string, keyvals ...interface{}) {
+	a.logit(msg, keyvals, "WARN")
 }
 
 func (a *adapter) Error(msg string, keyvals ...interface{}) {
-	a.logit(msg, keyvals, true)
+	a.logit(msg, keyvals, "EROR")
 }
 
 func (a *adapter) New(keyvals ...interface{}) LogAdapter {
@@ -70,7 +107,7 @@ func (a *adapter) New(keyvals ...interface{}) LogAdapter {
 	}
 }
 
-func (a *adapter) logit(msg string, keyvals []interface{}, iserror bool) {
+func (a *adapter) logit(msg string, keyvals []interface{}, lvl string) {
 	n := (len(keyvals) + 1) / 2
 	if len(keyvals)%2 != 0 {
 		keyvals = append(keyvals, ErrMissingLogValue)
@@ -81,10 +118,6 @@ func (a *adapter) logit(msg string, keyvals []interface{}, iserror bool) {
 	}
 	n += m
 	var fm bytes.Buffer
-	lvl := "INFO"
-	if iserror {
-		lvl = "EROR"
-	}
 	fm.WriteString(fmt.Sprintf("[%s] %s", lvl, msg))
 	vals := make([]interface{}, n)
 	offset := len(a.keyvals)
@@ -103,14 +136,72 @@ func (a *adapter) logit(msg string, keyvals []interface{}, iserror bool) {
 	a.Logger.Printf(fm.String(), vals...)
 }
 
+// ParseLogLevel returns the log level with the given name: "debug", "info", "warn" or "error".
+// The name is case insensitive. ok is false if the name is not a valid level.
+func ParseLogLevel(name string) (level LogLevel, ok bool) {
+	switch strings.ToLower(name) {
+	case "debug":
+		return LevelDebug, true
+	case "info":
+		return LevelInfo, true
+	case "warn", "warning":
+		return LevelWarn, true
+	case "error":
+		return LevelError, true
+	}
+	return LevelInfo, false
+}
+
+// String returns the lower case name of the level.
+func (l LogLevel) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	}
+	return fmt.Sprintf("LogLevel(%d)", int(l))
+}
+
+// LogDebug extracts the logger from the given context and calls Debug on it if the context
+// minimum log level is LevelDebug. It calls Info instead if the logger does not implement
+// LeveledLogAdapter.
+// This is intended for code that needs portable logging such as the internal code of goa and
+// middleware. User code should use the log adapters instead.
+func LogDebug(ctx context.Context, msg string, keyvals ...interface{}) {
+	if logger := leveledLogger(ctx, LevelDebug); logger != nil {
+		if l, ok := logger.(LeveledLogAdapter); ok {
+			l.Debug(msg, keyvals...)
+			return
+		}
+		logger.Info(msg, keyvals...)
+	}
+}
+
 // LogInfo extracts the logger from the given context and calls Info on it.
 // This is intended for code that needs portable logging such as the internal code of goa and
 // middleware. User code should use the log adapters instead.
 func LogInfo(ctx context.Context, msg string, keyvals ...interface{}) {
-	if l := ctx.Value(logKey); l != nil {
-		if logger, ok := l.(LogAdapter); ok {
-			logger.Info(msg, keyvals...)
+	if logger := leveledLogger(ctx, LevelInfo); logger != nil {
+		logger.Info(msg, keyvals...)
+	}
+}
+
+// LogWarn extracts the logger from the given context and calls Warn on it. It calls Error instead
+// if the logger does not implement LeveledLogAdapter.
+// This is intended for code that needs portable logging such as the internal code of goa and
+// middleware. User code should use the log adapters instead.
+func LogWarn(ctx context.Context, msg string, keyvals ...interface{}) {
+	if logger := leveledLogger(ctx, LevelWarn); logger != nil {
+		if l, ok := logger.(LeveledLogAdapter); ok {
+			l.Warn(msg, keyvals...)
+			return
 		}
+		logger.Error(msg, keyvals...)
 	}
 }
 
@@ -118,9 +209,21 @@ func LogInfo(ctx context.Context, msg string, keyvals ...interface{}) {
 // This is intended for code that needs portable logging such as the internal code of goa and
 // middleware. User code should use the log adapters instead.
 func LogError(ctx context.Context, msg string, keyvals ...interface{}) {
+	if logger := leveledLogger(ctx, LevelError); logger != nil {
+		logger.Error(msg, keyvals...)
+	}
+}
+
+// leveledLogger returns the logger stored in the context if messages with the given level
+// should be logged, nil otherwise.
+func leveledLogger(ctx context.Context, level LogLevel) LogAdapter {
+	if level < ContextLogLevel(ctx) {
+		return nil
+	}
 	if l := ctx.Value(logKey); l != nil {
 		if logger, ok := l.(LogAdapter); ok {
-			logger.Error(msg, keyvals...)
+			return logger
 		}
 	}
+	return nil
 }
@@ -0,0 +1,156 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/goadesign/goa"
+)
+
+type (
+	// Record is a message logged by a goa.LogAdapter as recorded by the adapter backend.
+	Record struct {
+		// Level is the level of the message mapped from the backend level.
+		Level goa.LogLevel
+		// Msg is the logged message.
+		Msg string
+		// Fields contains the key/value pairs logged with the message including the ones
+		// of the logger context. The values are formatted with fmt.Sprint.
+		Fields map[string]string
+	}
+
+	// AdapterFactory creates the adapter under test. It returns the adapter together with a
+	// function that returns the messages logged so far. The adapter backend must log all the
+	// levels including debug.
+	AdapterFactory func() (adapter goa.LogAdapter, records func() []Record)
+
+	// adapterCase is a single log adapter conformance test case.
+	adapterCase struct {
+		name string
+		run  func(*testing.T, goa.LogAdapter, func() []Record)
+	}
+)
+
+// TestAdapter runs the conformance test suite against the goa.LogAdapter implementations created
+// by newAdapter. The suite checks the mapping of the log levels, the handling of key/value pairs
+// including odd numbers of keyvals and the chaining of logger contexts with New. The adapters must
+// implement goa.LeveledLogAdapter:
+//
+//	func TestConformance(t *testing.T) {
+//		logging.TestAdapter(t, newTestAdapter)
+//	}
+func TestAdapter(t *testing.T, newAdapter AdapterFactory) {
+	for _, c := range adapterCases {
+		t.Run(c.name, func(t *testing.T) {
+			adapter, records := newAdapter()
+			c.run(t, adapter, records)
+		})
+	}
+}
+
+var adapterCases = []adapterCase{
+	{"levels", func(t *testing.T, a goa.LogAdapter, records func() []Record) {
+		la, ok := a.(goa.LeveledLogAdapter)
+		if !ok {
+			t.Fatalf("adapter %T does not implement goa.LeveledLogAdapter", a)
+		}
+		la.Debug("debug")
+		la.Info("info")
+		la.Warn("warn")
+		la.Error("error")
+		rs := expectRecords(t, records, 4)
+		expected := []goa.LogLevel{goa.LevelDebug, goa.LevelInfo, goa.LevelWarn, goa.LevelError}
+		for i, r := range rs {
+			if r.Level != expected[i] {
+				t.Errorf("got level %s for message %#v, expected %s", r.Level, r.Msg, expected[i])
+			}
+			if r.Msg != expected[i].String() {
+				t.Errorf("got message %#v, expected %#v", r.Msg, expected[i].String())
+			}
+		}
+	}},
+	{"key/values", func(t *testing.T, a goa.LogAdapter, records func() []Record) {
+		a.Info("msg", "k1", "v1", "k2", 42)
+		rs := expectRecords(t, records, 1)
+		expectFields(t, rs[0], map[string]string{"k1": "v1", "k2": "42"}, nil)
+	}},
+	{"missing value", func(t *testing.T, a goa.LogAdapter, records func() []Record) {
+		a.Info("msg", "k1", "v1", "k2")
+		rs := expectRecords(t, records, 1)
+		expectFields(t, rs[0], map[string]string{"k1": "v1", "k2": goa.ErrMissingLogValue}, nil)
+	}},
+	{"error values", func(t *testing.T, a goa.LogAdapter, records func() []Record) {
+		a.Error("failed", "err", errors.New("boom"))
+		rs := expectRecords(t, records, 1)
+		if rs[0].Level != goa.LevelError {
+			t.Errorf("got level %s, expected %s", rs[0].Level, goa.LevelError)
+		}
+		expectFields(t, rs[0], map[string]string{"err": "boom"}, nil)
+	}},
+	{"context", func(t *testing.T, a goa.LogAdapter, records func() []Record) {
+		a.New("c1", "v1").New("c2", "v2").Error("msg", "k", "v")
+		rs := expectRecords(t, records, 1)
+		expectFields(t, rs[0], map[string]string{"c1": "v1", "c2": "v2", "k": "v"}, nil)
+	}},
+	{"context missing value", func(t *testing.T, a goa.LogAdapter, records func() []Record) {
+		a.New("c1").Info("msg", "k", "v")
+		rs := expectRecords(t, records, 1)
+		expectFields(t, rs[0], map[string]string{"c1": goa.ErrMissingLogValue, "k": "v"}, nil)
+	}},
+	{"context isolation", func(t *testing.T, a goa.LogAdapter, records func() []Record) {
+		base := a.New("c1", "v1")
+		first := base.New("c2", "v2")
+		second := base.New("c3", "v3")
+		a.Info("root")
+		base.Info("base")
+		first.Info("first")
+		second.Info("second")
+		rs := expectRecords(t, records, 4)
+		expectFields(t, rs[0], nil, []string{"c1", "c2", "c3"})
+		expectFields(t, rs[1], map[string]string{"c1": "v1"}, []string{"c2", "c3"})
+		expectFields(t, rs[2], map[string]string{"c1": "v1", "c2": "v2"}, []string{"c3"})
+		expectFields(t, rs[3], map[string]string{"c1": "v1", "c3": "v3"}, []string{"c2"})
+	}},
+}
+
+// expectRecords checks that n messages were logged and returns them.
+func expectRecords(t *testing.T, records func() []Record, n int) []Record {
+	rs := records()
+	if len(rs) != n {
+		t.Fatalf("got %d messages, expected %d", len(rs), n)
+	}
+	return rs
+}
+
+// expectFields checks that the record contains the expected fields and none of the absent fields.
+func expectFields(t *testing.T, r Record, expected map[string]string, absent []string) {
+	for k, v := range expected {
+		actual, ok := r.Fields[k]
+		if !ok {
+			t.Errorf("message %#v: missing key %#v", r.Msg, k)
+			continue
+		}
+		if actual != v {
+			t.Errorf("message %#v: got value %#v for key %#v, expected %#v", r.Msg, actual, k, v)
+		}
+	}
+	for _, k := range absent {
+		if v, ok := r.Fields[k]; ok {
+			t.Errorf("message %#v: unexpected key %#v with value %#v", r.Msg, k, v)
+		}
+	}
+}
+
+// Fields builds the fields of a record from a list of key/value pairs.
+func Fields(keyvals ...interface{}) map[string]string {
+	fields := make(map[string]string, (len(keyvals)+1)/2)
+	for i := 0; i < len(keyvals); i += 2 {
+		var v interface{} = goa.ErrMissingLogValue
+		if i+1 < len(keyvals) {
+			v = keyvals[i+1]
+		}
+		fields[fmt.Sprint(keyvals[i])] = fmt.Sprint(v)
+	}
+	return fields
+}
@@ -20,6 +20,16 @@ Once instantiated adapters can be used by setting the goa service logger with Wi
 }
 ```
 
+goa discards the messages whose level is lower than the service minimum level, LevelInfo by
+default. Use the service WithLogLevel method to change it:
+
+```go
+    service.WithLogLevel(goa.LevelDebug)
+```
+
+TestAdapter runs a conformance test suite against an adapter, new adapters should call it from
+their tests.
+
 See http://goa.design/implement/logging/ for details.
 */
 package logging
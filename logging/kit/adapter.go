@@ -44,21 +44,43 @@ func Context(ctx context.Context) *log.Context {
 	return nil
 }
 
+// Debug logs debug messages using go-kit.
+func (a *adapter) Debug(msg string, data ...interface{}) {
+	ctx := []interface{}{"lvl", "debug", "msg", msg}
+	ctx = append(ctx, padKeyvals(data)...)
+	a.Context.Log(ctx...)
+}
+
 // Info logs informational messages using go-kit.
 func (a *adapter) Info(msg string, data ...interface{}) {
 	ctx := []interface{}{"lvl", "info", "msg", msg}
-	ctx = append(ctx, data...)
+	ctx = append(ctx, padKeyvals(data)...)
+	a.Context.Log(ctx...)
+}
+
+// Warn logs warning messages using go-kit.
+func (a *adapter) Warn(msg string, data ...interface{}) {
+	ctx := []interface{}{"lvl", "warn", "msg", msg}
+	ctx = append(ctx, padKeyvals(data)...)
 	a.Context.Log(ctx...)
 }
 
 // Error logs error messages using go-kit.
 func (a *adapter) Error(msg string, data ...interface{}) {
 	ctx := []interface{}{"lvl", "error", "msg", msg}
-	ctx = append(ctx, data...)
+	ctx = append(ctx, padKeyvals(data)...)
 	a.Context.Log(ctx...)
 }
 
 // New instantiates a new logger from the given context.
 func (a *adapter) New(data ...interface{}) goa.LogAdapter {
-	return &adapter{Context: a.Context.With(data...)}
+	return &adapter{Context: a.Context.With(padKeyvals(data)...)}
+}
+
+// padKeyvals appends goa.ErrMissingLogValue to keyvals if it has an odd length.
+func padKeyvals(keyvals []interface{}) []interface{} {
+	if len(keyvals)%2 != 0 {
+		return append(keyvals, goa.ErrMissingLogValue)
+	}
+	return keyvals
 }
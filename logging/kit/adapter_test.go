@@ -2,11 +2,13 @@ package goakit_test
 
 import (
 	"bytes"
+	"testing"
 
 	"golang.org/x/net/context"
 
 	"github.com/go-kit/kit/log"
 	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/logging"
 	"github.com/goadesign/goa/logging/kit"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -27,6 +29,14 @@ var _ = Describe("New", func() {
 		adapter.Info(msg)
 		Ω(buf.String()).Should(Equal("lvl=info msg=" + msg + "\n"))
 	})
+
+	It("logs the debug and warn levels", func() {
+		buf.Reset()
+		leveled := adapter.(goa.LeveledLogAdapter)
+		leveled.Debug("debug")
+		leveled.Warn("warn")
+		Ω(buf.String()).Should(Equal("lvl=debug msg=debug\nlvl=warn msg=warn\n"))
+	})
 })
 
 var _ = Describe("FromContext", func() {
@@ -58,3 +68,37 @@ var _ = Describe("FromContext", func() {
 		})
 	})
 })
+
+func TestConformance(t *testing.T) {
+	logging.TestAdapter(t, func() (goa.LogAdapter, func() []logging.Record) {
+		rec := new(recorder)
+		records := func() []logging.Record {
+			rs := make([]logging.Record, len(rec.entries))
+			for i, keyvals := range rec.entries {
+				fields := logging.Fields(keyvals...)
+				rs[i] = logging.Record{Level: levels[fields["lvl"]], Msg: fields["msg"], Fields: fields}
+				delete(fields, "lvl")
+				delete(fields, "msg")
+			}
+			return rs
+		}
+		return goakit.New(rec), records
+	})
+}
+
+// recorder is a go-kit logger that records the logged key/value pairs.
+type recorder struct {
+	entries [][]interface{}
+}
+
+func (r *recorder) Log(keyvals ...interface{}) error {
+	r.entries = append(r.entries, keyvals)
+	return nil
+}
+
+var levels = map[string]goa.LogLevel{
+	"debug": goa.LevelDebug,
+	"info":  goa.LevelInfo,
+	"warn":  goa.LevelWarn,
+	"error": goa.LevelError,
+}
@@ -38,17 +38,35 @@ func Logger(ctx context.Context) log15.Logger {
 	return nil
 }
 
+// Debug logs debug messages using log15.
+func (a *adapter) Debug(msg string, data ...interface{}) {
+	a.Logger.Debug(msg, padKeyvals(data)...)
+}
+
 // Info logs informational messages using log15.
 func (a *adapter) Info(msg string, data ...interface{}) {
-	a.Logger.Info(msg, data...)
+	a.Logger.Info(msg, padKeyvals(data)...)
+}
+
+// Warn logs warning messages using log15.
+func (a *adapter) Warn(msg string, data ...interface{}) {
+	a.Logger.Warn(msg, padKeyvals(data)...)
 }
 
 // Error logs error messages using log15.
 func (a *adapter) Error(msg string, data ...interface{}) {
-	a.Logger.Error(msg, data...)
+	a.Logger.Error(msg, padKeyvals(data)...)
 }
 
 // New creates a new logger given a context.
 func (a *adapter) New(data ...interface{}) goa.LogAdapter {
-	return &adapter{Logger: a.Logger.New(data...)}
+	return &adapter{Logger: a.Logger.New(padKeyvals(data)...)}
+}
+
+// padKeyvals appends goa.ErrMissingLogValue to keyvals if it has an odd length.
+func padKeyvals(keyvals []interface{}) []interface{} {
+	if len(keyvals)%2 != 0 {
+		return append(keyvals, goa.ErrMissingLogValue)
+	}
+	return keyvals
 }
@@ -1,7 +1,10 @@
 package goalog15_test
 
 import (
+	"testing"
+
 	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/logging"
 	"github.com/goadesign/goa/logging/log15"
 	"github.com/inconshreveable/log15"
 	. "github.com/onsi/ginkgo"
@@ -37,6 +40,15 @@ var _ = Describe("New", func() {
 		Ω(handler.records[0].Msg).Should(ContainSubstring(msg))
 	})
 
+	It("logs the debug and warn levels", func() {
+		leveled := adapter.(goa.LeveledLogAdapter)
+		leveled.Debug("debug")
+		leveled.Warn("warn")
+		Ω(handler.records).Should(HaveLen(2))
+		Ω(handler.records[0].Lvl).Should(Equal(log15.LvlDebug))
+		Ω(handler.records[1].Lvl).Should(Equal(log15.LvlWarn))
+	})
+
 	Context("Logger", func() {
 		var ctx context.Context
 
@@ -49,3 +61,26 @@ var _ = Describe("New", func() {
 		})
 	})
 })
+
+func TestConformance(t *testing.T) {
+	logging.TestAdapter(t, func() (goa.LogAdapter, func() []logging.Record) {
+		logger := log15.New()
+		handler := new(TestHandler)
+		logger.SetHandler(handler)
+		records := func() []logging.Record {
+			rs := make([]logging.Record, len(handler.records))
+			for i, r := range handler.records {
+				rs[i] = logging.Record{Level: levels[r.Lvl], Msg: r.Msg, Fields: logging.Fields(r.Ctx...)}
+			}
+			return rs
+		}
+		return goalog15.New(logger), records
+	})
+}
+
+var levels = map[log15.Lvl]goa.LogLevel{
+	log15.LvlDebug: goa.LevelDebug,
+	log15.LvlInfo:  goa.LevelInfo,
+	log15.LvlWarn:  goa.LevelWarn,
+	log15.LvlError: goa.LevelError,
+}
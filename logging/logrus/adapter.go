@@ -46,11 +46,21 @@ func Entry(ctx context.Context) *logrus.Entry {
 	return nil
 }
 
+// Debug logs debug messages using logrus.
+func (a *adapter) Debug(msg string, data ...interface{}) {
+	a.Entry.WithFields(data2rus(data)).Debug(msg)
+}
+
 // Info logs messages using logrus.
 func (a *adapter) Info(msg string, data ...interface{}) {
 	a.Entry.WithFields(data2rus(data)).Info(msg)
 }
 
+// Warn logs warnings using logrus.
+func (a *adapter) Warn(msg string, data ...interface{}) {
+	a.Entry.WithFields(data2rus(data)).Warn(msg)
+}
+
 // Error logs errors using logrus.
 func (a *adapter) Error(msg string, data ...interface{}) {
 	a.Entry.WithFields(data2rus(data)).Error(msg)
@@ -2,11 +2,14 @@ package goalogrus_test
 
 import (
 	"bytes"
+	"io/ioutil"
+	"testing"
 
 	"golang.org/x/net/context"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/logging"
 	"github.com/goadesign/goa/logging/logrus"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -28,6 +31,16 @@ var _ = Describe("goalogrus", func() {
 		adapter.Info(msg)
 		Ω(buf.String()).Should(ContainSubstring(msg))
 	})
+
+	It("adapts debug and warning messages", func() {
+		buf.Reset()
+		logger.Level = logrus.DebugLevel
+		leveled := adapter.(goa.LeveledLogAdapter)
+		leveled.Debug("debug")
+		leveled.Warn("warn")
+		Ω(buf.String()).Should(ContainSubstring("level=debug msg=debug"))
+		Ω(buf.String()).Should(ContainSubstring("level=warning msg=warn"))
+	})
 })
 
 var _ = Describe("FromEntry", func() {
@@ -60,3 +73,46 @@ var _ = Describe("FromEntry", func() {
 		})
 	})
 })
+
+func TestConformance(t *testing.T) {
+	logging.TestAdapter(t, func() (goa.LogAdapter, func() []logging.Record) {
+		logger := logrus.New()
+		logger.Out = ioutil.Discard
+		logger.Level = logrus.DebugLevel
+		hook := new(recordHook)
+		logger.Hooks.Add(hook)
+		records := func() []logging.Record {
+			rs := make([]logging.Record, len(hook.entries))
+			for i, e := range hook.entries {
+				var keyvals []interface{}
+				for k, v := range e.Data {
+					keyvals = append(keyvals, k, v)
+				}
+				rs[i] = logging.Record{Level: levels[e.Level], Msg: e.Message, Fields: logging.Fields(keyvals...)}
+			}
+			return rs
+		}
+		return goalogrus.New(logger), records
+	})
+}
+
+// recordHook is a logrus hook that records the logged entries.
+type recordHook struct {
+	entries []*logrus.Entry
+}
+
+func (h *recordHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *recordHook) Fire(e *logrus.Entry) error {
+	h.entries = append(h.entries, e)
+	return nil
+}
+
+var levels = map[logrus.Level]goa.LogLevel{
+	logrus.DebugLevel: goa.LevelDebug,
+	logrus.InfoLevel:  goa.LevelInfo,
+	logrus.WarnLevel:  goa.LevelWarn,
+	logrus.ErrorLevel: goa.LevelError,
+}
@@ -0,0 +1,79 @@
+package goazap_test
+
+import (
+	"testing"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/logging"
+	"github.com/goadesign/goa/logging/zap"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("New", func() {
+	var logger *zap.Logger
+	var adapter goa.LogAdapter
+	var logs *observer.ObservedLogs
+
+	BeforeEach(func() {
+		var core zapcore.Core
+		core, logs = observer.New(zapcore.DebugLevel)
+		logger = zap.New(core)
+		adapter = goazap.New(logger)
+	})
+
+	It("creates an adapter that logs", func() {
+		msg := "msg"
+		adapter.Info(msg, "foo", "bar")
+		Ω(logs.All()).Should(HaveLen(1))
+		entry := logs.All()[0]
+		Ω(entry.Message).Should(Equal(msg))
+		Ω(entry.Level).Should(Equal(zapcore.InfoLevel))
+		Ω(entry.ContextMap()).Should(Equal(map[string]interface{}{"foo": "bar"}))
+	})
+
+	Context("Logger", func() {
+		var ctx context.Context
+
+		BeforeEach(func() {
+			ctx = goa.WithLogger(context.Background(), adapter)
+		})
+
+		It("extracts the logger", func() {
+			Ω(goazap.Logger(ctx)).Should(Equal(logger))
+		})
+	})
+})
+
+func TestConformance(t *testing.T) {
+	logging.TestAdapter(t, func() (goa.LogAdapter, func() []logging.Record) {
+		core, logs := observer.New(zapcore.DebugLevel)
+		records := func() []logging.Record {
+			var rs []logging.Record
+			for _, e := range logs.All() {
+				var keyvals []interface{}
+				for k, v := range e.ContextMap() {
+					keyvals = append(keyvals, k, v)
+				}
+				rs = append(rs, logging.Record{
+					Level:  levels[e.Level],
+					Msg:    e.Message,
+					Fields: logging.Fields(keyvals...),
+				})
+			}
+			return rs
+		}
+		return goazap.New(zap.New(core)), records
+	})
+}
+
+var levels = map[zapcore.Level]goa.LogLevel{
+	zapcore.DebugLevel: goa.LevelDebug,
+	zapcore.InfoLevel:  goa.LevelInfo,
+	zapcore.WarnLevel:  goa.LevelWarn,
+	zapcore.ErrorLevel: goa.LevelError,
+}
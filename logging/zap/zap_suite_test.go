@@ -0,0 +1,13 @@
+package goazap_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestZap(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Goazap Suite")
+}
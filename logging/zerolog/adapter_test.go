@@ -0,0 +1,83 @@
+package goazerolog_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/logging"
+	"github.com/goadesign/goa/logging/zerolog"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/rs/zerolog"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("New", func() {
+	var buf bytes.Buffer
+	var logger zerolog.Logger
+	var adapter goa.LogAdapter
+
+	BeforeEach(func() {
+		buf.Reset()
+		logger = zerolog.New(&buf)
+		adapter = goazerolog.New(logger)
+	})
+
+	It("creates an adapter that logs", func() {
+		msg := "msg"
+		adapter.Info(msg, "foo", "bar")
+		Ω(buf.String()).Should(MatchJSON(`{"level":"info","foo":"bar","message":"msg"}`))
+	})
+
+	Context("Logger", func() {
+		var ctx context.Context
+
+		BeforeEach(func() {
+			ctx = goa.WithLogger(context.Background(), adapter)
+		})
+
+		It("extracts the logger", func() {
+			Ω(*goazerolog.Logger(ctx)).Should(Equal(logger))
+		})
+	})
+})
+
+func TestConformance(t *testing.T) {
+	logging.TestAdapter(t, func() (goa.LogAdapter, func() []logging.Record) {
+		var buf bytes.Buffer
+		records := func() []logging.Record {
+			var rs []logging.Record
+			scanner := bufio.NewScanner(bytes.NewReader(buf.Bytes()))
+			for scanner.Scan() {
+				var entry map[string]interface{}
+				if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+					t.Fatalf("invalid log line %s: %s", scanner.Text(), err)
+				}
+				r := logging.Record{
+					Level: levels[entry[zerolog.LevelFieldName].(string)],
+					Msg:   entry[zerolog.MessageFieldName].(string),
+				}
+				delete(entry, zerolog.LevelFieldName)
+				delete(entry, zerolog.MessageFieldName)
+				var keyvals []interface{}
+				for k, v := range entry {
+					keyvals = append(keyvals, k, v)
+				}
+				r.Fields = logging.Fields(keyvals...)
+				rs = append(rs, r)
+			}
+			return rs
+		}
+		return goazerolog.New(zerolog.New(&buf).Level(zerolog.DebugLevel)), records
+	})
+}
+
+var levels = map[string]goa.LogLevel{
+	"debug": goa.LevelDebug,
+	"info":  goa.LevelInfo,
+	"warn":  goa.LevelWarn,
+	"error": goa.LevelError,
+}
@@ -3,8 +3,11 @@ package goa_test
 import (
 	"bytes"
 	"log"
+	"strings"
+	"testing"
 
 	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/logging"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"golang.org/x/net/context"
@@ -48,5 +51,110 @@ var _ = Describe("LogAdapter", func() {
 			logger.Error(msg, data...)
 			Ω(out.String()).Should(ContainSubstring(msg + " data=foo"))
 		})
+
+		It("Debug and Warn log with their level", func() {
+			leveled := logger.(goa.LeveledLogAdapter)
+			leveled.Debug(msg, data...)
+			leveled.Warn(msg, data...)
+			Ω(out.String()).Should(ContainSubstring("[DBUG] " + msg + " data=foo"))
+			Ω(out.String()).Should(ContainSubstring("[WARN] " + msg + " data=foo"))
+		})
+	})
+})
+
+var _ = Describe("LogLevel", func() {
+	var ctx context.Context
+	var out bytes.Buffer
+
+	BeforeEach(func() {
+		out.Reset()
+		ctx = goa.WithLogger(context.Background(), goa.NewLogger(log.New(&out, "", 0)))
+	})
+
+	It("defaults to info", func() {
+		Ω(goa.ContextLogLevel(ctx)).Should(Equal(goa.LevelInfo))
+		goa.LogDebug(ctx, "debug")
+		goa.LogInfo(ctx, "info")
+		Ω(out.String()).Should(Equal("[INFO] info\n"))
+	})
+
+	It("discards messages below the context level", func() {
+		ctx = goa.WithLogLevel(ctx, goa.LevelWarn)
+		goa.LogInfo(ctx, "info")
+		goa.LogWarn(ctx, "warn")
+		goa.LogError(ctx, "error")
+		Ω(out.String()).Should(Equal("[WARN] warn\n[EROR] error\n"))
+	})
+
+	It("logs debug messages when enabled", func() {
+		ctx = goa.WithLogLevel(ctx, goa.LevelDebug)
+		goa.LogDebug(ctx, "debug")
+		Ω(out.String()).Should(Equal("[DBUG] debug\n"))
+	})
+
+	Context("with a logger that only supports info and error", func() {
+		BeforeEach(func() {
+			ctx = goa.WithLogger(context.Background(), infoErrorLogger{goa.NewLogger(log.New(&out, "", 0))})
+			ctx = goa.WithLogLevel(ctx, goa.LevelDebug)
+		})
+
+		It("logs debug messages with Info and warnings with Error", func() {
+			goa.LogDebug(ctx, "debug")
+			goa.LogWarn(ctx, "warn")
+			Ω(out.String()).Should(Equal("[INFO] debug\n[EROR] warn\n"))
+		})
+	})
+
+	It("parses level names", func() {
+		l, ok := goa.ParseLogLevel("DEBUG")
+		Ω(ok).Should(BeTrue())
+		Ω(l).Should(Equal(goa.LevelDebug))
+		Ω(l.String()).Should(Equal("debug"))
+		_, ok = goa.ParseLogLevel("verbose")
+		Ω(ok).Should(BeFalse())
 	})
 })
+
+// infoErrorLogger is a log adapter that does not implement goa.LeveledLogAdapter.
+type infoErrorLogger struct {
+	logger goa.LogAdapter
+}
+
+func (l infoErrorLogger) Info(msg string, keyvals ...interface{})  { l.logger.Info(msg, keyvals...) }
+func (l infoErrorLogger) Error(msg string, keyvals ...interface{}) { l.logger.Error(msg, keyvals...) }
+func (l infoErrorLogger) New(keyvals ...interface{}) goa.LogAdapter {
+	return infoErrorLogger{l.logger.New(keyvals...)}
+}
+
+func TestLogAdapterConformance(t *testing.T) {
+	logging.TestAdapter(t, func() (goa.LogAdapter, func() []logging.Record) {
+		var out bytes.Buffer
+		records := func() []logging.Record {
+			var rs []logging.Record
+			for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+				if line == "" {
+					continue
+				}
+				// Lines are formatted as "[LEVL] msg key=val key=val"
+				elems := strings.Split(line, " ")
+				r := logging.Record{Level: stdLevels[elems[0]], Msg: elems[1]}
+				var keyvals []interface{}
+				for _, kv := range elems[2:] {
+					parts := strings.SplitN(kv, "=", 2)
+					keyvals = append(keyvals, parts[0], parts[1])
+				}
+				r.Fields = logging.Fields(keyvals...)
+				rs = append(rs, r)
+			}
+			return rs
+		}
+		return goa.NewLogger(log.New(&out, "", 0)), records
+	})
+}
+
+var stdLevels = map[string]goa.LogLevel{
+	"[DBUG]": goa.LevelDebug,
+	"[INFO]": goa.LevelInfo,
+	"[WARN]": goa.LevelWarn,
+	"[EROR]": goa.LevelError,
+}
@@ -66,3 +66,23 @@ func SetGauge(key []string, val float32) {
 		metriks.SetGauge(key, val)
 	}
 }
+
+// incrCounter increments the counter named by key using m or the package-level metrics if m is
+// nil.
+func incrCounter(m Metrics, key []string, val float32) {
+	if m != nil {
+		m.IncrCounter(key, val)
+		return
+	}
+	IncrCounter(key, val)
+}
+
+// measureSince records the duration elapsed since start using m or the package-level metrics if
+// m is nil.
+func measureSince(m Metrics, key []string, start time.Time) {
+	if m != nil {
+		m.MeasureSince(key, start)
+		return
+	}
+	MeasureSince(key, start)
+}
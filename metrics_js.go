@@ -15,3 +15,17 @@ func IncrCounter(key []string, val float32) {
 func MeasureSince(key []string, start time.Time) {
 	// Do nothing
 }
+
+// incrCounter increments the counter named by key using m if not nil.
+func incrCounter(m Metrics, key []string, val float32) {
+	if m != nil {
+		m.IncrCounter(key, val)
+	}
+}
+
+// measureSince records the duration elapsed since start using m if not nil.
+func measureSince(m Metrics, key []string, start time.Time) {
+	if m != nil {
+		m.MeasureSince(key, start)
+	}
+}
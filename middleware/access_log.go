@@ -0,0 +1,187 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goadesign/goa"
+	"golang.org/x/net/context"
+)
+
+// AccessLogFormat is the format of the lines written by the AccessLog middleware.
+type AccessLogFormat int
+
+const (
+	// AccessLogCommon is the Apache common log format followed by the request ID and the latency
+	// in microseconds.
+	AccessLogCommon AccessLogFormat = iota
+	// AccessLogCombined is the Apache combined log format followed by the request ID and the
+	// latency in microseconds.
+	AccessLogCombined
+	// AccessLogJSON writes a JSON object per request.
+	AccessLogJSON
+	// AccessLogLogfmt writes the request fields using the logfmt key=value format.
+	AccessLogLogfmt
+)
+
+// accessLogEntry describes a handled request. The JSON encoding of the entry is the line written
+// by the AccessLog middleware with the AccessLogJSON format.
+type accessLogEntry struct {
+	Time       string  `json:"time"`
+	RemoteAddr string  `json:"remote_addr"`
+	User       string  `json:"user"`
+	Method     string  `json:"method"`
+	URI        string  `json:"uri"`
+	Proto      string  `json:"proto"`
+	Status     int     `json:"status"`
+	Bytes      int     `json:"bytes"`
+	Latency    float64 `json:"latency_ms"`
+	RequestID  string  `json:"req_id"`
+	Referer    string  `json:"referer"`
+	UserAgent  string  `json:"user_agent"`
+	Controller string  `json:"ctrl"`
+	Action     string  `json:"action"`
+	ErrorCode  string  `json:"error_code,omitempty"`
+
+	started time.Time
+}
+
+// AccessLog returns a middleware that writes one line to w per request once the request has been
+// handled. The line contains the request ID set by the RequestID middleware, the user identity
+// set by the security middlewares (see goa.ContextUser) or "-" if none did, the response status
+// and length and the request latency. The format of the line is one of AccessLogCommon,
+// AccessLogCombined, AccessLogJSON or AccessLogLogfmt. The middleware should be mounted above the
+// ErrorHandler middleware so that the status of error responses is logged.
+func AccessLog(w io.Writer, format AccessLogFormat) goa.Middleware {
+	var mu sync.Mutex
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			started := time.Now()
+			err := h(ctx, rw, req)
+			entry := newAccessLogEntry(ctx, req, started, err)
+
+			var buf bytes.Buffer
+			switch format {
+			case AccessLogJSON:
+				js, _ := json.Marshal(entry)
+				buf.Write(js)
+			case AccessLogLogfmt:
+				entry.writeLogfmt(&buf)
+			default:
+				entry.writeApache(&buf, format == AccessLogCombined)
+			}
+			buf.WriteByte('\n')
+
+			mu.Lock()
+			defer mu.Unlock()
+			if _, werr := w.Write(buf.Bytes()); werr != nil {
+				goa.LogError(ctx, "failed to write access log", "err", werr)
+			}
+			return err
+		}
+	}
+}
+
+// newAccessLogEntry builds the access log entry of a request handled by a goa handler that
+// returned err.
+func newAccessLogEntry(ctx context.Context, req *http.Request, started time.Time, err error) *accessLogEntry {
+	e := &accessLogEntry{
+		Time:       started.Format(time.RFC3339),
+		RemoteAddr: from(req),
+		User:       goa.ContextUser(ctx),
+		Method:     req.Method,
+		URI:        req.URL.RequestURI(),
+		Proto:      req.Proto,
+		Latency:    float64(time.Since(started).Nanoseconds()) / float64(time.Millisecond),
+		RequestID:  ContextRequestID(ctx),
+		Referer:    req.Referer(),
+		UserAgent:  req.UserAgent(),
+		Controller: goa.ContextController(ctx),
+		Action:     goa.ContextAction(ctx),
+		started:    started,
+	}
+	if resp := goa.ContextResponse(ctx); resp != nil {
+		e.Status, e.Bytes, e.ErrorCode = resp.Status, resp.Length, resp.ErrorCode
+	}
+	if err != nil && e.Status == 0 {
+		e.Status = http.StatusInternalServerError
+		if gerr, ok := err.(*goa.Error); ok {
+			e.Status, e.ErrorCode = gerr.Status, gerr.Code
+		}
+	}
+	return e
+}
+
+// writeApache writes the entry using the Apache common or combined log format.
+func (e *accessLogEntry) writeApache(buf *bytes.Buffer, combined bool) {
+	fmt.Fprintf(buf, "%s - %s [%s] \"%s %s %s\" %d %s",
+		dash(e.RemoteAddr), dash(e.User), e.started.Format("02/Jan/2006:15:04:05 -0700"),
+		e.Method, e.URI, e.Proto, e.Status, dash(bytesField(e.Bytes)))
+	if combined {
+		fmt.Fprintf(buf, " %s %s", strconv.Quote(dash(e.Referer)), strconv.Quote(dash(e.UserAgent)))
+	}
+	fmt.Fprintf(buf, " %s %d", strconv.Quote(dash(e.RequestID)), int64(e.Latency*1000))
+}
+
+// writeLogfmt writes the entry using the logfmt format.
+func (e *accessLogEntry) writeLogfmt(buf *bytes.Buffer) {
+	fields := []interface{}{
+		"time", e.Time,
+		"remote_addr", e.RemoteAddr,
+		"user", e.User,
+		"method", e.Method,
+		"uri", e.URI,
+		"proto", e.Proto,
+		"status", e.Status,
+		"bytes", e.Bytes,
+		"latency_ms", strconv.FormatFloat(e.Latency, 'f', 3, 64),
+		"req_id", e.RequestID,
+		"referer", e.Referer,
+		"user_agent", e.UserAgent,
+		"ctrl", e.Controller,
+		"action", e.Action,
+	}
+	if e.ErrorCode != "" {
+		fields = append(fields, "error_code", e.ErrorCode)
+	}
+	for i := 0; i < len(fields); i += 2 {
+		if i > 0 {
+			buf.WriteByte(' ')
+		}
+		fmt.Fprintf(buf, "%s=%s", fields[i], logfmtValue(fmt.Sprint(fields[i+1])))
+	}
+}
+
+// logfmtValue quotes v if it is empty or contains spaces, quotes, equal signs or control
+// characters.
+func logfmtValue(v string) string {
+	if v == "" || strings.IndexFunc(v, func(r rune) bool {
+		return r <= ' ' || r == '"' || r == '=' || r == '\\' || r == 0x7f
+	}) != -1 {
+		return strconv.Quote(v)
+	}
+	return v
+}
+
+// bytesField returns the Apache log representation of the response length.
+func bytesField(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
+
+// dash returns "-" if v is empty, v otherwise.
+func dash(v string) string {
+	if v == "" {
+		return "-"
+	}
+	return v
+}
@@ -0,0 +1,134 @@
+package middleware_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/context"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/middleware"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AccessLog", func() {
+	var ctx context.Context
+	var rw *testResponseWriter
+	var req *http.Request
+	var service *goa.Service
+	var buf *bytes.Buffer
+	var format middleware.AccessLogFormat
+	var handler goa.Handler
+	var line string
+
+	BeforeEach(func() {
+		service = newService(nil)
+		var err error
+		req, err = http.NewRequest("GET", "/goo?param=value", nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		req.RemoteAddr = "10.0.0.1:4242"
+		req.Header.Set("User-Agent", "test agent")
+		req.Header.Set("Referer", "http://example.com")
+		req.Header.Set("X-Request-Id", "reqid")
+		rw = newTestResponseWriter()
+		ctx = newContext(service, rw, req, nil)
+		buf = new(bytes.Buffer)
+		handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			ctx = goa.WithUser(ctx, "alice")
+			return service.Send(ctx, 200, "ok")
+		}
+	})
+
+	JustBeforeEach(func() {
+		h := middleware.RequestID()(middleware.AccessLog(buf, format)(handler))
+		h(ctx, rw, req)
+		line = buf.String()
+	})
+
+	Context("with the combined format", func() {
+		BeforeEach(func() {
+			format = middleware.AccessLogCombined
+		})
+
+		It("writes one line", func() {
+			re := `^10\.0\.0\.1 - alice \[[^\]]+\] "GET /goo\?param=value HTTP/1\.1" 200 5 "http://example.com" "test agent" "reqid" \d+\n$`
+			Ω(line).Should(MatchRegexp(re))
+		})
+
+		Context("and unverified basic auth credentials", func() {
+			BeforeEach(func() {
+				req.SetBasicAuth("mallory", "forged")
+				handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+					return service.Send(ctx, 200, "ok")
+				}
+			})
+
+			It("does not log the basic auth user", func() {
+				Ω(line).Should(HavePrefix(`10.0.0.1 - - [`))
+				Ω(line).ShouldNot(ContainSubstring("mallory"))
+			})
+		})
+	})
+
+	Context("with the common format", func() {
+		BeforeEach(func() {
+			format = middleware.AccessLogCommon
+		})
+
+		It("omits the referer and user agent", func() {
+			re := `^10\.0\.0\.1 - alice \[[^\]]+\] "GET /goo\?param=value HTTP/1\.1" 200 5 "reqid" \d+\n$`
+			Ω(line).Should(MatchRegexp(re))
+		})
+	})
+
+	Context("with the JSON format", func() {
+		BeforeEach(func() {
+			format = middleware.AccessLogJSON
+		})
+
+		It("writes a JSON object", func() {
+			var entry map[string]interface{}
+			Ω(json.Unmarshal([]byte(line), &entry)).ShouldNot(HaveOccurred())
+			Ω(entry).Should(HaveKeyWithValue("user", "alice"))
+			Ω(entry).Should(HaveKeyWithValue("method", "GET"))
+			Ω(entry).Should(HaveKeyWithValue("uri", "/goo?param=value"))
+			Ω(entry).Should(HaveKeyWithValue("status", 200.0))
+			Ω(entry).Should(HaveKeyWithValue("bytes", 5.0))
+			Ω(entry).Should(HaveKeyWithValue("req_id", "reqid"))
+			Ω(entry).Should(HaveKeyWithValue("ctrl", "test"))
+			Ω(entry).Should(HaveKey("latency_ms"))
+		})
+
+		Context("and a handler that returns an error", func() {
+			BeforeEach(func() {
+				handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+					return goa.ErrBadRequest("boom")
+				}
+			})
+
+			It("logs the error status and code", func() {
+				var entry map[string]interface{}
+				Ω(json.Unmarshal([]byte(line), &entry)).ShouldNot(HaveOccurred())
+				Ω(entry).Should(HaveKeyWithValue("status", 400.0))
+				Ω(entry).Should(HaveKeyWithValue("error_code", "bad_request"))
+				Ω(entry).Should(HaveKeyWithValue("user", ""))
+			})
+		})
+	})
+
+	Context("with the logfmt format", func() {
+		BeforeEach(func() {
+			format = middleware.AccessLogLogfmt
+		})
+
+		It("writes key value pairs", func() {
+			Ω(line).Should(ContainSubstring(` user=alice method=GET uri="/goo?param=value" proto=HTTP/1.1 status=200 bytes=5 `))
+			Ω(line).Should(ContainSubstring(` user_agent="test agent" `))
+			Ω(line).Should(MatchRegexp(`req_id=reqid`))
+			Ω(strings.Count(line, "\n")).Should(Equal(1))
+		})
+	})
+})
@@ -0,0 +1,197 @@
+/*
+Package caching provides middlewares that implement HTTP conditional requests. The New middleware
+buffers the responses to GET and HEAD requests, sets their ETag header and replies with 304 Not
+Modified when the If-None-Match or If-Modified-Since request headers show that the client
+representation is up to date:
+
+	service.Use(caching.New(false))
+
+The Preconditions middleware evaluates the If-Match and If-Unmodified-Since headers of PUT and
+PATCH requests against the current representation of the resource and fails the requests with
+ErrPreconditionFailed if it was modified since the client retrieved it:
+
+	ctrl.UseAction("update", caching.Preconditions(currentBottleValidators))
+
+The Cache-Control header of the responses is set by the generated code from the policies declared
+in the design with the CacheControl DSL.
+*/
+package caching
+
+import (
+	"bytes"
+	"net/http"
+	"time"
+
+	"github.com/goadesign/goa"
+	"golang.org/x/net/context"
+)
+
+// Names of the headers read and written by the middlewares.
+const (
+	headerETag              = "ETag"
+	headerLastModified      = "Last-Modified"
+	headerIfMatch           = "If-Match"
+	headerIfNoneMatch       = "If-None-Match"
+	headerIfModifiedSince   = "If-Modified-Since"
+	headerIfUnmodifiedSince = "If-Unmodified-Since"
+	headerContentType       = "Content-Type"
+	headerContentLength     = "Content-Length"
+)
+
+// ErrPreconditionFailed is the error returned by the Preconditions middleware for requests whose
+// If-Match or If-Unmodified-Since precondition does not hold.
+var ErrPreconditionFailed = goa.NewErrorClass("precondition_failed", 412)
+
+// ValidatorsFunc returns the entity tag and the last modification time of the current
+// representation of the resource targeted by a request. The entity tag is empty if the resource
+// does not exist and the modification time is zero if it is unknown.
+type ValidatorsFunc func(ctx context.Context, req *http.Request) (etag string, lastModified time.Time, err error)
+
+// bufferedWriter records the status and body written by a handler. The headers are written
+// directly to the underlying response writer. Flushing the writer stops the buffering so that
+// streamed responses are not held back.
+type bufferedWriter struct {
+	http.ResponseWriter
+	status      int
+	body        bytes.Buffer
+	passthrough bool
+}
+
+// New returns a middleware that buffers the successful responses to GET and HEAD requests and
+// sets their ETag header to the hash of the response body unless the handler already set it.
+// The tags are weak if weak is true, strong otherwise. The middleware replies with 304 Not
+// Modified if one of the If-None-Match request header tags matches the response tag or, in the
+// absence of If-None-Match, if the response Last-Modified header is not later than the
+// If-Modified-Since request header.
+func New(weak bool) goa.Middleware {
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			if req.Method != "GET" && req.Method != "HEAD" {
+				return h(ctx, rw, req)
+			}
+			resp := goa.ContextResponse(ctx)
+			bw := &bufferedWriter{ResponseWriter: resp.ResponseWriter}
+			orig := resp.SwitchWriter(bw)
+			err := h(ctx, rw, req)
+			resp.SwitchWriter(orig)
+			if bw.passthrough || (bw.status == 0 && bw.body.Len() == 0) {
+				return err
+			}
+
+			status := bw.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			header := orig.Header()
+			if status == http.StatusOK {
+				if header.Get(headerETag) == "" {
+					header.Set(headerETag, ETag(bw.body.Bytes(), weak))
+				}
+				if notModified(req, header) {
+					header.Del(headerContentType)
+					header.Del(headerContentLength)
+					resp.Status, resp.Length = http.StatusNotModified, 0
+					orig.WriteHeader(http.StatusNotModified)
+					return err
+				}
+			}
+			orig.WriteHeader(status)
+			if _, werr := orig.Write(bw.body.Bytes()); werr != nil {
+				goa.LogError(ctx, "failed to write response", "err", werr)
+			}
+			return err
+		}
+	}
+}
+
+// Preconditions returns a middleware that evaluates the If-Match and If-Unmodified-Since headers
+// of PUT and PATCH requests against the validators of the current representation of the target
+// resource returned by current. The If-Match header tags are compared with the current entity tag
+// using the strong comparison function, the "*" tag matches any existing representation.
+// If-Unmodified-Since is only evaluated in the absence of If-Match and if the modification time
+// is known. The middleware fails the requests whose precondition does not hold with
+// ErrPreconditionFailed and the requests for which current fails with the error it returns.
+func Preconditions(current ValidatorsFunc) goa.Middleware {
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			if req.Method != "PUT" && req.Method != "PATCH" {
+				return h(ctx, rw, req)
+			}
+			ifMatch, ifUnmodified := req.Header.Get(headerIfMatch), req.Header.Get(headerIfUnmodifiedSince)
+			if ifMatch == "" && ifUnmodified == "" {
+				return h(ctx, rw, req)
+			}
+			etag, lastModified, err := current(ctx, req)
+			if err != nil {
+				return err
+			}
+			if ifMatch != "" {
+				if !MatchETag(ifMatch, etag, false) {
+					return ErrPreconditionFailed("If-Match precondition failed").Meta("etag", etag)
+				}
+			} else if t, terr := http.ParseTime(ifUnmodified); terr == nil && !lastModified.IsZero() {
+				if lastModified.Truncate(time.Second).After(t) {
+					return ErrPreconditionFailed("If-Unmodified-Since precondition failed").
+						Meta("last_modified", lastModified.UTC().Format(http.TimeFormat))
+				}
+			}
+			return h(ctx, rw, req)
+		}
+	}
+}
+
+// notModified returns true if the conditional headers of req show that the client already has
+// the representation described by the response header.
+func notModified(req *http.Request, header http.Header) bool {
+	if inm := req.Header.Get(headerIfNoneMatch); inm != "" {
+		return MatchETag(inm, header.Get(headerETag), true)
+	}
+	ims, lm := req.Header.Get(headerIfModifiedSince), header.Get(headerLastModified)
+	if ims == "" || lm == "" {
+		return false
+	}
+	since, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+	modified, err := http.ParseTime(lm)
+	if err != nil {
+		return false
+	}
+	return !modified.After(since)
+}
+
+// WriteHeader records the response status.
+func (bw *bufferedWriter) WriteHeader(status int) {
+	if bw.passthrough {
+		bw.ResponseWriter.WriteHeader(status)
+		return
+	}
+	bw.status = status
+}
+
+// Write buffers b.
+func (bw *bufferedWriter) Write(b []byte) (int, error) {
+	if bw.passthrough {
+		return bw.ResponseWriter.Write(b)
+	}
+	return bw.body.Write(b)
+}
+
+// Flush writes the recorded status and buffered body to the underlying writer, flushes it and
+// disables buffering for the rest of the response.
+func (bw *bufferedWriter) Flush() {
+	if !bw.passthrough {
+		bw.passthrough = true
+		if bw.status != 0 {
+			bw.ResponseWriter.WriteHeader(bw.status)
+		}
+		if bw.body.Len() > 0 {
+			bw.ResponseWriter.Write(bw.body.Bytes())
+			bw.body.Reset()
+		}
+	}
+	if f, ok := bw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,235 @@
+package caching_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/middleware"
+	"github.com/goadesign/goa/middleware/caching"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("New", func() {
+	var service *goa.Service
+	var handler goa.Handler
+	var weak bool
+	var lastModified time.Time
+
+	BeforeEach(func() {
+		service = goa.New("test")
+		service.Encoder.Register(goa.NewJSONEncoder, "*/*")
+		weak = false
+		lastModified = time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC)
+		handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			rw.Header().Set("Last-Modified", lastModified.Format(http.TimeFormat))
+			return service.Send(ctx, 200, "ok")
+		}
+	})
+
+	serve := func(method string, header http.Header) (*httptest.ResponseRecorder, *goa.ResponseData) {
+		req, _ := http.NewRequest(method, "/", nil)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		rw := httptest.NewRecorder()
+		ctx := goa.NewContext(service.Context, rw, req, nil)
+		h := middleware.ErrorHandler(service, false)(caching.New(weak)(handler))
+		Ω(h(ctx, rw, req)).ShouldNot(HaveOccurred())
+		return rw, goa.ContextResponse(ctx)
+	}
+
+	It("sets the ETag header of successful responses", func() {
+		rw, _ := serve("GET", nil)
+		Ω(rw.Code).Should(Equal(200))
+		Ω(rw.Body.String()).Should(Equal("\"ok\"\n"))
+		Ω(rw.Header().Get("ETag")).Should(Equal(caching.ETag([]byte("\"ok\"\n"), false)))
+	})
+
+	Context("with weak tags", func() {
+		BeforeEach(func() {
+			weak = true
+		})
+
+		It("sets a weak ETag", func() {
+			rw, _ := serve("GET", nil)
+			Ω(rw.Header().Get("ETag")).Should(HavePrefix(`W/"`))
+		})
+	})
+
+	It("replies with 304 when If-None-Match matches the response tag", func() {
+		rw, _ := serve("GET", nil)
+		etag := rw.Header().Get("ETag")
+		rw, resp := serve("GET", http.Header{"If-None-Match": {`"other", W/` + etag}})
+		Ω(rw.Code).Should(Equal(304))
+		Ω(rw.Body.Len()).Should(Equal(0))
+		Ω(rw.Header().Get("ETag")).Should(Equal(etag))
+		Ω(rw.Header().Get("Content-Type")).Should(BeEmpty())
+		Ω(resp.Status).Should(Equal(304))
+		Ω(resp.Length).Should(Equal(0))
+	})
+
+	It("sends the response when If-None-Match does not match", func() {
+		rw, _ := serve("GET", http.Header{"If-None-Match": {`"other"`}})
+		Ω(rw.Code).Should(Equal(200))
+		Ω(rw.Body.String()).Should(Equal("\"ok\"\n"))
+	})
+
+	It("replies with 304 when the response was not modified since If-Modified-Since", func() {
+		rw, _ := serve("GET", http.Header{"If-Modified-Since": {lastModified.Format(http.TimeFormat)}})
+		Ω(rw.Code).Should(Equal(304))
+	})
+
+	It("sends the response when it was modified since If-Modified-Since", func() {
+		since := lastModified.Add(-time.Hour).Format(http.TimeFormat)
+		rw, _ := serve("GET", http.Header{"If-Modified-Since": {since}})
+		Ω(rw.Code).Should(Equal(200))
+	})
+
+	It("ignores If-Modified-Since when If-None-Match is set", func() {
+		rw, _ := serve("GET", http.Header{
+			"If-None-Match":     {`"other"`},
+			"If-Modified-Since": {lastModified.Format(http.TimeFormat)},
+		})
+		Ω(rw.Code).Should(Equal(200))
+	})
+
+	Context("with a handler that sets the ETag header", func() {
+		BeforeEach(func() {
+			handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+				rw.Header().Set("ETag", `"v1"`)
+				return service.Send(ctx, 200, "ok")
+			}
+		})
+
+		It("uses the handler tag", func() {
+			rw, _ := serve("GET", http.Header{"If-None-Match": {`"v1"`}})
+			Ω(rw.Code).Should(Equal(304))
+			Ω(rw.Header().Get("ETag")).Should(Equal(`"v1"`))
+		})
+	})
+
+	Context("with a handler that fails", func() {
+		BeforeEach(func() {
+			handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+				return goa.ErrNotFound("not found")
+			}
+		})
+
+		It("does not tag the error response", func() {
+			rw, _ := serve("GET", http.Header{"If-None-Match": {"*"}})
+			Ω(rw.Code).Should(Equal(404))
+			Ω(rw.Header().Get("ETag")).Should(BeEmpty())
+		})
+	})
+
+	Context("with a handler that flushes the response", func() {
+		BeforeEach(func() {
+			handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+				resp := goa.ContextResponse(ctx)
+				resp.WriteHeader(200)
+				resp.Write([]byte("first"))
+				resp.Flush()
+				resp.Write([]byte("second"))
+				return nil
+			}
+		})
+
+		It("streams the response", func() {
+			rw, _ := serve("GET", http.Header{"If-None-Match": {"*"}})
+			Ω(rw.Code).Should(Equal(200))
+			Ω(rw.Flushed).Should(BeTrue())
+			Ω(rw.Body.String()).Should(Equal("firstsecond"))
+			Ω(rw.Header().Get("ETag")).Should(BeEmpty())
+		})
+	})
+
+	It("does not buffer the responses to unsafe requests", func() {
+		rw, _ := serve("POST", http.Header{"If-None-Match": {"*"}})
+		Ω(rw.Code).Should(Equal(200))
+		Ω(rw.Header().Get("ETag")).Should(BeEmpty())
+	})
+})
+
+var _ = Describe("Preconditions", func() {
+	var service *goa.Service
+	var etag string
+	var lastModified time.Time
+	var currentErr error
+	var called bool
+
+	BeforeEach(func() {
+		service = goa.New("test")
+		service.Encoder.Register(goa.NewJSONEncoder, "*/*")
+		etag = `"v1"`
+		lastModified = time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC)
+		currentErr = nil
+		called = false
+	})
+
+	serve := func(method string, header http.Header) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, "/", nil)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		rw := httptest.NewRecorder()
+		ctx := goa.NewContext(service.Context, rw, req, nil)
+		current := func(ctx context.Context, req *http.Request) (string, time.Time, error) {
+			return etag, lastModified, currentErr
+		}
+		handler := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			called = true
+			return service.Send(ctx, 200, "updated")
+		}
+		h := middleware.ErrorHandler(service, false)(caching.Preconditions(current)(handler))
+		Ω(h(ctx, rw, req)).ShouldNot(HaveOccurred())
+		return rw
+	}
+
+	It("handles the requests whose If-Match header matches the current tag", func() {
+		rw := serve("PUT", http.Header{"If-Match": {`"v0", "v1"`}})
+		Ω(rw.Code).Should(Equal(200))
+		Ω(called).Should(BeTrue())
+	})
+
+	It("rejects the requests whose If-Match header does not match the current tag", func() {
+		rw := serve("PATCH", http.Header{"If-Match": {`"v0"`}})
+		Ω(rw.Code).Should(Equal(412))
+		Ω(rw.Body.String()).Should(ContainSubstring("precondition_failed"))
+		Ω(called).Should(BeFalse())
+	})
+
+	It("rejects If-Match * when the resource does not exist", func() {
+		etag = ""
+		rw := serve("PUT", http.Header{"If-Match": {"*"}})
+		Ω(rw.Code).Should(Equal(412))
+	})
+
+	It("rejects the requests for resources modified since If-Unmodified-Since", func() {
+		since := lastModified.Add(-time.Hour).Format(http.TimeFormat)
+		rw := serve("PUT", http.Header{"If-Unmodified-Since": {since}})
+		Ω(rw.Code).Should(Equal(412))
+		Ω(called).Should(BeFalse())
+	})
+
+	It("handles the requests for resources not modified since If-Unmodified-Since", func() {
+		rw := serve("PUT", http.Header{"If-Unmodified-Since": {lastModified.Format(http.TimeFormat)}})
+		Ω(rw.Code).Should(Equal(200))
+	})
+
+	It("fails the requests when the current validators cannot be computed", func() {
+		currentErr = goa.ErrNotFound(errors.New("no bottle"))
+		rw := serve("PUT", http.Header{"If-Match": {`"v1"`}})
+		Ω(rw.Code).Should(Equal(404))
+		Ω(called).Should(BeFalse())
+	})
+
+	It("ignores safe requests", func() {
+		serve("GET", http.Header{"If-Match": {`"v0"`}})
+		Ω(called).Should(BeTrue())
+	})
+})
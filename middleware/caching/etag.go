@@ -0,0 +1,43 @@
+package caching
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"strings"
+)
+
+// ETag returns the entity tag of a representation computed from the hash of its content. The tag
+// is quoted and prefixed with W/ if weak is true.
+func ETag(content []byte, weak bool) string {
+	tag := fmt.Sprintf(`"%x"`, sha1.Sum(content))
+	if weak {
+		return "W/" + tag
+	}
+	return tag
+}
+
+// MatchETag returns true if one of the comma separated entity tags of the If-Match or
+// If-None-Match header value matches etag. The weak comparison function considers tags
+// equal if their opaque values are equal, the strong comparison function also requires both tags
+// to be strong. The "*" tag matches any non empty etag.
+func MatchETag(header, etag string, weakComparison bool) bool {
+	if etag == "" {
+		return false
+	}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return true
+		}
+		if weakComparison {
+			if strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+			continue
+		}
+		if tag == etag && !strings.HasPrefix(tag, "W/") {
+			return true
+		}
+	}
+	return false
+}
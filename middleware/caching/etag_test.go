@@ -0,0 +1,42 @@
+package caching_test
+
+import (
+	"github.com/goadesign/goa/middleware/caching"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ETag", func() {
+	It("computes quoted tags from the content", func() {
+		tag := caching.ETag([]byte("content"), false)
+		Ω(tag).Should(MatchRegexp(`^"[0-9a-f]{40}"$`))
+		Ω(caching.ETag([]byte("content"), false)).Should(Equal(tag))
+		Ω(caching.ETag([]byte("other"), false)).ShouldNot(Equal(tag))
+	})
+
+	It("prefixes weak tags", func() {
+		Ω(caching.ETag([]byte("content"), true)).Should(Equal("W/" + caching.ETag([]byte("content"), false)))
+	})
+})
+
+var _ = Describe("MatchETag", func() {
+	It("matches any of the listed tags", func() {
+		Ω(caching.MatchETag(`"a", "b"`, `"b"`, false)).Should(BeTrue())
+		Ω(caching.MatchETag(`"a", "b"`, `"c"`, false)).Should(BeFalse())
+	})
+
+	It("matches any existing representation with *", func() {
+		Ω(caching.MatchETag("*", `"a"`, false)).Should(BeTrue())
+		Ω(caching.MatchETag("*", "", false)).Should(BeFalse())
+	})
+
+	It("ignores weakness with the weak comparison function", func() {
+		Ω(caching.MatchETag(`W/"a"`, `"a"`, true)).Should(BeTrue())
+		Ω(caching.MatchETag(`"a"`, `W/"a"`, true)).Should(BeTrue())
+	})
+
+	It("never matches weak tags with the strong comparison function", func() {
+		Ω(caching.MatchETag(`W/"a"`, `W/"a"`, false)).Should(BeFalse())
+		Ω(caching.MatchETag(`"a"`, `W/"a"`, false)).Should(BeFalse())
+	})
+})
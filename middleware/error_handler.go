@@ -10,8 +10,12 @@ import (
 // ErrorHandler turns a Go error into an HTTP response. It should be placed in the middleware chain
 // below the logger middleware so the logger properly logs the HTTP response. ErrorHandler
 // understands instances of goa.Error and returns the status and response body embodied in them,
-// it turns other Go error types into a 500 internal error response.
+// it turns other Go error types into a 500 internal error response. goa.Error instances are
+// rendered using the service ErrorFormatter and localized using the service Translator, see
+// goa.Service.Send.
 // If verbose is false the details of internal errors is not included in HTTP responses.
+// Internal errors are logged together with their chain of causes and their stack if recorded,
+// causes and stacks are never included in HTTP responses.
 func ErrorHandler(service *goa.Service, verbose bool) goa.Middleware {
 	return func(h goa.Handler) goa.Handler {
 		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
@@ -26,7 +30,6 @@ func ErrorHandler(service *goa.Service, verbose bool) goa.Middleware {
 				status = err.Status
 				respBody = err
 				goa.ContextResponse(ctx).ErrorCode = err.Code
-				rw.Header().Set("Content-Type", goa.ErrorMediaIdentifier)
 			} else {
 				respBody = e.Error()
 				rw.Header().Set("Content-Type", "text/plain")
@@ -37,9 +40,22 @@ func ErrorHandler(service *goa.Service, verbose bool) goa.Middleware {
 					reqID = shortID()
 					ctx = context.WithValue(ctx, reqIDKey, reqID)
 				}
-				goa.LogError(ctx, "uncaught error", "id", reqID, "msg", respBody)
+				keyvals := []interface{}{"id", reqID, "msg", respBody}
+				if causes := goa.ErrorCauses(e); len(causes) > 1 {
+					chain := make([]string, len(causes)-1)
+					for i, c := range causes[1:] {
+						chain[i] = c.Error()
+					}
+					keyvals = append(keyvals, "causes", chain)
+				}
+				if err, ok := e.(*goa.Error); ok {
+					if stack := err.Stack(); stack != "" {
+						keyvals = append(keyvals, "stack", stack)
+					}
+				}
+				goa.LogError(ctx, "uncaught error", keyvals...)
 				if !verbose {
-					rw.Header().Set("Content-Type", goa.ErrorMediaIdentifier)
+					rw.Header().Del("Content-Type")
 					respBody = goa.ErrInternal("%s [%s]", http.StatusText(http.StatusInternalServerError), reqID)
 				}
 			}
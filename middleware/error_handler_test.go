@@ -20,6 +20,7 @@ var _ = Describe("ErrorHandler", func() {
 	var service *goa.Service
 	var h goa.Handler
 	var verbose bool
+	var acceptLanguage string
 
 	var rw *testResponseWriter
 
@@ -27,6 +28,7 @@ var _ = Describe("ErrorHandler", func() {
 		service = nil
 		h = nil
 		verbose = true
+		acceptLanguage = ""
 		rw = nil
 	})
 
@@ -35,6 +37,9 @@ var _ = Describe("ErrorHandler", func() {
 		eh := middleware.ErrorHandler(service, verbose)(h)
 		req, err := http.NewRequest("GET", "/foo", nil)
 		Ω(err).ShouldNot(HaveOccurred())
+		if acceptLanguage != "" {
+			req.Header.Set("Accept-Language", acceptLanguage)
+		}
 		ctx := newContext(service, rw, req, nil)
 		err = eh(ctx, rw, req)
 		Ω(err).ShouldNot(HaveOccurred())
@@ -76,6 +81,31 @@ var _ = Describe("ErrorHandler", func() {
 		})
 	})
 
+	Context("with a handler returning an internal goa error with a cause", func() {
+		var logger *testLogger
+
+		BeforeEach(func() {
+			logger = new(testLogger)
+			service = newService(logger)
+			h = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+				return goa.ErrInternal("failed to load bottle").WithCause(errors.New("connection refused"))
+			}
+		})
+
+		It("logs the cause chain", func() {
+			Ω(logger.ErrorEntries).Should(HaveLen(1))
+			data := logger.ErrorEntries[0].Data
+			Ω(data).Should(ContainElement("causes"))
+			Ω(data).Should(ContainElement([]string{"connection refused"}))
+		})
+
+		It("does not render the cause", func() {
+			Ω(rw.Status).Should(Equal(500))
+			Ω(string(rw.Body)).Should(ContainSubstring("failed to load bottle"))
+			Ω(string(rw.Body)).ShouldNot(ContainSubstring("connection refused"))
+		})
+	})
+
 	Context("with a handler returning a goa error", func() {
 		var gerr *goa.Error
 
@@ -100,5 +130,57 @@ var _ = Describe("ErrorHandler", func() {
 			Ω(err).ShouldNot(HaveOccurred())
 			Ω(fmt.Sprintf("%v", decoded)).Should(Equal(fmt.Sprintf("%v", *gerr)))
 		})
+
+		Context("with a problem error formatter", func() {
+			BeforeEach(func() {
+				service.ErrorFormatter = goa.NewProblemErrorFormatter("")
+			})
+
+			It("renders the error as a problem", func() {
+				var decoded map[string]interface{}
+				Ω(rw.Status).Should(Equal(gerr.Status))
+				Ω(rw.ParentHeader["Content-Type"]).Should(Equal([]string{goa.ProblemMediaIdentifier}))
+				err := service.Decoder.Decode(&decoded, bytes.NewBuffer(rw.Body), "application/json")
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(decoded).Should(HaveKeyWithValue("type", "code"))
+				Ω(decoded).Should(HaveKeyWithValue("detail", "teapot"))
+				Ω(decoded).Should(HaveKeyWithValue("foobar", 42.0))
+			})
+		})
+	})
+
+	Context("with a handler returning a validation error", func() {
+		BeforeEach(func() {
+			service = newService(nil)
+			h = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+				return goa.MissingParamError("id")
+			}
+		})
+
+		It("renders the English message and the field errors", func() {
+			var decoded goa.Error
+			Ω(rw.Status).Should(Equal(400))
+			err := service.Decoder.Decode(&decoded, bytes.NewBuffer(rw.Body), "application/json")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(decoded.Detail).Should(Equal(`missing required parameter "id"`))
+			Ω(decoded.MetaValues).ShouldNot(HaveKey(goa.MessageKeyMeta))
+			Ω(decoded.FieldErrors()).Should(Equal([]*goa.FieldError{{Path: "id", Rule: "required"}}))
+		})
+
+		Context("with a translator supporting the requested language", func() {
+			BeforeEach(func() {
+				catalog := goa.NewMessageCatalog()
+				catalog.Add("fr", map[string]string{goa.MsgMissingParam: "paramètre obligatoire %#v manquant"})
+				service.Translator = catalog
+				acceptLanguage = "de;q=0.9, fr-CH"
+			})
+
+			It("localizes the message", func() {
+				var decoded goa.Error
+				err := service.Decoder.Decode(&decoded, bytes.NewBuffer(rw.Body), "application/json")
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(decoded.Detail).Should(Equal(`paramètre obligatoire "id" manquant`))
+			})
+		})
 	})
 })
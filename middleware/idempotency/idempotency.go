@@ -0,0 +1,178 @@
+/*
+Package idempotency provides a middleware that makes the requests carrying an Idempotency-Key
+header safe to retry. The middleware records the status, headers and body of the response to the
+first request made with a given key and replays it to the retries made with the same key instead
+of handling them again. The keys are scoped to the principal making the request as returned by
+goa.ContextUser, the middleware thus rejects the idempotency keys of unauthenticated requests.
+
+The middleware runs for the actions marked with the Idempotent DSL in the design. It is mounted
+with the generated UseIdempotencyMiddleware function before the controllers are created:
+
+	app.UseIdempotencyMiddleware(service, idempotency.New(idempotency.NewMemoryStore(), 24*time.Hour))
+
+The generated code runs the middleware after the security middleware of the action if any so that
+the principal is known.
+*/
+package idempotency
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/goadesign/goa"
+	"golang.org/x/net/context"
+)
+
+const (
+	// KeyHeader is the name of the request header that contains the idempotency key.
+	KeyHeader = "Idempotency-Key"
+	// ReplayedHeader is the name of the header set on replayed responses.
+	ReplayedHeader = "Idempotent-Replayed"
+)
+
+var (
+	// ErrConflict is the error returned by the middleware for requests made while a request with
+	// the same idempotency key is being handled.
+	ErrConflict = goa.NewErrorClass("idempotency_conflict", 409)
+
+	// ErrKeyReused is the error returned by the middleware for requests whose idempotency key
+	// was used with a different method or URI.
+	ErrKeyReused = goa.NewErrorClass("idempotency_key_reused", 422)
+
+	// ErrUnauthenticated is the error returned by the middleware for requests that carry an
+	// idempotency key but no principal. Keys are chosen by clients, scoping them to the principal
+	// prevents a client from getting the responses recorded for another client.
+	ErrUnauthenticated = goa.NewErrorClass("idempotency_unauthenticated", 401)
+)
+
+// LockTTL is the duration of the reservation made by the middleware while the first request
+// made with an idempotency key is handled. The reservation expires after LockTTL if the service
+// dies before the response is recorded so that the request may be retried, LockTTL must thus
+// exceed the time it takes to handle a request.
+var LockTTL = time.Minute
+
+// recorder copies the status, headers and body written to the response.
+type recorder struct {
+	http.ResponseWriter
+	status int
+	header http.Header
+	body   bytes.Buffer
+}
+
+// New returns a middleware that records the responses to the requests that carry the
+// Idempotency-Key header in store and replays them to the retries. Responses are kept for ttl,
+// the keys of the requests being handled are reserved for LockTTL.
+// Responses with a 5xx status and errors returned by the handler before writing the response
+// are not recorded so that the request may be retried. The middleware fails the requests made
+// while the first request is being handled with ErrConflict and the requests that reuse a key
+// with a different method or URI with ErrKeyReused. Requests with an idempotency key must be
+// authenticated, the middleware fails the others with ErrUnauthenticated. Requests without
+// idempotency key are handled normally.
+func New(store Store, ttl time.Duration) goa.Middleware {
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			key := req.Header.Get(KeyHeader)
+			if key == "" {
+				return h(ctx, rw, req)
+			}
+			user := goa.ContextUser(ctx)
+			if user == "" {
+				return ErrUnauthenticated("idempotency key %#v requires an authenticated request", key)
+			}
+			// Header values cannot contain new lines so the store key is unambiguous.
+			skey := user + "\n" + key
+			fingerprint := req.Method + " " + req.URL.RequestURI()
+			token := newToken()
+			entry, err := store.Reserve(skey, token, fingerprint, LockTTL)
+			if err != nil {
+				return err
+			}
+			if entry != nil {
+				switch {
+				case entry.Fingerprint != fingerprint:
+					return ErrKeyReused("idempotency key %#v was used with %s", key, entry.Fingerprint)
+				case entry.Response == nil:
+					return ErrConflict("a request with idempotency key %#v is being handled", key)
+				}
+				return replay(ctx, entry.Response)
+			}
+
+			resp := goa.ContextResponse(ctx)
+			rec := &recorder{ResponseWriter: resp.ResponseWriter}
+			orig := resp.SwitchWriter(rec)
+			saved := false
+			defer func() {
+				resp.SwitchWriter(orig)
+				if !saved {
+					if rerr := store.Release(skey, token); rerr != nil {
+						goa.LogError(ctx, "failed to release idempotency key", "key", key, "err", rerr)
+					}
+				}
+			}()
+
+			err = h(ctx, rw, req)
+
+			if rec.status == 0 || rec.status >= 500 {
+				return err
+			}
+			recorded := &Response{Status: rec.status, Header: rec.header, Body: rec.body.Bytes()}
+			if serr := store.Save(skey, token, fingerprint, recorded, ttl); serr != nil {
+				goa.LogError(ctx, "failed to save idempotent response", "key", key, "err", serr)
+				return err
+			}
+			saved = true
+			return err
+		}
+	}
+}
+
+// newToken returns a random token that identifies the owner of a reservation.
+func newToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// replay writes the recorded response.
+func replay(ctx context.Context, recorded *Response) error {
+	resp := goa.ContextResponse(ctx)
+	header := resp.Header()
+	for k, v := range recorded.Header {
+		header[k] = v
+	}
+	header.Set(ReplayedHeader, "true")
+	resp.WriteHeader(recorded.Status)
+	_, err := resp.Write(recorded.Body)
+	return err
+}
+
+// WriteHeader records the status and a copy of the headers and writes the header.
+func (r *recorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+		r.header = make(http.Header, len(r.ResponseWriter.Header()))
+		for k, v := range r.ResponseWriter.Header() {
+			r.header[k] = append([]string(nil), v...)
+		}
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Write records and writes b.
+func (r *recorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.WriteHeader(http.StatusOK)
+	}
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+// Flush flushes the underlying writer.
+func (r *recorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
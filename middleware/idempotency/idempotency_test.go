@@ -0,0 +1,192 @@
+package idempotency_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/middleware"
+	"github.com/goadesign/goa/middleware/idempotency"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("New", func() {
+	var service *goa.Service
+	var store *idempotency.MemoryStore
+	var handler goa.Handler
+	var called int
+
+	BeforeEach(func() {
+		service = goa.New("test")
+		service.Encoder.Register(goa.NewJSONEncoder, "*/*")
+		store = idempotency.NewMemoryStore()
+		called = 0
+		handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			called++
+			rw.Header().Set("Location", "/orders/1")
+			return service.Send(ctx, 201, map[string]int{"id": called})
+		}
+	})
+
+	serve := func(user, method, key string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, "/orders", nil)
+		if key != "" {
+			req.Header.Set(idempotency.KeyHeader, key)
+		}
+		rw := httptest.NewRecorder()
+		ctx := goa.NewContext(service.Context, rw, req, nil)
+		if user != "" {
+			ctx = goa.WithUser(ctx, user)
+		}
+		h := middleware.ErrorHandler(service, false)(idempotency.New(store, time.Hour)(handler))
+		Ω(h(ctx, rw, req)).ShouldNot(HaveOccurred())
+		return rw
+	}
+
+	It("replays the response to retries", func() {
+		first := serve("alice", "POST", "k1")
+		Ω(first.Code).Should(Equal(201))
+		Ω(first.Header().Get(idempotency.ReplayedHeader)).Should(BeEmpty())
+		retry := serve("alice", "POST", "k1")
+		Ω(called).Should(Equal(1))
+		Ω(retry.Code).Should(Equal(201))
+		Ω(retry.Body.String()).Should(Equal(first.Body.String()))
+		Ω(retry.Header().Get("Location")).Should(Equal("/orders/1"))
+		Ω(retry.Header().Get(idempotency.ReplayedHeader)).Should(Equal("true"))
+	})
+
+	It("scopes the keys to the principal", func() {
+		serve("alice", "POST", "k1")
+		rw := serve("bob", "POST", "k1")
+		Ω(called).Should(Equal(2))
+		Ω(rw.Body.String()).Should(ContainSubstring(`"id":2`))
+	})
+
+	It("rejects the keys of unauthenticated requests", func() {
+		rw := serve("", "POST", "k1")
+		Ω(rw.Code).Should(Equal(401))
+		Ω(rw.Body.String()).Should(ContainSubstring("idempotency_unauthenticated"))
+		Ω(called).Should(Equal(0))
+		Ω(store.Len()).Should(Equal(0))
+	})
+
+	Context("with a short lock TTL", func() {
+		var lockTTL time.Duration
+
+		BeforeEach(func() {
+			lockTTL = idempotency.LockTTL
+			idempotency.LockTTL = time.Nanosecond
+		})
+
+		AfterEach(func() {
+			idempotency.LockTTL = lockTTL
+		})
+
+		It("keeps the responses for the full TTL", func() {
+			serve("alice", "POST", "k1")
+			time.Sleep(time.Millisecond)
+			rw := serve("alice", "POST", "k1")
+			Ω(called).Should(Equal(1))
+			Ω(rw.Header().Get(idempotency.ReplayedHeader)).Should(Equal("true"))
+		})
+
+		Context("that expires while the request is handled", func() {
+			BeforeEach(func() {
+				idempotency.LockTTL = time.Millisecond
+				handle := handler
+				handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+					time.Sleep(5 * time.Millisecond)
+					return handle(ctx, rw, req)
+				}
+			})
+
+			It("replays the response to retries", func() {
+				Ω(serve("alice", "POST", "k1").Code).Should(Equal(201))
+				rw := serve("alice", "POST", "k1")
+				Ω(called).Should(Equal(1))
+				Ω(rw.Code).Should(Equal(201))
+				Ω(rw.Header().Get(idempotency.ReplayedHeader)).Should(Equal("true"))
+			})
+		})
+	})
+
+	It("handles the requests without key", func() {
+		serve("alice", "POST", "")
+		serve("alice", "POST", "")
+		Ω(called).Should(Equal(2))
+		Ω(store.Len()).Should(Equal(0))
+	})
+
+	It("rejects keys reused with a different method or URI", func() {
+		serve("alice", "POST", "k1")
+		rw := serve("alice", "PUT", "k1")
+		Ω(rw.Code).Should(Equal(422))
+		Ω(rw.Body.String()).Should(ContainSubstring("idempotency_key_reused"))
+		Ω(called).Should(Equal(1))
+	})
+
+	Context("with a request being handled", func() {
+		var started, release chan struct{}
+
+		BeforeEach(func() {
+			started, release = make(chan struct{}), make(chan struct{})
+			handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+				called++
+				close(started)
+				<-release
+				return service.Send(ctx, 201, "created")
+			}
+		})
+
+		It("rejects concurrent duplicates", func() {
+			done := make(chan *httptest.ResponseRecorder)
+			go func() {
+				defer GinkgoRecover()
+				done <- serve("alice", "POST", "k1")
+			}()
+			<-started
+			rw := serve("alice", "POST", "k1")
+			close(release)
+			Ω((<-done).Code).Should(Equal(201))
+			Ω(rw.Code).Should(Equal(409))
+			Ω(rw.Body.String()).Should(ContainSubstring("idempotency_conflict"))
+			Ω(called).Should(Equal(1))
+		})
+	})
+
+	Context("with a handler that fails", func() {
+		BeforeEach(func() {
+			handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+				called++
+				return errors.New("boom")
+			}
+		})
+
+		It("lets the request be retried", func() {
+			Ω(serve("alice", "POST", "k1").Code).Should(Equal(500))
+			Ω(serve("alice", "POST", "k1").Code).Should(Equal(500))
+			Ω(called).Should(Equal(2))
+			Ω(store.Len()).Should(Equal(0))
+		})
+	})
+
+	Context("with a handler that writes a client error", func() {
+		BeforeEach(func() {
+			handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+				called++
+				return service.Send(ctx, 400, "invalid")
+			}
+		})
+
+		It("replays the error", func() {
+			serve("alice", "POST", "k1")
+			rw := serve("alice", "POST", "k1")
+			Ω(rw.Code).Should(Equal(400))
+			Ω(called).Should(Equal(1))
+		})
+	})
+})
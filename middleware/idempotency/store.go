@@ -0,0 +1,137 @@
+package idempotency
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type (
+	// Store records the requests handled by the middleware and their responses. Implementations
+	// backed by shared storage make it possible to detect retries across multiple service
+	// instances.
+	Store interface {
+		// Reserve atomically records that the request identified by key and fingerprint is
+		// being handled by the owner identified by token unless key is already recorded in
+		// which case it returns the recorded entry. The reservation expires after ttl, the
+		// middleware gives a short ttl so that the key is freed quickly if the service dies
+		// while handling the request.
+		Reserve(key, token, fingerprint string, ttl time.Duration) (*Entry, error)
+		// Save records the response to the request identified by key and fingerprint. The
+		// response is kept for ttl which replaces the ttl given to Reserve. Save returns
+		// ErrNotOwner and leaves the entry unchanged if key is recorded for another owner
+		// than the one identified by token.
+		Save(key, token, fingerprint string, resp *Response, ttl time.Duration) error
+		// Release removes key so that the request it identifies may be handled again. Release
+		// does nothing if key is recorded for another owner than the one identified by token.
+		Release(key, token string) error
+	}
+
+	// Entry is a request recorded in a store.
+	Entry struct {
+		// Fingerprint identifies the method and URI of the request.
+		Fingerprint string
+		// Response is the response to the request, nil while it is being handled.
+		Response *Response
+	}
+
+	// Response is a response recorded in a store.
+	Response struct {
+		// Status is the response status code.
+		Status int
+		// Header contains the response headers.
+		Header http.Header
+		// Body is the response body.
+		Body []byte
+	}
+
+	// MemoryStore is a Store that keeps the entries in memory.
+	MemoryStore struct {
+		mu        sync.Mutex
+		entries   map[string]*memoryEntry
+		lastSweep time.Time
+	}
+
+	// memoryEntry is an entry stored in a MemoryStore.
+	memoryEntry struct {
+		entry   Entry
+		token   string
+		expires time.Time
+	}
+)
+
+// ErrNotOwner is the error returned by Store.Save when the key is recorded for another owner,
+// typically because the reservation expired while the request was handled and another request
+// made with the same key reserved it.
+var ErrNotOwner = errors.New("idempotency key is reserved by another request")
+
+// sweepInterval is the minimum duration between two removals of the expired MemoryStore entries.
+const sweepInterval = time.Minute
+
+// NewMemoryStore returns a store that keeps the entries in memory. Expired entries are removed
+// periodically as requests are reserved.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]*memoryEntry), lastSweep: time.Now()}
+}
+
+// Reserve records key unless it is already recorded and returns the existing entry if any.
+func (s *MemoryStore) Reserve(key, token, fingerprint string, ttl time.Duration) (*Entry, error) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.entries == nil {
+		s.entries = make(map[string]*memoryEntry)
+	}
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		for k, e := range s.entries {
+			if !now.Before(e.expires) {
+				delete(s.entries, k)
+			}
+		}
+		s.lastSweep = now
+	}
+	if e, ok := s.entries[key]; ok && now.Before(e.expires) {
+		entry := e.entry
+		return &entry, nil
+	}
+	s.entries[key] = &memoryEntry{entry: Entry{Fingerprint: fingerprint}, token: token, expires: now.Add(ttl)}
+	return nil, nil
+}
+
+// Save records the response to the request identified by key unless another owner recorded key.
+func (s *MemoryStore) Save(key, token, fingerprint string, resp *Response, ttl time.Duration) error {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.entries == nil {
+		s.entries = make(map[string]*memoryEntry)
+	}
+	if e, ok := s.entries[key]; ok && e.token != token && now.Before(e.expires) {
+		return ErrNotOwner
+	}
+	s.entries[key] = &memoryEntry{
+		entry:   Entry{Fingerprint: fingerprint, Response: resp},
+		token:   token,
+		expires: now.Add(ttl),
+	}
+	return nil
+}
+
+// Release removes key unless another owner recorded it.
+func (s *MemoryStore) Release(key, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.entries[key]; ok && e.token == token {
+		delete(s.entries, key)
+	}
+	return nil
+}
+
+// Len returns the number of entries held by the store including the expired entries that have
+// not been removed yet.
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
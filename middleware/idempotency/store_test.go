@@ -0,0 +1,87 @@
+package idempotency_test
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/goadesign/goa/middleware/idempotency"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MemoryStore", func() {
+	var store *idempotency.MemoryStore
+
+	BeforeEach(func() {
+		store = idempotency.NewMemoryStore()
+	})
+
+	It("reserves unknown keys", func() {
+		entry, err := store.Reserve("key", "t1", "POST /orders", time.Minute)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(entry).Should(BeNil())
+		Ω(store.Len()).Should(Equal(1))
+	})
+
+	It("returns the entries of reserved keys", func() {
+		store.Reserve("key", "t1", "POST /orders", time.Minute)
+		entry, err := store.Reserve("key", "t1", "POST /other", time.Minute)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(entry).ShouldNot(BeNil())
+		Ω(entry.Fingerprint).Should(Equal("POST /orders"))
+		Ω(entry.Response).Should(BeNil())
+	})
+
+	It("returns the saved responses", func() {
+		store.Reserve("key", "t1", "POST /orders", time.Minute)
+		resp := &idempotency.Response{Status: 201, Header: http.Header{"Location": {"/orders/1"}}, Body: []byte("{}")}
+		Ω(store.Save("key", "t1", "POST /orders", resp, time.Minute)).ShouldNot(HaveOccurred())
+		entry, err := store.Reserve("key", "t1", "POST /orders", time.Minute)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(entry.Fingerprint).Should(Equal("POST /orders"))
+		Ω(entry.Response).Should(Equal(resp))
+	})
+
+	It("releases keys", func() {
+		store.Reserve("key", "t1", "POST /orders", time.Minute)
+		Ω(store.Release("key", "t1")).ShouldNot(HaveOccurred())
+		entry, err := store.Reserve("key", "t1", "POST /orders", time.Minute)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(entry).Should(BeNil())
+	})
+
+	It("does not release the keys reserved by other owners", func() {
+		store.Reserve("key", "t1", "POST /orders", time.Millisecond)
+		time.Sleep(5 * time.Millisecond)
+		store.Reserve("key", "t2", "POST /orders", time.Minute)
+		Ω(store.Release("key", "t1")).ShouldNot(HaveOccurred())
+		entry, err := store.Reserve("key", "t3", "POST /orders", time.Minute)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(entry).ShouldNot(BeNil())
+	})
+
+	It("does not save the responses of other owners", func() {
+		store.Reserve("key", "t1", "POST /orders", time.Millisecond)
+		time.Sleep(5 * time.Millisecond)
+		store.Reserve("key", "t2", "POST /orders", time.Minute)
+		err := store.Save("key", "t1", "POST /orders", &idempotency.Response{Status: 201}, time.Minute)
+		Ω(err).Should(Equal(idempotency.ErrNotOwner))
+	})
+
+	It("saves the fingerprint of expired reservations", func() {
+		store.Reserve("key", "t1", "POST /orders", time.Millisecond)
+		time.Sleep(5 * time.Millisecond)
+		Ω(store.Save("key", "t1", "POST /orders", &idempotency.Response{Status: 201}, time.Minute)).ShouldNot(HaveOccurred())
+		entry, err := store.Reserve("key", "t2", "POST /orders", time.Minute)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(entry.Fingerprint).Should(Equal("POST /orders"))
+	})
+
+	It("expires entries", func() {
+		store.Reserve("key", "t1", "POST /orders", time.Millisecond)
+		time.Sleep(5 * time.Millisecond)
+		entry, err := store.Reserve("key", "t1", "POST /orders", time.Minute)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(entry).Should(BeNil())
+	})
+})
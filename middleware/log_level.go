@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/goadesign/goa"
+
+	"golang.org/x/net/context"
+)
+
+// LogLevelHeader is the name of the header used to raise the log verbosity of a request.
+const LogLevelHeader = "X-Log-Level"
+
+// RequestLogLevel creates a middleware that lowers the minimum log level of a request to the level
+// named by the value of the given header, e.g. "debug". The header may only raise the verbosity:
+// values naming a level higher than the current minimum level and invalid values are ignored.
+// Since any client may set the header the middleware should only be used in trusted environments
+// or mounted after an authentication middleware.
+func RequestLogLevel(header string) goa.Middleware {
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			if v := req.Header.Get(header); v != "" {
+				if level, ok := goa.ParseLogLevel(v); ok && level < goa.ContextLogLevel(ctx) {
+					ctx = goa.WithLogLevel(ctx, level)
+				}
+			}
+			return h(ctx, rw, req)
+		}
+	}
+}
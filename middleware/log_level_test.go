@@ -0,0 +1,51 @@
+package middleware_test
+
+import (
+	"net/http"
+
+	"golang.org/x/net/context"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/middleware"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RequestLogLevel", func() {
+	var ctx context.Context
+	var req *http.Request
+	var rw http.ResponseWriter
+	var level goa.LogLevel
+
+	h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		level = goa.ContextLogLevel(ctx)
+		return nil
+	}
+
+	BeforeEach(func() {
+		service := newService(nil)
+		var err error
+		req, err = http.NewRequest("GET", "/goo", nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		rw = new(testResponseWriter)
+		ctx = newContext(service, rw, req, nil)
+	})
+
+	It("raises the verbosity of the request", func() {
+		req.Header.Set(middleware.LogLevelHeader, "debug")
+		Ω(middleware.RequestLogLevel(middleware.LogLevelHeader)(h)(ctx, rw, req)).ShouldNot(HaveOccurred())
+		Ω(level).Should(Equal(goa.LevelDebug))
+	})
+
+	It("does not lower the verbosity of the request", func() {
+		req.Header.Set(middleware.LogLevelHeader, "error")
+		Ω(middleware.RequestLogLevel(middleware.LogLevelHeader)(h)(ctx, rw, req)).ShouldNot(HaveOccurred())
+		Ω(level).Should(Equal(goa.LevelInfo))
+	})
+
+	It("ignores invalid levels", func() {
+		req.Header.Set(middleware.LogLevelHeader, "verbose")
+		Ω(middleware.RequestLogLevel(middleware.LogLevelHeader)(h)(ctx, rw, req)).ShouldNot(HaveOccurred())
+		Ω(level).Should(Equal(goa.LevelInfo))
+	})
+})
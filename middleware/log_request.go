@@ -18,7 +18,8 @@ import (
 // LogRequest creates a request logger middleware.
 // This middleware is aware of the RequestID middleware and if registered after it leverages the
 // request ID for logging.
-// If verbose is true then the middlware logs the request and response bodies.
+// If verbose is true then the middlware logs the request parameters and payload using the debug
+// level, see goa.WithLogLevel and RequestLogLevel.
 func LogRequest(verbose bool) goa.Middleware {
 	return func(h goa.Handler) goa.Handler {
 		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
@@ -40,7 +41,7 @@ func LogRequest(verbose bool) goa.Middleware {
 						logCtx[i+1] = interface{}(strings.Join(v, ", "))
 						i = i + 2
 					}
-					goa.LogInfo(ctx, "params", logCtx...)
+					goa.LogDebug(ctx, "params", logCtx...)
 				}
 				if r.ContentLength > 0 {
 					if mp, ok := r.Payload.(map[string]interface{}); ok {
@@ -51,14 +52,14 @@ func LogRequest(verbose bool) goa.Middleware {
 							logCtx[i+1] = interface{}(v)
 							i = i + 2
 						}
-						goa.LogInfo(ctx, "payload", logCtx...)
+						goa.LogDebug(ctx, "payload", logCtx...)
 					} else {
 						// Not the most efficient but this is used for debugging
 						js, err := json.Marshal(r.Payload)
 						if err != nil {
 							js = []byte("<invalid JSON>")
 						}
-						goa.LogInfo(ctx, "payload", "raw", string(js))
+						goa.LogDebug(ctx, "payload", "raw", string(js))
 					}
 				}
 			}
@@ -42,31 +42,42 @@ var _ = Describe("LogRequest", func() {
 			return service.Send(ctx, 200, "ok")
 		}
 		lg := middleware.LogRequest(true)(h)
-		Ω(lg(ctx, rw, req)).ShouldNot(HaveOccurred())
-		Ω(logger.InfoEntries).Should(HaveLen(4))
+		Ω(lg(goa.WithLogLevel(ctx, goa.LevelDebug), rw, req)).ShouldNot(HaveOccurred())
+		Ω(logger.InfoEntries).Should(HaveLen(2))
+		Ω(logger.DebugEntries).Should(HaveLen(2))
 
 		Ω(logger.InfoEntries[0].Data).Should(HaveLen(10))
 		Ω(logger.InfoEntries[0].Data[0]).Should(Equal("req_id"))
 		Ω(logger.InfoEntries[0].Data[2]).Should(Equal("POST"))
 		Ω(logger.InfoEntries[0].Data[3]).Should(Equal("/goo?param=value"))
 
-		Ω(logger.InfoEntries[1].Data).Should(HaveLen(4))
-		Ω(logger.InfoEntries[0].Data[0]).Should(Equal("req_id"))
-		Ω(logger.InfoEntries[1].Data[2]).Should(Equal("query"))
-		Ω(logger.InfoEntries[1].Data[3]).Should(Equal("value"))
+		Ω(logger.DebugEntries[0].Data).Should(HaveLen(4))
+		Ω(logger.DebugEntries[0].Data[0]).Should(Equal("req_id"))
+		Ω(logger.DebugEntries[0].Data[2]).Should(Equal("query"))
+		Ω(logger.DebugEntries[0].Data[3]).Should(Equal("value"))
 
-		Ω(logger.InfoEntries[2].Data).Should(HaveLen(4))
-		Ω(logger.InfoEntries[0].Data[0]).Should(Equal("req_id"))
-		Ω(logger.InfoEntries[2].Data[2]).Should(Equal("payload"))
-		Ω(logger.InfoEntries[2].Data[3]).Should(Equal(42))
+		Ω(logger.DebugEntries[1].Data).Should(HaveLen(4))
+		Ω(logger.DebugEntries[1].Data[0]).Should(Equal("req_id"))
+		Ω(logger.DebugEntries[1].Data[2]).Should(Equal("payload"))
+		Ω(logger.DebugEntries[1].Data[3]).Should(Equal(42))
 
-		Ω(logger.InfoEntries[3].Data).Should(HaveLen(8))
-		Ω(logger.InfoEntries[0].Data[0]).Should(Equal("req_id"))
-		Ω(logger.InfoEntries[3].Data[2]).Should(Equal("status"))
-		Ω(logger.InfoEntries[3].Data[3]).Should(Equal(200))
-		Ω(logger.InfoEntries[3].Data[4]).Should(Equal("bytes"))
-		Ω(logger.InfoEntries[3].Data[5]).Should(Equal(5))
-		Ω(logger.InfoEntries[3].Data[6]).Should(Equal("time"))
+		Ω(logger.InfoEntries[1].Data).Should(HaveLen(8))
+		Ω(logger.InfoEntries[1].Data[0]).Should(Equal("req_id"))
+		Ω(logger.InfoEntries[1].Data[2]).Should(Equal("status"))
+		Ω(logger.InfoEntries[1].Data[3]).Should(Equal(200))
+		Ω(logger.InfoEntries[1].Data[4]).Should(Equal("bytes"))
+		Ω(logger.InfoEntries[1].Data[5]).Should(Equal(5))
+		Ω(logger.InfoEntries[1].Data[6]).Should(Equal("time"))
+	})
+
+	It("does not log the parameters and payload if the debug level is disabled", func() {
+		h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			return service.Send(ctx, 200, "ok")
+		}
+		lg := middleware.LogRequest(true)(h)
+		Ω(lg(ctx, rw, req)).ShouldNot(HaveOccurred())
+		Ω(logger.InfoEntries).Should(HaveLen(2))
+		Ω(logger.DebugEntries).Should(BeEmpty())
 	})
 
 	It("logs error codes", func() {
@@ -88,7 +99,7 @@ var _ = Describe("LogRequest", func() {
 		Ω(logger.InfoEntries[1].Data[4]).Should(Equal("error"))
 		Ω(logger.InfoEntries[1].Data[5]).Should(Equal("invalid_request"))
 		Ω(logger.InfoEntries[1].Data[6]).Should(Equal("bytes"))
-		Ω(logger.InfoEntries[1].Data[7]).Should(Equal(86))
+		Ω(logger.InfoEntries[1].Data[7]).Should(Equal(139))
 		Ω(logger.InfoEntries[1].Data[8]).Should(Equal("time"))
 	})
 })
@@ -40,11 +40,11 @@ var _ = Describe("LogResponse", func() {
 			return nil
 		}
 		lg := middleware.LogResponse()(h)
-		Ω(lg(ctx, rw, req)).ShouldNot(HaveOccurred())
-		Ω(logger.InfoEntries).Should(HaveLen(1))
+		Ω(lg(goa.WithLogLevel(ctx, goa.LevelDebug), rw, req)).ShouldNot(HaveOccurred())
+		Ω(logger.DebugEntries).Should(HaveLen(1))
 
-		Ω(logger.InfoEntries[0].Data).Should(HaveLen(2))
-		Ω(logger.InfoEntries[0].Data[0]).Should(Equal("body"))
-		Ω(logger.InfoEntries[0].Data[1]).Should(Equal(responseText))
+		Ω(logger.DebugEntries[0].Data).Should(HaveLen(2))
+		Ω(logger.DebugEntries[0].Data[0]).Should(Equal("body"))
+		Ω(logger.DebugEntries[0].Data[1]).Should(Equal(responseText))
 	})
 })
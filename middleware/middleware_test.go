@@ -30,10 +30,22 @@ type logEntry struct {
 
 type testLogger struct {
 	Context      []interface{}
+	DebugEntries []logEntry
 	InfoEntries  []logEntry
+	WarnEntries  []logEntry
 	ErrorEntries []logEntry
 }
 
+func (t *testLogger) Debug(msg string, data ...interface{}) {
+	e := logEntry{msg, append(t.Context, data...)}
+	t.DebugEntries = append(t.DebugEntries, e)
+}
+
+func (t *testLogger) Warn(msg string, data ...interface{}) {
+	e := logEntry{msg, append(t.Context, data...)}
+	t.WarnEntries = append(t.WarnEntries, e)
+}
+
 func (t *testLogger) Info(msg string, data ...interface{}) {
 	e := logEntry{msg, append(t.Context, data...)}
 	t.InfoEntries = append(t.InfoEntries, e)
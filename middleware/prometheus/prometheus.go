@@ -0,0 +1,288 @@
+/*
+Package prometheus provides a middleware that records request metrics and a handler that exposes
+them using the Prometheus text exposition format. The package does not depend on the Prometheus
+client libraries.
+
+The middleware records the following metrics where <ns> is the collector namespace:
+
+	<ns>_http_requests_total                 counter   controller, action, status, error_code
+	<ns>_http_request_duration_seconds       histogram controller, action, status, error_code
+	<ns>_http_response_size_bytes            histogram controller, action, status, error_code
+	<ns>_http_requests_in_flight             gauge     controller, action
+
+Usage:
+
+	collector := prometheus.NewCollector("goa")
+	service.Use(collector.Middleware())
+	collector.Mount(service, "/metrics")
+*/
+package prometheus
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goadesign/goa"
+	"golang.org/x/net/context"
+)
+
+// ContentType is the content type of the metrics exposition.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+var (
+	// DefaultDurationBuckets are the upper bounds in seconds of the request duration histogram
+	// buckets used by collectors created with NewCollector.
+	DefaultDurationBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+	// DefaultSizeBuckets are the upper bounds in bytes of the response size histogram buckets
+	// used by collectors created with NewCollector.
+	DefaultSizeBuckets = []float64{100, 1000, 10000, 100000, 1000000, 10000000}
+)
+
+type (
+	// Collector records request metrics and renders them using the Prometheus text format.
+	// A collector is typically used by a single service.
+	Collector struct {
+		// Namespace is the prefix of the metric names.
+		Namespace string
+		// DurationBuckets are the upper bounds of the request duration histogram buckets.
+		DurationBuckets []float64
+		// SizeBuckets are the upper bounds of the response size histogram buckets.
+		SizeBuckets []float64
+
+		mu        sync.Mutex
+		requests  map[labels]float64
+		durations map[labels]*histogram
+		sizes     map[labels]*histogram
+		inflight  map[labels]float64
+	}
+
+	// labels identifies a time series of a metric.
+	labels struct {
+		controller, action, status, errorCode string
+	}
+
+	// histogram counts observations in buckets.
+	histogram struct {
+		counts []uint64 // Count of observations per bucket, not cumulative
+		count  uint64
+		sum    float64
+	}
+)
+
+// NewCollector returns a collector that prefixes its metric names with namespace and uses the
+// default histogram buckets.
+func NewCollector(namespace string) *Collector {
+	return &Collector{
+		Namespace:       namespace,
+		DurationBuckets: DefaultDurationBuckets,
+		SizeBuckets:     DefaultSizeBuckets,
+	}
+}
+
+// Middleware returns a middleware that records the metrics of each request. The controller and
+// action labels are read from the request context. The status and error_code labels are read
+// from the response data once the request has been handled or from the error returned by the
+// handler if the response was not written. The middleware should thus be mounted above the
+// ErrorHandler middleware.
+func (c *Collector) Middleware() goa.Middleware {
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			started := time.Now()
+			flight := labels{controller: goa.ContextController(ctx), action: goa.ContextAction(ctx)}
+			c.addInFlight(flight, 1)
+			defer c.addInFlight(flight, -1)
+
+			err := h(ctx, rw, req)
+
+			l := flight
+			var length int
+			if resp := goa.ContextResponse(ctx); resp != nil {
+				l.status, l.errorCode, length = strconv.Itoa(resp.Status), resp.ErrorCode, resp.Length
+			}
+			if err != nil && (l.status == "" || l.status == "0") {
+				l.status = "500"
+				if gerr, ok := err.(*goa.Error); ok {
+					l.status, l.errorCode = strconv.Itoa(gerr.Status), gerr.Code
+				}
+			}
+			c.observe(l, time.Since(started).Seconds(), float64(length))
+			return err
+		}
+	}
+}
+
+// Handler returns a handler that renders the metrics using the Prometheus text format.
+func (c *Collector) Handler() goa.Handler {
+	return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		rw.Header().Set("Content-Type", ContentType)
+		rw.WriteHeader(http.StatusOK)
+		_, err := c.WriteTo(rw)
+		return err
+	}
+}
+
+// Mount mounts the metrics handler on the service under the given path, typically "/metrics".
+func (c *Collector) Mount(service *goa.Service, path string) {
+	ctrl := service.NewController("Metrics")
+	route := &goa.Route{Method: "GET", Path: path, Controller: ctrl.Name, Action: "show"}
+	service.HandleRoute(route, ctrl.MuxHandler("show", c.Handler(), nil))
+	goa.LogInfo(ctrl.Context, "mount metrics", "route", fmt.Sprintf("GET %s", path))
+}
+
+// WriteTo writes the metrics to w using the Prometheus text format. The time series of each
+// metric are sorted by label values.
+func (c *Collector) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	c.mu.Lock()
+	name := c.metricName("http_requests_total")
+	fmt.Fprintf(&buf, "# HELP %s Total number of HTTP requests handled.\n# TYPE %s counter\n", name, name)
+	for _, l := range sortedLabels(c.requests) {
+		fmt.Fprintf(&buf, "%s%s %s\n", name, l.String(), formatFloat(c.requests[l]))
+	}
+	name = c.metricName("http_request_duration_seconds")
+	fmt.Fprintf(&buf, "# HELP %s Duration of HTTP requests in seconds.\n# TYPE %s histogram\n", name, name)
+	writeHistograms(&buf, name, c.durations, c.DurationBuckets)
+	name = c.metricName("http_response_size_bytes")
+	fmt.Fprintf(&buf, "# HELP %s Size of HTTP response bodies in bytes.\n# TYPE %s histogram\n", name, name)
+	writeHistograms(&buf, name, c.sizes, c.SizeBuckets)
+	name = c.metricName("http_requests_in_flight")
+	fmt.Fprintf(&buf, "# HELP %s Number of HTTP requests being handled.\n# TYPE %s gauge\n", name, name)
+	for _, l := range sortedLabels(c.inflight) {
+		fmt.Fprintf(&buf, "%s%s %s\n", name, l.String(), formatFloat(c.inflight[l]))
+	}
+	c.mu.Unlock()
+	n, err := w.Write(buf.Bytes())
+	return int64(n), err
+}
+
+// addInFlight updates the in-flight requests gauge.
+func (c *Collector) addInFlight(l labels, delta float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.inflight == nil {
+		c.inflight = make(map[labels]float64)
+	}
+	c.inflight[l] += delta
+}
+
+// observe records the metrics of a handled request.
+func (c *Collector) observe(l labels, duration, size float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.requests == nil {
+		c.requests = make(map[labels]float64)
+		c.durations = make(map[labels]*histogram)
+		c.sizes = make(map[labels]*histogram)
+	}
+	c.requests[l]++
+	observeHistogram(c.durations, l, c.DurationBuckets, duration)
+	observeHistogram(c.sizes, l, c.SizeBuckets, size)
+}
+
+// metricName returns the name of the metric prefixed with the collector namespace.
+func (c *Collector) metricName(name string) string {
+	if c.Namespace == "" {
+		return name
+	}
+	return c.Namespace + "_" + name
+}
+
+// String renders the labels using the Prometheus text format. Labels that do not apply to a
+// metric (i.e. the status and error code of in-flight requests) are omitted.
+func (l labels) String() string {
+	pairs := []string{
+		fmt.Sprintf(`controller="%s"`, escape(l.controller)),
+		fmt.Sprintf(`action="%s"`, escape(l.action)),
+	}
+	if l.status != "" {
+		pairs = append(pairs,
+			fmt.Sprintf(`status="%s"`, escape(l.status)),
+			fmt.Sprintf(`error_code="%s"`, escape(l.errorCode)))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// observeHistogram records v in the histogram identified by l creating it if needed.
+func observeHistogram(hs map[labels]*histogram, l labels, buckets []float64, v float64) {
+	h, ok := hs[l]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(buckets))}
+		hs[l] = h
+	}
+	for i, b := range buckets {
+		if v <= b {
+			h.counts[i]++
+			break
+		}
+	}
+	h.count++
+	h.sum += v
+}
+
+// writeHistograms renders the buckets, sum and count of the given histograms.
+func writeHistograms(buf *bytes.Buffer, name string, hs map[labels]*histogram, buckets []float64) {
+	keys := make([]labels, 0, len(hs))
+	for l := range hs {
+		keys = append(keys, l)
+	}
+	sort.Sort(byLabels(keys))
+	for _, l := range keys {
+		h := hs[l]
+		ls := l.String()
+		prefix := ls[:len(ls)-1] + ","
+		var cumul uint64
+		for i, b := range buckets {
+			cumul += h.counts[i]
+			fmt.Fprintf(buf, "%s_bucket%sle=\"%s\"} %d\n", name, prefix, formatFloat(b), cumul)
+		}
+		fmt.Fprintf(buf, "%s_bucket%sle=\"+Inf\"} %d\n", name, prefix, h.count)
+		fmt.Fprintf(buf, "%s_sum%s %s\n", name, ls, formatFloat(h.sum))
+		fmt.Fprintf(buf, "%s_count%s %d\n", name, ls, h.count)
+	}
+}
+
+// sortedLabels returns the keys of m sorted by label values.
+func sortedLabels(m map[labels]float64) []labels {
+	keys := make([]labels, 0, len(m))
+	for l := range m {
+		keys = append(keys, l)
+	}
+	sort.Sort(byLabels(keys))
+	return keys
+}
+
+// formatFloat renders a sample value.
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// escape escapes a label value.
+func escape(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
+
+// byLabels sorts time series by label values.
+type byLabels []labels
+
+func (b byLabels) Len() int      { return len(b) }
+func (b byLabels) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b byLabels) Less(i, j int) bool {
+	x, y := b[i], b[j]
+	switch {
+	case x.controller != y.controller:
+		return x.controller < y.controller
+	case x.action != y.action:
+		return x.action < y.action
+	case x.status != y.status:
+		return x.status < y.status
+	}
+	return x.errorCode < y.errorCode
+}
@@ -0,0 +1,82 @@
+package prometheus_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+
+	"golang.org/x/net/context"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/middleware/prometheus"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Collector", func() {
+	var collector *prometheus.Collector
+	var service *goa.Service
+	var handler goa.Handler
+
+	BeforeEach(func() {
+		collector = prometheus.NewCollector("test")
+		collector.DurationBuckets = []float64{60}
+		service = goa.New("test")
+		service.Encoder.Register(goa.NewJSONEncoder, "*/*")
+		handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			return service.Send(ctx, 200, "ok")
+		}
+	})
+
+	JustBeforeEach(func() {
+		req, err := http.NewRequest("GET", "/bottles/1", nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		rw := httptest.NewRecorder()
+		ctrl := service.NewController("bottle")
+		ctx := goa.NewContext(goa.WithAction(ctrl.Context, "show"), rw, req, nil)
+		collector.Middleware()(handler)(ctx, rw, req)
+	})
+
+	render := func() string {
+		var buf bytes.Buffer
+		_, err := collector.WriteTo(&buf)
+		Ω(err).ShouldNot(HaveOccurred())
+		return buf.String()
+	}
+
+	It("records the request metrics", func() {
+		out := render()
+		Ω(out).Should(ContainSubstring("# TYPE test_http_requests_total counter\n"))
+		Ω(out).Should(ContainSubstring(`test_http_requests_total{controller="bottle",action="show",status="200",error_code=""} 1` + "\n"))
+		Ω(out).Should(ContainSubstring(`test_http_request_duration_seconds_bucket{controller="bottle",action="show",status="200",error_code="",le="60"} 1` + "\n"))
+		Ω(out).Should(ContainSubstring(`test_http_request_duration_seconds_count{controller="bottle",action="show",status="200",error_code=""} 1` + "\n"))
+		Ω(out).Should(ContainSubstring(`test_http_response_size_bytes_bucket{controller="bottle",action="show",status="200",error_code="",le="100"} 1` + "\n"))
+		Ω(out).Should(ContainSubstring(`test_http_response_size_bytes_sum{controller="bottle",action="show",status="200",error_code=""} 5` + "\n"))
+		Ω(out).Should(ContainSubstring(`test_http_requests_in_flight{controller="bottle",action="show"} 0` + "\n"))
+	})
+
+	Context("with a handler returning an error", func() {
+		BeforeEach(func() {
+			handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+				return goa.ErrNotFound("no bottle")
+			}
+		})
+
+		It("labels the metrics with the error status and code", func() {
+			Ω(render()).Should(ContainSubstring(`test_http_requests_total{controller="bottle",action="show",status="404",error_code="not_found"} 1`))
+		})
+	})
+
+	Context("mounted on a service", func() {
+		It("serves the metrics", func() {
+			collector.Mount(service, "/metrics")
+			rw := httptest.NewRecorder()
+			req, err := http.NewRequest("GET", "/metrics", nil)
+			Ω(err).ShouldNot(HaveOccurred())
+			service.Mux.ServeHTTP(rw, req)
+			Ω(rw.Code).Should(Equal(200))
+			Ω(rw.Header().Get("Content-Type")).Should(Equal(prometheus.ContentType))
+			Ω(rw.Body.String()).Should(ContainSubstring(`test_http_requests_total{controller="bottle"`))
+		})
+	})
+})
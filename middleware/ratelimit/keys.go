@@ -0,0 +1,70 @@
+package ratelimit
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/goadesign/goa"
+	"golang.org/x/net/context"
+)
+
+// RemoteAddr identifies clients by the IP address of the network peer. Note that all the clients
+// behind a proxy share the same address.
+func RemoteAddr(ctx context.Context, req *http.Request) string {
+	ip, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return ip
+}
+
+// User identifies clients by the identity recorded by the security middleware that authenticated
+// the request, see goa.WithUser. The rate limit middleware must thus run after the security
+// middleware.
+func User(ctx context.Context, req *http.Request) string {
+	return goa.ContextUser(ctx)
+}
+
+// APIKey returns a key function that identifies clients by the API key read from the request
+// header or querystring parameter with the given name, see goa.APIKeySecurity.
+//
+// The key is only used once a security middleware has validated it and recorded the client
+// identity with goa.WithUser, the key function returns an empty key for other requests. This
+// prevents clients from getting a fresh quota with each made-up key. The rate limit middleware
+// must thus run after the security middleware, use Fallback to also limit unauthenticated
+// requests.
+func APIKey(in goa.Location, name string) KeyFunc {
+	return func(ctx context.Context, req *http.Request) string {
+		if goa.ContextUser(ctx) == "" {
+			return ""
+		}
+		if in == goa.LocQuery {
+			return req.URL.Query().Get(name)
+		}
+		return req.Header.Get(name)
+	}
+}
+
+// JWTSubject identifies clients by the subject ("sub" claim) of the JWT validated by the jwt
+// security middleware which records it with goa.WithUser. The rate limit middleware must thus run
+// after the jwt middleware, see the package documentation.
+func JWTSubject(ctx context.Context, req *http.Request) string {
+	return goa.ContextUser(ctx)
+}
+
+// Fallback returns a key function that returns the first non-empty key computed by keys. The key
+// is prefixed with the index of the function that computed it so that keys computed by different
+// functions never collide. Fallback makes it possible to throttle anonymous clients, e.g.:
+//
+//	ratelimit.Fallback(ratelimit.APIKey(goa.LocHeader, "X-API-Key"), ratelimit.RemoteAddr)
+func Fallback(keys ...KeyFunc) KeyFunc {
+	return func(ctx context.Context, req *http.Request) string {
+		for i, key := range keys {
+			if k := key(ctx, req); k != "" {
+				return strconv.Itoa(i) + ":" + k
+			}
+		}
+		return ""
+	}
+}
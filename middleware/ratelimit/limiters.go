@@ -0,0 +1,139 @@
+package ratelimit
+
+import (
+	"math"
+	"time"
+)
+
+type (
+	// tokenBucket implements the token bucket algorithm.
+	tokenBucket struct {
+		store  Store
+		limit  int
+		period time.Duration
+	}
+
+	// slidingWindow implements the sliding window counter algorithm.
+	slidingWindow struct {
+		store  Store
+		limit  int
+		window time.Duration
+	}
+)
+
+// NewTokenBucket returns a limiter that implements the token bucket algorithm. Each client starts
+// with a bucket of limit tokens, each request consumes one token and the bucket is refilled at the
+// rate of limit tokens per period. The limiter thus allows bursts of up to limit requests.
+func NewTokenBucket(store Store, limit int, period time.Duration) Limiter {
+	return &tokenBucket{store: store, limit: limit, period: period}
+}
+
+// NewSlidingWindow returns a limiter that implements the sliding window counter algorithm. The
+// limiter allows up to limit requests per window, the number of requests made in the window that
+// ends at the time of a request is estimated from the counts of the current and previous fixed
+// windows.
+func NewSlidingWindow(store Store, limit int, window time.Duration) Limiter {
+	return &slidingWindow{store: store, limit: limit, window: window}
+}
+
+// Allow consumes a token from the bucket of the client identified by key if there is one left.
+func (tb *tokenBucket) Allow(key string, now time.Time) (*Result, error) {
+	limit := float64(tb.limit)
+	rate := limit / tb.period.Seconds() // tokens per second
+	var allowed bool
+	state, err := tb.store.Update(key, tb.period, func(s State, ok bool) State {
+		if !ok {
+			s = State{Count: limit, Time: now}
+		}
+		if elapsed := now.Sub(s.Time).Seconds(); elapsed > 0 {
+			s.Count = math.Min(limit, s.Count+elapsed*rate)
+			s.Time = now
+		}
+		allowed = s.Count >= 1
+		if allowed {
+			s.Count--
+		}
+		return s
+	})
+	if err != nil {
+		return nil, err
+	}
+	res := &Result{
+		Allowed:   allowed,
+		Limit:     tb.limit,
+		Remaining: int(math.Floor(state.Count)),
+		Reset:     secondsDuration((limit - state.Count) / rate),
+	}
+	if !allowed {
+		res.RetryAfter = secondsDuration((1 - state.Count) / rate)
+	}
+	return res, nil
+}
+
+// Allow counts the request in the current window of the client identified by key if the estimated
+// number of requests made in the sliding window is below the limit.
+func (sw *slidingWindow) Allow(key string, now time.Time) (*Result, error) {
+	limit := float64(sw.limit)
+	start := now.Truncate(sw.window)
+	var allowed bool
+	var estimate float64
+	state, err := sw.store.Update(key, 2*sw.window, func(s State, ok bool) State {
+		if !ok || !s.Time.Equal(start) {
+			previous := 0.0
+			if ok && s.Time.Equal(start.Add(-sw.window)) {
+				previous = s.Count
+			}
+			s = State{Previous: previous, Time: start}
+		}
+		estimate = s.Previous*sw.previousWeight(now, start) + s.Count
+		allowed = estimate+1 <= limit
+		if allowed {
+			s.Count++
+			estimate++
+		}
+		return s
+	})
+	if err != nil {
+		return nil, err
+	}
+	end := start.Add(sw.window)
+	res := &Result{
+		Allowed:   allowed,
+		Limit:     sw.limit,
+		Remaining: int(math.Max(0, math.Floor(limit-estimate))),
+		Reset:     end.Sub(now),
+	}
+	if !allowed {
+		res.RetryAfter = sw.retryAfter(state, now, start)
+	}
+	return res, nil
+}
+
+// previousWeight returns the portion of the previous window covered by the sliding window ending
+// at now.
+func (sw *slidingWindow) previousWeight(now, start time.Time) float64 {
+	return 1 - float64(now.Sub(start))/float64(sw.window)
+}
+
+// retryAfter computes the duration until the estimated number of requests made in the sliding
+// window drops below the limit.
+func (sw *slidingWindow) retryAfter(s State, now, start time.Time) time.Duration {
+	limit := float64(sw.limit)
+	if s.Count+1 > limit {
+		// The current window is full, wait until the weight of its count in the next
+		// window is low enough.
+		if s.Count == 0 {
+			return start.Add(sw.window).Sub(now)
+		}
+		x := 1 - (limit-1)/s.Count
+		return start.Add(sw.window).Add(time.Duration(x * float64(sw.window))).Sub(now)
+	}
+	// Wait until the weight of the previous window count is low enough.
+	x := 1 - (limit-s.Count-1)/s.Previous
+	return start.Add(time.Duration(x * float64(sw.window))).Sub(now)
+}
+
+// secondsDuration converts a number of seconds into a duration.
+func secondsDuration(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,137 @@
+package ratelimit_test
+
+import (
+	"time"
+
+	"github.com/goadesign/goa/middleware/ratelimit"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TokenBucket", func() {
+	var limiter ratelimit.Limiter
+	var now time.Time
+
+	BeforeEach(func() {
+		limiter = ratelimit.NewTokenBucket(ratelimit.NewMemoryStore(), 3, 3*time.Second)
+		now = time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	})
+
+	allow := func(key string) *ratelimit.Result {
+		res, err := limiter.Allow(key, now)
+		Ω(err).ShouldNot(HaveOccurred())
+		return res
+	}
+
+	It("allows bursts up to the limit", func() {
+		for i := 2; i >= 0; i-- {
+			res := allow("a")
+			Ω(res.Allowed).Should(BeTrue())
+			Ω(res.Limit).Should(Equal(3))
+			Ω(res.Remaining).Should(Equal(i))
+		}
+		res := allow("a")
+		Ω(res.Allowed).Should(BeFalse())
+		Ω(res.Remaining).Should(Equal(0))
+		Ω(res.RetryAfter).Should(Equal(time.Second))
+		Ω(res.Reset).Should(Equal(3 * time.Second))
+	})
+
+	It("refills the bucket over time", func() {
+		for i := 0; i < 3; i++ {
+			allow("a")
+		}
+		Ω(allow("a").Allowed).Should(BeFalse())
+		now = now.Add(time.Second)
+		Ω(allow("a").Allowed).Should(BeTrue())
+		Ω(allow("a").Allowed).Should(BeFalse())
+	})
+
+	It("keeps a bucket per key", func() {
+		for i := 0; i < 3; i++ {
+			allow("a")
+		}
+		Ω(allow("a").Allowed).Should(BeFalse())
+		Ω(allow("b").Allowed).Should(BeTrue())
+	})
+})
+
+var _ = Describe("SlidingWindow", func() {
+	var limiter ratelimit.Limiter
+	var now time.Time
+
+	BeforeEach(func() {
+		limiter = ratelimit.NewSlidingWindow(ratelimit.NewMemoryStore(), 4, time.Minute)
+		now = time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	})
+
+	allow := func(key string) *ratelimit.Result {
+		res, err := limiter.Allow(key, now)
+		Ω(err).ShouldNot(HaveOccurred())
+		return res
+	}
+
+	It("allows up to limit requests per window", func() {
+		for i := 3; i >= 0; i-- {
+			res := allow("a")
+			Ω(res.Allowed).Should(BeTrue())
+			Ω(res.Remaining).Should(Equal(i))
+			Ω(res.Reset).Should(Equal(time.Minute))
+		}
+		res := allow("a")
+		Ω(res.Allowed).Should(BeFalse())
+		Ω(res.RetryAfter).Should(Equal(time.Minute + 15*time.Second))
+	})
+
+	It("weighs the previous window", func() {
+		for i := 0; i < 4; i++ {
+			allow("a")
+		}
+		// Half way through the next window the estimate is 4*0.5 = 2 requests.
+		now = now.Add(90 * time.Second)
+		Ω(allow("a").Allowed).Should(BeTrue())
+		Ω(allow("a").Allowed).Should(BeTrue())
+		res := allow("a")
+		Ω(res.Allowed).Should(BeFalse())
+		Ω(res.Reset).Should(Equal(30 * time.Second))
+		// The estimate drops to 4*0.25 + 2 = 3 requests 15 seconds later.
+		Ω(res.RetryAfter).Should(Equal(15 * time.Second))
+	})
+
+	It("forgets windows older than the previous one", func() {
+		for i := 0; i < 4; i++ {
+			allow("a")
+		}
+		now = now.Add(2 * time.Minute)
+		Ω(allow("a").Remaining).Should(Equal(3))
+	})
+})
+
+var _ = Describe("MemoryStore", func() {
+	var store *ratelimit.MemoryStore
+
+	BeforeEach(func() {
+		store = ratelimit.NewMemoryStore()
+		store.MaxKeys = 2
+	})
+
+	update := func(key string) (bool, error) {
+		var found bool
+		_, err := store.Update(key, time.Minute, func(s ratelimit.State, ok bool) ratelimit.State {
+			found = ok
+			s.Count++
+			return s
+		})
+		return found, err
+	}
+
+	It("evicts the least recently updated states", func() {
+		update("a")
+		update("b")
+		update("a")
+		update("c")
+		Ω(store.Len()).Should(Equal(2))
+		Ω(update("a")).Should(BeTrue())
+		Ω(update("b")).Should(BeFalse())
+	})
+})
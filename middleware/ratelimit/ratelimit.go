@@ -0,0 +1,115 @@
+/*
+Package ratelimit provides a middleware that throttles requests per client. Clients are identified
+by a key computed from each request, e.g. the remote address, an API key or the subject of a JWT.
+The middleware supports the token bucket and sliding window algorithms, both keep their state in a
+Store so that limits may be shared by multiple service instances. MemoryStore keeps the state in
+process memory and evicts the least recently used keys once it holds MaxKeys states.
+
+The middleware sets the RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers on all
+responses and the Retry-After header on the responses of the requests that exceed the limit. These
+requests fail with ErrTooManyRequests:
+
+	store := ratelimit.NewMemoryStore()
+	limiter := ratelimit.NewTokenBucket(store, 100, time.Minute)
+	service.Use(ratelimit.New(limiter, ratelimit.RemoteAddr))
+
+Limits keyed by the API key or the authenticated user (see User) must be enforced once the
+security middleware has validated the request: the key functions return an empty key for the
+requests that do not carry a validated identity. Limits keyed by the JWT subject must be enforced
+once the token has been validated, the middleware is then given to the jwt security middleware as
+validation function:
+
+	throttle := ratelimit.New(limiter, ratelimit.JWTSubject)
+	app.UseJWTMiddleware(service, jwt.New(key, throttle, app.NewJWTSecurity()))
+*/
+package ratelimit
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/goadesign/goa"
+	"golang.org/x/net/context"
+)
+
+// Names of the headers set by the middleware.
+const (
+	// LimitHeader is the name of the header that contains the request quota.
+	LimitHeader = "RateLimit-Limit"
+	// RemainingHeader is the name of the header that contains the number of requests left in
+	// the quota.
+	RemainingHeader = "RateLimit-Remaining"
+	// ResetHeader is the name of the header that contains the number of seconds until the quota
+	// resets.
+	ResetHeader = "RateLimit-Reset"
+	// RetryAfterHeader is the name of the header that contains the number of seconds a client
+	// should wait before retrying a request that exceeded the limit.
+	RetryAfterHeader = "Retry-After"
+)
+
+// ErrTooManyRequests is the error returned by the middleware for requests that exceed the limit.
+var ErrTooManyRequests = goa.NewErrorClass("too_many_requests", 429)
+
+type (
+	// Limiter implements a rate limiting algorithm.
+	Limiter interface {
+		// Allow records a request made at the given time by the client identified by key
+		// and reports whether it is allowed.
+		Allow(key string, now time.Time) (*Result, error)
+	}
+
+	// Result describes the outcome of a call to Allow.
+	Result struct {
+		// Allowed is true if the request is allowed.
+		Allowed bool
+		// Limit is the maximum number of requests allowed per period.
+		Limit int
+		// Remaining is the number of requests the client may still make.
+		Remaining int
+		// Reset is the duration until the quota is fully restored.
+		Reset time.Duration
+		// RetryAfter is the duration until the next request is allowed, zero if the request
+		// is allowed.
+		RetryAfter time.Duration
+	}
+
+	// KeyFunc computes the key that identifies the client making a request. Requests for
+	// which the key is empty are not limited.
+	KeyFunc func(ctx context.Context, req *http.Request) string
+)
+
+// New returns a middleware that limits the requests using limiter. The requests are grouped by the
+// keys computed by key. The middleware lets requests through and logs an error if the limiter
+// fails, e.g. because a shared store is unreachable.
+func New(limiter Limiter, key KeyFunc) goa.Middleware {
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			k := key(ctx, req)
+			if k == "" {
+				return h(ctx, rw, req)
+			}
+			res, err := limiter.Allow(k, time.Now())
+			if err != nil {
+				goa.LogError(ctx, "rate limiter failed", "err", err)
+				return h(ctx, rw, req)
+			}
+			header := rw.Header()
+			header.Set(LimitHeader, strconv.Itoa(res.Limit))
+			header.Set(RemainingHeader, strconv.Itoa(res.Remaining))
+			header.Set(ResetHeader, seconds(res.Reset))
+			if !res.Allowed {
+				retry := seconds(res.RetryAfter)
+				header.Set(RetryAfterHeader, retry)
+				return ErrTooManyRequests("rate limit exceeded").Meta("retry_after", retry)
+			}
+			return h(ctx, rw, req)
+		}
+	}
+}
+
+// seconds renders d as a number of seconds rounded up.
+func seconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
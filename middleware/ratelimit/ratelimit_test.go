@@ -0,0 +1,157 @@
+package ratelimit_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	jwtgo "github.com/dgrijalva/jwt-go"
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/middleware"
+	"github.com/goadesign/goa/middleware/ratelimit"
+	"github.com/goadesign/goa/middleware/security/jwt"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("New", func() {
+	var service *goa.Service
+	var limiter ratelimit.Limiter
+	var key ratelimit.KeyFunc
+	var handler goa.Handler
+	var called int
+
+	BeforeEach(func() {
+		service = goa.New("test")
+		service.Encoder.Register(goa.NewJSONEncoder, "*/*")
+		limiter = ratelimit.NewTokenBucket(ratelimit.NewMemoryStore(), 2, time.Minute)
+		key = ratelimit.RemoteAddr
+		called = 0
+		handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			called++
+			return service.Send(ctx, 200, "ok")
+		}
+	})
+
+	serve := func(remoteAddr string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.RemoteAddr = remoteAddr
+		rw := httptest.NewRecorder()
+		ctx := goa.NewContext(service.Context, rw, req, nil)
+		h := middleware.ErrorHandler(service, false)(ratelimit.New(limiter, key)(handler))
+		Ω(h(ctx, rw, req)).ShouldNot(HaveOccurred())
+		return rw
+	}
+
+	It("sets the rate limit headers", func() {
+		rw := serve("10.0.0.1:4242")
+		Ω(rw.Code).Should(Equal(200))
+		Ω(rw.Header().Get(ratelimit.LimitHeader)).Should(Equal("2"))
+		Ω(rw.Header().Get(ratelimit.RemainingHeader)).Should(Equal("1"))
+		Ω(rw.Header().Get(ratelimit.ResetHeader)).ShouldNot(BeEmpty())
+		Ω(rw.Header().Get(ratelimit.RetryAfterHeader)).Should(BeEmpty())
+	})
+
+	It("rejects the requests that exceed the limit", func() {
+		serve("10.0.0.1:4242")
+		serve("10.0.0.1:4243")
+		rw := serve("10.0.0.1:4244")
+		Ω(called).Should(Equal(2))
+		Ω(rw.Code).Should(Equal(429))
+		Ω(rw.Header().Get(ratelimit.RemainingHeader)).Should(Equal("0"))
+		Ω(rw.Header().Get(ratelimit.RetryAfterHeader)).Should(Equal("30"))
+		Ω(rw.Body.String()).Should(ContainSubstring(`"code":"too_many_requests"`))
+		Ω(serve("10.0.0.2:4242").Code).Should(Equal(200))
+	})
+
+	Context("with a key function that returns an empty key", func() {
+		BeforeEach(func() {
+			key = ratelimit.APIKey(goa.LocHeader, "X-API-Key")
+		})
+
+		It("does not limit the requests", func() {
+			for i := 0; i < 3; i++ {
+				rw := serve("10.0.0.1:4242")
+				Ω(rw.Code).Should(Equal(200))
+				Ω(rw.Header().Get(ratelimit.LimitHeader)).Should(BeEmpty())
+			}
+		})
+	})
+
+	Context("with a failing limiter", func() {
+		BeforeEach(func() {
+			limiter = failingLimiter{}
+		})
+
+		It("lets the requests through", func() {
+			Ω(serve("10.0.0.1:4242").Code).Should(Equal(200))
+			Ω(called).Should(Equal(1))
+		})
+	})
+})
+
+var _ = Describe("key functions", func() {
+	var req *http.Request
+	var ctx context.Context
+
+	BeforeEach(func() {
+		req, _ = http.NewRequest("GET", "/?key=query", nil)
+		req.RemoteAddr = "10.0.0.1:4242"
+		req.Header.Set("X-API-Key", "header")
+		ctx = goa.WithUser(context.Background(), "alice")
+	})
+
+	It("computes keys from the remote address", func() {
+		Ω(ratelimit.RemoteAddr(ctx, req)).Should(Equal("10.0.0.1"))
+	})
+
+	It("computes keys from API keys", func() {
+		Ω(ratelimit.APIKey(goa.LocHeader, "X-API-Key")(ctx, req)).Should(Equal("header"))
+		Ω(ratelimit.APIKey(goa.LocQuery, "key")(ctx, req)).Should(Equal("query"))
+	})
+
+	It("ignores the API keys of unauthenticated requests", func() {
+		ctx = context.Background()
+		Ω(ratelimit.APIKey(goa.LocHeader, "X-API-Key")(ctx, req)).Should(BeEmpty())
+	})
+
+	It("computes keys from the authenticated user", func() {
+		Ω(ratelimit.User(ctx, req)).Should(Equal("alice"))
+		Ω(ratelimit.User(context.Background(), req)).Should(BeEmpty())
+	})
+
+	It("computes keys from the JWT subject", func() {
+		Ω(ratelimit.JWTSubject(context.Background(), req)).Should(BeEmpty())
+		token := jwtgo.NewWithClaims(jwtgo.SigningMethodHS256, jwtgo.MapClaims{"sub": "alice"})
+		signed, err := token.SignedString([]byte("secret"))
+		Ω(err).ShouldNot(HaveOccurred())
+		req.Header.Set("Authorization", "Bearer "+signed)
+		var sub string
+		validation := func(h goa.Handler) goa.Handler {
+			return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+				sub = ratelimit.JWTSubject(ctx, req)
+				return nil
+			}
+		}
+		scheme := &goa.JWTSecurity{In: goa.LocHeader, Name: "Authorization"}
+		h := jwt.New("secret", validation, scheme)(nil)
+		Ω(h(context.Background(), httptest.NewRecorder(), req)).ShouldNot(HaveOccurred())
+		Ω(sub).Should(Equal("alice"))
+	})
+
+	It("falls back to the next key function", func() {
+		key := ratelimit.Fallback(ratelimit.APIKey(goa.LocHeader, "X-Other"), ratelimit.RemoteAddr)
+		Ω(key(ctx, req)).Should(Equal("1:10.0.0.1"))
+		key = ratelimit.Fallback(ratelimit.APIKey(goa.LocHeader, "X-API-Key"), ratelimit.RemoteAddr)
+		Ω(key(ctx, req)).Should(Equal("0:header"))
+	})
+})
+
+// failingLimiter is a limiter whose store is unavailable.
+type failingLimiter struct{}
+
+func (failingLimiter) Allow(string, time.Time) (*ratelimit.Result, error) {
+	return nil, errors.New("store unavailable")
+}
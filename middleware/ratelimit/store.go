@@ -0,0 +1,116 @@
+package ratelimit
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+type (
+	// Store keeps the state of the limiters for each key. Implementations backed by shared
+	// storage make it possible to enforce limits across multiple service instances.
+	Store interface {
+		// Update atomically replaces the state stored under key with the state returned
+		// by fn and returns it. fn is given the current state and false if there is none.
+		// The stored state expires after ttl. Implementations that rely on optimistic
+		// concurrency control may call fn more than once.
+		Update(key string, ttl time.Duration, fn func(current State, ok bool) State) (State, error)
+	}
+
+	// State is the state of a limiter for a given key.
+	State struct {
+		// Count is the number of tokens left in the bucket for the token bucket algorithm
+		// or the number of requests made in the current window for the sliding window
+		// algorithm.
+		Count float64
+		// Previous is the number of requests made in the previous window for the sliding
+		// window algorithm.
+		Previous float64
+		// Time is the time of the last refill for the token bucket algorithm or the start
+		// of the current window for the sliding window algorithm.
+		Time time.Time
+	}
+
+	// MemoryStore is a Store that keeps the states in memory. The store holds at most MaxKeys
+	// states, the least recently updated states are evicted when it is full so that the memory
+	// used by the store stays bounded whatever the number of clients.
+	MemoryStore struct {
+		// MaxKeys is the maximum number of states held by the store, 0 means no limit.
+		MaxKeys int
+
+		mu        sync.Mutex
+		entries   map[string]*list.Element
+		lru       *list.List // Entries ordered from the most to the least recently updated
+		lastSweep time.Time
+	}
+
+	// memoryEntry is a state stored in a MemoryStore.
+	memoryEntry struct {
+		key     string
+		state   State
+		expires time.Time
+	}
+)
+
+// DefaultMaxKeys is the MaxKeys of the stores created with NewMemoryStore.
+const DefaultMaxKeys = 100000
+
+// sweepInterval is the minimum duration between two removals of the expired MemoryStore states.
+const sweepInterval = time.Minute
+
+// NewMemoryStore returns a store that keeps up to DefaultMaxKeys states in memory. Expired states
+// are removed periodically as the store is updated.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{MaxKeys: DefaultMaxKeys, lastSweep: time.Now()}
+}
+
+// Update replaces the state stored under key with the state returned by fn.
+func (s *MemoryStore) Update(key string, ttl time.Duration, fn func(State, bool) State) (State, error) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.entries == nil {
+		s.entries = make(map[string]*list.Element)
+		s.lru = list.New()
+	}
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		for k, el := range s.entries {
+			if !now.Before(el.Value.(*memoryEntry).expires) {
+				s.lru.Remove(el)
+				delete(s.entries, k)
+			}
+		}
+		s.lastSweep = now
+	}
+	var current State
+	el, ok := s.entries[key]
+	if ok && now.Before(el.Value.(*memoryEntry).expires) {
+		current = el.Value.(*memoryEntry).state
+	} else {
+		ok = false
+	}
+	state := fn(current, ok)
+	if el != nil {
+		e := el.Value.(*memoryEntry)
+		e.state, e.expires = state, now.Add(ttl)
+		s.lru.MoveToFront(el)
+		return state, nil
+	}
+	if s.MaxKeys > 0 {
+		for len(s.entries) >= s.MaxKeys {
+			oldest := s.lru.Back()
+			s.lru.Remove(oldest)
+			delete(s.entries, oldest.Value.(*memoryEntry).key)
+		}
+	}
+	s.entries[key] = s.lru.PushFront(&memoryEntry{key: key, state: state, expires: now.Add(ttl)})
+	return state, nil
+}
+
+// Len returns the number of states held by the store including the expired states that have not
+// been removed yet.
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
@@ -0,0 +1,147 @@
+/*
+Package secureheaders provides a middleware that sets the response headers commonly used to harden
+web services: Strict-Transport-Security, Content-Security-Policy, X-Content-Type-Options,
+X-Frame-Options, Referrer-Policy and Permissions-Policy. The header values are given by a Config,
+Default provides values suitable for APIs:
+
+	service.Use(secureheaders.New(secureheaders.Default))
+
+The middleware mounted on a controller replaces the headers set by the middleware mounted on the
+service so that controllers may use a different configuration:
+
+	cfg := secureheaders.Default
+	cfg.FrameOptions = "SAMEORIGIN"
+	ctrl.Use(secureheaders.New(cfg))
+
+The Content-Security-Policy, X-Frame-Options, Referrer-Policy and Permissions-Policy headers
+recommended for APIs prevent browsers from rendering web pages properly. The middleware thus
+applies the relaxed configuration returned by Config.Relaxed to the requests handled by the file
+servers mounted with the service ServeFiles method or generated from the Files DSL, see
+goa.ContextFileServer. The relaxed Content-Security-Policy only lets pages load the scripts served
+by the service, pages that rely on inline scripts such as Swagger UI require a custom file server
+configuration:
+
+	cfg := secureheaders.Default
+	files := cfg.Relaxed()
+	files.ContentSecurityPolicy = "default-src 'self'; script-src 'self' 'unsafe-inline'; style-src 'self' 'unsafe-inline'; img-src 'self' data:"
+	cfg.Files = &files
+	service.Use(secureheaders.New(cfg))
+*/
+package secureheaders
+
+import (
+	"net/http"
+
+	"github.com/goadesign/goa"
+	"golang.org/x/net/context"
+)
+
+// Names of the headers set by the middleware.
+const (
+	// StrictTransportSecurityHeader is the name of the header that tells browsers to only use
+	// HTTPS to reach the service.
+	StrictTransportSecurityHeader = "Strict-Transport-Security"
+	// ContentSecurityPolicyHeader is the name of the header that restricts the resources
+	// browsers may load for the response.
+	ContentSecurityPolicyHeader = "Content-Security-Policy"
+	// ContentTypeOptionsHeader is the name of the header that prevents browsers from guessing
+	// the response content type.
+	ContentTypeOptionsHeader = "X-Content-Type-Options"
+	// FrameOptionsHeader is the name of the header that controls whether browsers may render the
+	// response in a frame.
+	FrameOptionsHeader = "X-Frame-Options"
+	// ReferrerPolicyHeader is the name of the header that controls the referrer information sent
+	// by browsers when following links.
+	ReferrerPolicyHeader = "Referrer-Policy"
+	// PermissionsPolicyHeader is the name of the header that controls the browser features the
+	// response may use.
+	PermissionsPolicyHeader = "Permissions-Policy"
+)
+
+// Config contains the values of the headers set by the middleware. The middleware removes the
+// headers whose value is empty.
+type Config struct {
+	// StrictTransportSecurity is the value of the Strict-Transport-Security header, e.g.
+	// "max-age=31536000; includeSubDomains".
+	StrictTransportSecurity string
+	// ContentSecurityPolicy is the value of the Content-Security-Policy header, e.g.
+	// "default-src 'self'".
+	ContentSecurityPolicy string
+	// ContentTypeOptions is the value of the X-Content-Type-Options header, "nosniff" is the
+	// only value defined.
+	ContentTypeOptions string
+	// FrameOptions is the value of the X-Frame-Options header, "DENY" or "SAMEORIGIN".
+	FrameOptions string
+	// ReferrerPolicy is the value of the Referrer-Policy header, e.g. "no-referrer".
+	ReferrerPolicy string
+	// PermissionsPolicy is the value of the Permissions-Policy header, e.g. "camera=()".
+	PermissionsPolicy string
+	// Files is the configuration applied to the requests handled by file servers, the
+	// configuration returned by Relaxed is applied if nil.
+	Files *Config
+}
+
+// Default is a configuration suitable for APIs. It forbids browsers from loading any resource
+// referenced by the responses, from rendering them in frames and from sending referrer information.
+var Default = Config{
+	StrictTransportSecurity: "max-age=31536000; includeSubDomains",
+	ContentSecurityPolicy:   "default-src 'none'; frame-ancestors 'none'",
+	ContentTypeOptions:      "nosniff",
+	FrameOptions:            "DENY",
+	ReferrerPolicy:          "no-referrer",
+	PermissionsPolicy:       "camera=(), geolocation=(), microphone=(), payment=()",
+}
+
+// New returns a middleware that sets the headers described by cfg before running the handler so
+// that the handler may override them. The middleware applies cfg.Files or cfg.Relaxed() if nil to
+// the requests handled by file servers.
+func New(cfg Config) goa.Middleware {
+	files := cfg.Relaxed()
+	if cfg.Files != nil {
+		files = *cfg.Files
+	}
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			c := cfg
+			if goa.ContextFileServer(ctx) {
+				c = files
+			}
+			header := rw.Header()
+			set(header, StrictTransportSecurityHeader, c.StrictTransportSecurity)
+			set(header, ContentSecurityPolicyHeader, c.ContentSecurityPolicy)
+			set(header, ContentTypeOptionsHeader, c.ContentTypeOptions)
+			set(header, FrameOptionsHeader, c.FrameOptions)
+			set(header, ReferrerPolicyHeader, c.ReferrerPolicy)
+			set(header, PermissionsPolicyHeader, c.PermissionsPolicy)
+			return h(ctx, rw, req)
+		}
+	}
+}
+
+// Relaxed returns a copy of c suitable for serving web pages and their assets. The copy lets
+// browsers load the resources served by the service, render the pages in frames of the same
+// origin and send the origin as referrer to other sites. Relaxed only changes the headers that c
+// sets, it keeps the Strict-Transport-Security and X-Content-Type-Options values of c and removes
+// the restrictions on browser features. The relaxed Content-Security-Policy blocks inline scripts.
+func (c Config) Relaxed() Config {
+	if c.ContentSecurityPolicy != "" {
+		c.ContentSecurityPolicy = "default-src 'self'; img-src 'self' data:; style-src 'self' 'unsafe-inline'; frame-ancestors 'self'"
+	}
+	if c.FrameOptions != "" {
+		c.FrameOptions = "SAMEORIGIN"
+	}
+	if c.ReferrerPolicy != "" {
+		c.ReferrerPolicy = "strict-origin-when-cross-origin"
+	}
+	c.PermissionsPolicy = ""
+	return c
+}
+
+// set sets the header with the given name to value or removes it if value is empty.
+func set(header http.Header, name, value string) {
+	if value == "" {
+		header.Del(name)
+		return
+	}
+	header.Set(name, value)
+}
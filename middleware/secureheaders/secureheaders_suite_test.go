@@ -0,0 +1,13 @@
+package secureheaders_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestSecureHeaders(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "SecureHeaders Suite")
+}
@@ -0,0 +1,144 @@
+package secureheaders_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/middleware/secureheaders"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("New", func() {
+	var service *goa.Service
+	var handler goa.Handler
+
+	BeforeEach(func() {
+		service = goa.New("test")
+		service.WithLogger(nil)
+		service.Encoder.Register(goa.NewJSONEncoder, "*/*")
+		service.Use(secureheaders.New(secureheaders.Default))
+		handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			return service.Send(ctx, 200, "ok")
+		}
+	})
+
+	serve := func(ctrl *goa.Controller) http.Header {
+		service.Mux.Handle("GET", "/", ctrl.MuxHandler("show", handler, nil))
+		req, _ := http.NewRequest("GET", "/", nil)
+		rw := httptest.NewRecorder()
+		service.Mux.ServeHTTP(rw, req)
+		return rw.Header()
+	}
+
+	It("sets the headers", func() {
+		header := serve(service.NewController("test"))
+		Ω(header.Get(secureheaders.StrictTransportSecurityHeader)).Should(Equal(secureheaders.Default.StrictTransportSecurity))
+		Ω(header.Get(secureheaders.ContentSecurityPolicyHeader)).Should(Equal(secureheaders.Default.ContentSecurityPolicy))
+		Ω(header.Get(secureheaders.ContentTypeOptionsHeader)).Should(Equal("nosniff"))
+		Ω(header.Get(secureheaders.FrameOptionsHeader)).Should(Equal("DENY"))
+		Ω(header.Get(secureheaders.ReferrerPolicyHeader)).Should(Equal("no-referrer"))
+		Ω(header.Get(secureheaders.PermissionsPolicyHeader)).Should(Equal(secureheaders.Default.PermissionsPolicy))
+	})
+
+	It("lets the handler override the headers", func() {
+		handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			rw.Header().Set(secureheaders.FrameOptionsHeader, "SAMEORIGIN")
+			return service.Send(ctx, 200, "ok")
+		}
+		header := serve(service.NewController("test"))
+		Ω(header.Get(secureheaders.FrameOptionsHeader)).Should(Equal("SAMEORIGIN"))
+	})
+
+	Context("with a controller configuration", func() {
+		It("replaces the service configuration", func() {
+			cfg := secureheaders.Default
+			cfg.FrameOptions = "SAMEORIGIN"
+			cfg.ContentSecurityPolicy = ""
+			ctrl := service.NewController("test")
+			ctrl.Use(secureheaders.New(cfg))
+			header := serve(ctrl)
+			Ω(header.Get(secureheaders.FrameOptionsHeader)).Should(Equal("SAMEORIGIN"))
+			Ω(header).ShouldNot(HaveKey(secureheaders.ContentSecurityPolicyHeader))
+			Ω(header.Get(secureheaders.ContentTypeOptionsHeader)).Should(Equal("nosniff"))
+		})
+	})
+
+	Context("with files served by ServeFiles", func() {
+		var dir string
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "secureheaders")
+			Ω(err).ShouldNot(HaveOccurred())
+			err = ioutil.WriteFile(filepath.Join(dir, "index.html"), []byte("<html></html>"), 0644)
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		It("applies the relaxed configuration", func() {
+			Ω(service.ServeFiles("/index.html", filepath.Join(dir, "index.html"))).ShouldNot(HaveOccurred())
+			req, _ := http.NewRequest("GET", "/index.html", nil)
+			rw := httptest.NewRecorder()
+			service.Mux.ServeHTTP(rw, req)
+			Ω(rw.Code).Should(Equal(200))
+			relaxed := secureheaders.Default.Relaxed()
+			Ω(rw.Header().Get(secureheaders.ContentSecurityPolicyHeader)).Should(Equal(relaxed.ContentSecurityPolicy))
+			Ω(rw.Header().Get(secureheaders.FrameOptionsHeader)).Should(Equal("SAMEORIGIN"))
+			Ω(rw.Header().Get(secureheaders.StrictTransportSecurityHeader)).Should(Equal(secureheaders.Default.StrictTransportSecurity))
+			Ω(rw.Header()).ShouldNot(HaveKey(secureheaders.PermissionsPolicyHeader))
+		})
+
+		It("applies the relaxed configuration to the file servers of other controllers", func() {
+			ctrl := service.NewController("public")
+			h := ctrl.FileHandler("/index.html", filepath.Join(dir, "index.html"))
+			service.Mux.Handle("GET", "/index.html", ctrl.FileMuxHandler("serve", h))
+			req, _ := http.NewRequest("GET", "/index.html", nil)
+			rw := httptest.NewRecorder()
+			service.Mux.ServeHTTP(rw, req)
+			Ω(rw.Code).Should(Equal(200))
+			Ω(rw.Header().Get(secureheaders.FrameOptionsHeader)).Should(Equal("SAMEORIGIN"))
+		})
+
+		It("applies the file server configuration", func() {
+			cfg := secureheaders.Default
+			files := cfg.Relaxed()
+			files.ContentSecurityPolicy = "default-src 'self'; script-src 'self' 'unsafe-inline'"
+			cfg.Files = &files
+			ctrl := service.NewController("public")
+			ctrl.Use(secureheaders.New(cfg))
+			h := ctrl.FileHandler("/index.html", filepath.Join(dir, "index.html"))
+			service.Mux.Handle("GET", "/index.html", ctrl.FileMuxHandler("serve", h))
+			req, _ := http.NewRequest("GET", "/index.html", nil)
+			rw := httptest.NewRecorder()
+			service.Mux.ServeHTTP(rw, req)
+			Ω(rw.Code).Should(Equal(200))
+			Ω(rw.Header().Get(secureheaders.ContentSecurityPolicyHeader)).Should(Equal(files.ContentSecurityPolicy))
+		})
+	})
+})
+
+var _ = Describe("Relaxed", func() {
+	It("relaxes the policies", func() {
+		relaxed := secureheaders.Default.Relaxed()
+		Ω(relaxed.ContentSecurityPolicy).Should(ContainSubstring("default-src 'self'"))
+		Ω(relaxed.FrameOptions).Should(Equal("SAMEORIGIN"))
+		Ω(relaxed.ReferrerPolicy).Should(Equal("strict-origin-when-cross-origin"))
+		Ω(relaxed.PermissionsPolicy).Should(BeEmpty())
+		Ω(relaxed.StrictTransportSecurity).Should(Equal(secureheaders.Default.StrictTransportSecurity))
+		Ω(relaxed.ContentTypeOptions).Should(Equal("nosniff"))
+	})
+
+	It("does not add the headers that are not set", func() {
+		relaxed := secureheaders.Config{ContentTypeOptions: "nosniff"}.Relaxed()
+		Ω(relaxed).Should(Equal(secureheaders.Config{ContentTypeOptions: "nosniff"}))
+	})
+})
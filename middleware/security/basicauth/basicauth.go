@@ -18,14 +18,16 @@ var ErrBasicAuthFailed = goa.NewErrorClass("basic_auth_failed", 401)
 // It doesn't get simpler than that.
 //
 // If you want to handle the username and password checks dynamically,
-// copy the source of `New`, it's 8 lines and you can tweak at will.
+// copy the source of `New`, it's 12 lines and you can tweak at will.
 func New(username, password string) goa.Middleware {
-	middleware, _ := goa.NewMiddleware(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
-		u, p, ok := r.BasicAuth()
-		if !ok || u != username || p != password {
-			return ErrBasicAuthFailed("Authentication failed")
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			u, p, ok := r.BasicAuth()
+			if !ok || u != username || p != password {
+				return ErrBasicAuthFailed("Authentication failed")
+			}
+			ctx = goa.WithUser(ctx, u)
+			return h(ctx, w, r)
 		}
-		return nil
-	})
-	return middleware
+	}
 }
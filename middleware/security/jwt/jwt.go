@@ -120,6 +120,11 @@ func New(validationKeys interface{}, validationFunc goa.Middleware, scheme *goa.
 			}
 
 			ctx = context.WithValue(ctx, jwtKey, token)
+			if claims, ok := token.Claims.(jwt.MapClaims); ok {
+				if sub, ok := claims["sub"].(string); ok {
+					ctx = goa.WithUser(ctx, sub)
+				}
+			}
 			if validationFunc != nil {
 				nextHandler = validationFunc(nextHandler)
 			}
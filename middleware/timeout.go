@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/goadesign/goa"
@@ -9,6 +10,20 @@ import (
 	"golang.org/x/net/context"
 )
 
+// ErrTimeout is the error written by StrictTimeout when a request times out.
+var ErrTimeout = goa.NewErrorClass("timeout", http.StatusServiceUnavailable)
+
+// timeoutWriter is the response writer given to the handlers run by StrictTimeout. It discards
+// the writes made after the request timed out.
+type timeoutWriter struct {
+	mu          sync.Mutex
+	w           http.ResponseWriter
+	header      http.Header
+	status      int
+	wroteHeader bool
+	timedOut    bool
+}
+
 // Timeout sets a global timeout for all controller actions.
 // The timeout notification is made through the context, it is the responsability of the request
 // handler to handle it. For example:
@@ -47,3 +62,145 @@ func Timeout(timeout time.Duration) goa.Middleware {
 		}
 	}
 }
+
+// StrictTimeout is a variant of Timeout that does not rely on the request handler to handle the
+// timeout notification. StrictTimeout runs the handler in a separate goroutine and writes a 503
+// response with an ErrTimeout error in the body if the handler has not returned once the timeout
+// elapses. The handler keeps running in the background, its context is canceled and the writes it
+// makes to the response from then on fail with http.ErrHandlerTimeout. If the handler had already
+// started writing the response the response is left truncated. The service Shutdown method keeps
+// waiting for the handler and the temporary files of multipart bodies are kept until it returns,
+// see goa.HoldRequest. The timeouts are logged together with the names of the controller and
+// action.
+//
+// The timeout of actions whose design defines the "timeout" metadata overrides the timeout given
+// to StrictTimeout, see goa.ContextActionTimeout. A zero or negative timeout disables the
+// middleware, actions that stream their responses thus define a "0" timeout to opt out of the
+// timeout given to StrictTimeout.
+//
+// Panics raised by the handler before the timeout elapses are raised again by StrictTimeout so
+// that the Recover middleware may handle them.
+func StrictTimeout(service *goa.Service, timeout time.Duration) goa.Middleware {
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			d := timeout
+			if t, ok := goa.ContextActionTimeout(ctx); ok {
+				d = t
+			}
+			if d <= 0 {
+				return h(ctx, rw, req)
+			}
+			nctx, cancel := context.WithTimeout(ctx, d)
+			defer cancel()
+
+			resp := goa.ContextResponse(ctx)
+			tw := &timeoutWriter{w: resp.ResponseWriter, header: make(http.Header)}
+			copyHeader(tw.header, resp.Header())
+			hresp := *resp
+			hresp.ResponseWriter = tw
+			nctx = goa.WithResponseData(nctx, &hresp)
+
+			// The handler may outlive the request, keep its resources until it returns.
+			release := goa.HoldRequest(ctx)
+			done := make(chan error, 1)
+			panicked := make(chan interface{}, 1)
+			go func() {
+				defer release()
+				defer func() {
+					if p := recover(); p != nil {
+						panicked <- p
+					}
+				}()
+				done <- h(nctx, &hresp, req)
+			}()
+
+			select {
+			case p := <-panicked:
+				panic(p)
+			case err := <-done:
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				if !tw.wroteHeader {
+					copyHeader(resp.Header(), tw.header)
+				}
+				resp.Status, resp.Length, resp.ErrorCode = hresp.Status, hresp.Length, hresp.ErrorCode
+				return err
+			case <-nctx.Done():
+				tw.mu.Lock()
+				tw.timedOut = true
+				wroteHeader, status := tw.wroteHeader, tw.status
+				tw.mu.Unlock()
+				goa.LogError(ctx, "request timed out", "ctrl", goa.ContextController(ctx),
+					"action", goa.ContextAction(ctx), "timeout", d.String())
+				if wroteHeader {
+					resp.Status = status
+					return nil
+				}
+				err := ErrTimeout("request timed out after %s", d)
+				resp.ErrorCode = err.Code
+				return service.Send(ctx, err.Status, err)
+			}
+		}
+	}
+}
+
+// copyHeader replaces the content of dst with a copy of src.
+func copyHeader(dst, src http.Header) {
+	for k := range dst {
+		if _, ok := src[k]; !ok {
+			delete(dst, k)
+		}
+	}
+	for k, v := range src {
+		dst[k] = append([]string(nil), v...)
+	}
+}
+
+// Header returns the header map of the handler response. The map is copied to the response when
+// the header is written.
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+// WriteHeader writes the header unless the request timed out.
+func (tw *timeoutWriter) WriteHeader(status int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+	tw.writeHeader(status)
+}
+
+// Write writes b unless the request timed out in which case it returns http.ErrHandlerTimeout.
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !tw.wroteHeader {
+		tw.writeHeader(http.StatusOK)
+	}
+	return tw.w.Write(b)
+}
+
+// Flush flushes the underlying writer unless the request timed out.
+func (tw *timeoutWriter) Flush() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return
+	}
+	if f, ok := tw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// writeHeader copies the header map to the response and writes the header, tw.mu must be held.
+func (tw *timeoutWriter) writeHeader(status int) {
+	copyHeader(tw.w.Header(), tw.header)
+	tw.status = status
+	tw.wroteHeader = true
+	tw.w.WriteHeader(status)
+}
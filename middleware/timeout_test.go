@@ -7,6 +7,7 @@ import (
 
 	"golang.org/x/net/context"
 
+	"github.com/goadesign/goa"
 	"github.com/goadesign/goa/middleware"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -32,3 +33,196 @@ var _ = Describe("Timeout", func() {
 		Ω(ok).Should(BeTrue())
 	})
 })
+
+var _ = Describe("StrictTimeout", func() {
+	var logger *testLogger
+	var service *goa.Service
+	var req *http.Request
+	var rw *testResponseWriter
+	var ctx context.Context
+	var handler goa.Handler
+	var timeout time.Duration
+	var recoverPanics bool
+	var err error
+
+	BeforeEach(func() {
+		logger = new(testLogger)
+		service = newService(logger)
+		req, _ = http.NewRequest("GET", "/goo", nil)
+		rw = newTestResponseWriter()
+		ctx = goa.WithAction(newContext(service, rw, req, nil), "show")
+		timeout = 10 * time.Millisecond
+		recoverPanics = false
+	})
+
+	JustBeforeEach(func() {
+		h := middleware.StrictTimeout(service, timeout)(handler)
+		if recoverPanics {
+			h = middleware.Recover()(h)
+		}
+		err = h(ctx, rw, req)
+	})
+
+	Context("with a handler that returns in time", func() {
+		BeforeEach(func() {
+			handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+				rw.Header().Set("Location", "/goo/1")
+				return service.Send(ctx, 201, "created")
+			}
+		})
+
+		It("writes the handler response", func() {
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(rw.Status).Should(Equal(201))
+			Ω(string(rw.Body)).Should(ContainSubstring("created"))
+			Ω(rw.Header().Get("Location")).Should(Equal("/goo/1"))
+			Ω(goa.ContextResponse(ctx).Status).Should(Equal(201))
+			Ω(goa.ContextResponse(ctx).Length).Should(Equal(len(rw.Body)))
+		})
+	})
+
+	Context("with a handler that fails without writing the response", func() {
+		BeforeEach(func() {
+			handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+				rw.Header().Set("Retry-After", "1")
+				return goa.ErrBadRequest("boom")
+			}
+		})
+
+		It("returns the error", func() {
+			Ω(err).Should(HaveOccurred())
+			Ω(rw.Status).Should(Equal(0))
+			Ω(rw.Header().Get("Retry-After")).Should(Equal("1"))
+		})
+	})
+
+	Context("with a handler that does not return in time", func() {
+		var release chan struct{}
+		var late chan error
+
+		BeforeEach(func() {
+			// Use local channels, the handler outlives the spec.
+			rel, lt := make(chan struct{}), make(chan error, 1)
+			release, late = rel, lt
+			handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+				<-rel
+				rw.WriteHeader(200)
+				_, err := rw.Write([]byte("late"))
+				lt <- err
+				return nil
+			}
+		})
+
+		It("writes a 503 response", func() {
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(rw.Status).Should(Equal(503))
+			Ω(string(rw.Body)).Should(ContainSubstring("timeout"))
+			Ω(goa.ContextResponse(ctx).ErrorCode).Should(Equal("timeout"))
+			close(release)
+		})
+
+		It("discards the late writes", func() {
+			close(release)
+			Ω(<-late).Should(Equal(http.ErrHandlerTimeout))
+			Ω(rw.Status).Should(Equal(503))
+			Ω(string(rw.Body)).ShouldNot(ContainSubstring("late"))
+		})
+
+		It("logs the controller and action", func() {
+			close(release)
+			Ω(logger.ErrorEntries).Should(HaveLen(1))
+			Ω(logger.ErrorEntries[0].Msg).Should(Equal("request timed out"))
+			Ω(logger.ErrorEntries[0].Data).Should(ContainElement("test"))
+			Ω(logger.ErrorEntries[0].Data).Should(ContainElement("show"))
+		})
+
+		Context("and an action timeout", func() {
+			BeforeEach(func() {
+				timeout = time.Hour
+				ctx = goa.WithActionTimeout(ctx, 10*time.Millisecond)
+			})
+
+			It("uses the action timeout", func() {
+				Ω(rw.Status).Should(Equal(503))
+				close(release)
+			})
+		})
+	})
+
+	Context("with a handler that streams the response past the timeout", func() {
+		BeforeEach(func() {
+			handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+				rw.WriteHeader(200)
+				for i := 0; i < 5; i++ {
+					time.Sleep(5 * time.Millisecond)
+					if _, err := rw.Write([]byte("event\n")); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+		})
+
+		Context("and an action timeout of zero", func() {
+			BeforeEach(func() {
+				ctx = goa.WithActionTimeout(ctx, 0)
+			})
+
+			It("writes the whole stream", func() {
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(rw.Status).Should(Equal(200))
+				Ω(string(rw.Body)).Should(Equal(strings.Repeat("event\n", 5)))
+				Ω(logger.ErrorEntries).Should(BeEmpty())
+			})
+		})
+	})
+
+	Context("with a handler mounted on a controller that does not return in time", func() {
+		var release chan struct{}
+		var hookCalled chan struct{}
+
+		BeforeEach(func() {
+			rel, hook := make(chan struct{}), make(chan struct{})
+			release, hookCalled = rel, hook
+			service.DrainTimeout = time.Second
+			service.ReadinessDelay = 0
+			service.OnShutdown(func(context.Context) error {
+				close(hook)
+				return nil
+			})
+			handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+				<-rel
+				return nil
+			}
+		})
+
+		It("keeps the request in flight until the handler returns", func() {
+			ctrl := service.NewController("test")
+			mh := ctrl.MuxHandler("show", middleware.StrictTimeout(service, 10*time.Millisecond)(handler), nil)
+			rw := newTestResponseWriter()
+			mh(rw, req, nil)
+			Ω(rw.Status).Should(Equal(503))
+
+			done := make(chan error, 1)
+			go func() { done <- service.Shutdown(context.Background()) }()
+			Consistently(hookCalled, 50*time.Millisecond).ShouldNot(BeClosed())
+			close(release)
+			Eventually(hookCalled).Should(BeClosed())
+			Eventually(done).Should(Receive(BeNil()))
+		})
+	})
+
+	Context("with a handler that panics", func() {
+		BeforeEach(func() {
+			handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+				panic("boom")
+			}
+			recoverPanics = true
+		})
+
+		It("raises the panic in the request goroutine", func() {
+			Ω(err).Should(HaveOccurred())
+			Ω(err.Error()).Should(ContainSubstring("panic: boom"))
+		})
+	})
+})
@@ -0,0 +1,52 @@
+package tracing
+
+import (
+	"net/http"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/client"
+	"golang.org/x/net/context"
+)
+
+// doer is a client Doer that traces the requests it makes.
+type doer struct {
+	client.Doer
+	exporter Exporter
+}
+
+// WrapDoer returns a client Doer that makes requests using d and starts a client span for each
+// request. The span is a child of the span stored in the context given to the goa client Do
+// method if any, the traceparent header of the request is set so that the service handling it
+// continues the trace. Sampled spans are exported with exporter when the response is received.
+func WrapDoer(d client.Doer, exporter Exporter) client.ContextDoer {
+	return &doer{Doer: d, exporter: exporter}
+}
+
+// Do makes the request starting a new trace.
+func (d *doer) Do(req *http.Request) (*http.Response, error) {
+	return d.DoContext(context.Background(), req)
+}
+
+// DoContext makes the request tracing it as part of the trace of the span stored in ctx if any.
+func (d *doer) DoContext(ctx context.Context, req *http.Request) (*http.Response, error) {
+	var parent *SpanContext
+	if s := ContextSpan(ctx); s != nil {
+		parent = &s.SpanContext
+	}
+	span := newSpan("HTTP "+req.Method, SpanKindClient, parent, d.exporter)
+	span.SetAttribute("http.method", req.Method)
+	span.SetAttribute("http.url", req.URL.String())
+	req.Header.Set(TraceparentHeader, span.Traceparent())
+
+	resp, err := d.Doer.Do(req)
+
+	if err != nil {
+		span.SetAttribute("error", err.Error())
+	} else {
+		span.Status = resp.StatusCode
+	}
+	if eerr := span.End(); eerr != nil {
+		goa.LogError(ctx, "failed to export span", "span", span.Name, "err", eerr)
+	}
+	return resp, err
+}
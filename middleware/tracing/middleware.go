@@ -0,0 +1,48 @@
+package tracing
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/goadesign/goa"
+	"golang.org/x/net/context"
+)
+
+// Middleware returns a middleware that starts a server span for each request. The span continues
+// the trace described by the request traceparent header if any, it is named after the controller
+// and action handling the request (e.g. "bottle.show") and records the response status and error
+// code once the request has been handled. The span is stored in the request context, see
+// ContextSpan and StartSpan. Sampled spans are exported with exporter when they end.
+// The middleware should be mounted above the ErrorHandler middleware so that the status and error
+// code of error responses are recorded.
+func Middleware(exporter Exporter) goa.Middleware {
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			var parent *SpanContext
+			if sc, ok := ParseTraceparent(req.Header.Get(TraceparentHeader)); ok {
+				parent = &sc
+			}
+			name := fmt.Sprintf("%s.%s", goa.ContextController(ctx), goa.ContextAction(ctx))
+			span := newSpan(name, SpanKindServer, parent, exporter)
+			span.SetAttribute("http.method", req.Method)
+			span.SetAttribute("http.path", req.URL.Path)
+
+			err := h(WithSpan(ctx, span), rw, req)
+
+			if resp := goa.ContextResponse(ctx); resp != nil {
+				span.Status = resp.Status
+				span.ErrorCode = resp.ErrorCode
+			}
+			if err != nil {
+				span.SetAttribute("error", err.Error())
+				if gerr, ok := err.(*goa.Error); ok && span.ErrorCode == "" {
+					span.ErrorCode = gerr.Code
+				}
+			}
+			if eerr := span.End(); eerr != nil {
+				goa.LogError(ctx, "failed to export span", "span", span.Name, "err", eerr)
+			}
+			return err
+		}
+	}
+}
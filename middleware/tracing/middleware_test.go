@@ -0,0 +1,161 @@
+package tracing_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"golang.org/x/net/context"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/client"
+	"github.com/goadesign/goa/middleware/tracing"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Middleware", func() {
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	var exporter *tracing.InMemoryExporter
+	var service *goa.Service
+	var req *http.Request
+	var handler goa.Handler
+	var handlerCtx context.Context
+
+	BeforeEach(func() {
+		exporter = tracing.NewInMemoryExporter()
+		service = goa.New("test")
+		service.Encoder.Register(goa.NewJSONEncoder, "*/*")
+		var err error
+		req, err = http.NewRequest("GET", "/bottles/1", nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			handlerCtx = ctx
+			return service.Send(ctx, 200, "ok")
+		}
+	})
+
+	JustBeforeEach(func() {
+		rw := httptest.NewRecorder()
+		ctrl := service.NewController("bottle")
+		ctx := goa.NewContext(goa.WithAction(ctrl.Context, "show"), rw, req, nil)
+		tracing.Middleware(exporter)(handler)(ctx, rw, req)
+	})
+
+	It("exports a span named after the controller and action", func() {
+		spans := exporter.Spans()
+		Ω(spans).Should(HaveLen(1))
+		Ω(spans[0].Name).Should(Equal("bottle.show"))
+		Ω(spans[0].Kind).Should(Equal(tracing.SpanKindServer))
+		Ω(spans[0].Status).Should(Equal(200))
+		Ω(spans[0].ParentSpanID).Should(BeEmpty())
+		Ω(spans[0].Attributes).Should(HaveKeyWithValue("http.path", "/bottles/1"))
+		Ω(tracing.ContextSpan(handlerCtx)).Should(Equal(spans[0]))
+	})
+
+	Context("with a traceparent header", func() {
+		BeforeEach(func() {
+			req.Header.Set(tracing.TraceparentHeader, traceparent)
+		})
+
+		It("continues the trace", func() {
+			spans := exporter.Spans()
+			Ω(spans).Should(HaveLen(1))
+			Ω(spans[0].TraceID).Should(Equal("4bf92f3577b34da6a3ce929d0e0e4736"))
+			Ω(spans[0].ParentSpanID).Should(Equal("00f067aa0ba902b7"))
+		})
+	})
+
+	Context("with an unsampled traceparent header", func() {
+		BeforeEach(func() {
+			req.Header.Set(tracing.TraceparentHeader, traceparent[:len(traceparent)-1]+"0")
+		})
+
+		It("does not export the span", func() {
+			Ω(exporter.Spans()).Should(BeEmpty())
+		})
+	})
+
+	Context("with a handler returning an error", func() {
+		BeforeEach(func() {
+			handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+				return goa.ErrNotFound("no bottle")
+			}
+		})
+
+		It("records the error code", func() {
+			spans := exporter.Spans()
+			Ω(spans).Should(HaveLen(1))
+			Ω(spans[0].ErrorCode).Should(Equal("not_found"))
+			Ω(spans[0].Attributes).Should(HaveKey("error"))
+		})
+	})
+
+	Context("with a handler starting a child span", func() {
+		BeforeEach(func() {
+			handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+				_, span := tracing.StartSpan(ctx, "query")
+				return span.End()
+			}
+		})
+
+		It("exports the child span", func() {
+			spans := exporter.Spans()
+			Ω(spans).Should(HaveLen(2))
+			Ω(spans[0].Name).Should(Equal("query"))
+			Ω(spans[0].Kind).Should(Equal(tracing.SpanKindInternal))
+			Ω(spans[0].TraceID).Should(Equal(spans[1].TraceID))
+			Ω(spans[0].ParentSpanID).Should(Equal(spans[1].SpanID))
+		})
+	})
+})
+
+var _ = Describe("WrapDoer", func() {
+	var exporter *tracing.InMemoryExporter
+	var server *httptest.Server
+	var received string
+
+	BeforeEach(func() {
+		exporter = tracing.NewInMemoryExporter()
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received = r.Header.Get(tracing.TraceparentHeader)
+			w.WriteHeader(204)
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("propagates the trace of the context span", func() {
+		parent, _ := tracing.ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		ctx := tracing.WithSpan(context.Background(), &tracing.Span{SpanContext: parent})
+		c := client.New(tracing.WrapDoer(http.DefaultClient, exporter))
+		req, err := http.NewRequest("GET", server.URL, nil)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		resp, err := c.Do(ctx, req)
+
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(resp.StatusCode).Should(Equal(204))
+		spans := exporter.Spans()
+		Ω(spans).Should(HaveLen(1))
+		Ω(spans[0].Kind).Should(Equal(tracing.SpanKindClient))
+		Ω(spans[0].Status).Should(Equal(204))
+		Ω(spans[0].ParentSpanID).Should(Equal(parent.SpanID))
+		Ω(received).Should(Equal(spans[0].Traceparent()))
+	})
+
+	It("records transport errors", func() {
+		server.Close()
+		c := client.New(tracing.WrapDoer(http.DefaultClient, exporter))
+		req, err := http.NewRequest("GET", server.URL, nil)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		_, err = c.Do(context.Background(), req)
+
+		Ω(err).Should(HaveOccurred())
+		Ω(exporter.Spans()).Should(HaveLen(1))
+		Ω(exporter.Spans()[0].Attributes).Should(HaveKey("error"))
+	})
+})
@@ -0,0 +1,253 @@
+/*
+Package tracing provides a middleware that traces the requests handled by goa services and a client
+Doer that traces and propagates outbound requests. Trace contexts are propagated using the W3C
+Trace Context traceparent header (https://www.w3.org/TR/trace-context/).
+
+The middleware starts a span per request named after the controller and action that handle it and
+records the response status and goa error code. The spans are handed to an Exporter once complete,
+InMemoryExporter keeps them in memory which is useful in tests:
+
+	exporter := tracing.NewInMemoryExporter()
+	service.Use(tracing.Middleware(exporter))
+
+Action implementations may start child spans with StartSpan. Clients propagate the trace of the
+request being handled by wrapping their Doer:
+
+	c := client.New(tracing.WrapDoer(http.DefaultClient, exporter))
+*/
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// TraceparentHeader is the name of the header that carries the trace context.
+const TraceparentHeader = "traceparent"
+
+// Kinds of spans.
+const (
+	// SpanKindServer is the kind of the spans that trace requests handled by a service.
+	SpanKindServer = "server"
+	// SpanKindClient is the kind of the spans that trace outbound requests.
+	SpanKindClient = "client"
+	// SpanKindInternal is the kind of the spans started with StartSpan.
+	SpanKindInternal = "internal"
+)
+
+type (
+	// SpanContext identifies a span and is propagated across process boundaries.
+	SpanContext struct {
+		// TraceID is the hex encoded 16 bytes identifier of the trace.
+		TraceID string
+		// SpanID is the hex encoded 8 bytes identifier of the span.
+		SpanID string
+		// Sampled is true if the span should be exported.
+		Sampled bool
+	}
+
+	// Span represents a unit of work such as the handling of a request.
+	Span struct {
+		SpanContext
+		// ParentSpanID is the identifier of the parent span, empty for root spans.
+		ParentSpanID string
+		// Name of span.
+		Name string
+		// Kind is one of SpanKindServer, SpanKindClient or SpanKindInternal.
+		Kind string
+		// StartTime is the time the span was started.
+		StartTime time.Time
+		// EndTime is the time the span ended, zero if it did not end yet.
+		EndTime time.Time
+		// Status is the HTTP status of the response if any.
+		Status int
+		// ErrorCode is the code of the goa error returned by the request if any.
+		ErrorCode string
+		// Attributes contains additional key/value pairs describing the span.
+		Attributes map[string]interface{}
+
+		exporter Exporter
+		mu       sync.Mutex
+	}
+
+	// Exporter receives the sampled spans once they end.
+	Exporter interface {
+		// Export exports the given span. Export may be called concurrently.
+		Export(span *Span) error
+	}
+
+	// InMemoryExporter is an exporter that keeps the spans it exports in memory.
+	InMemoryExporter struct {
+		mu    sync.Mutex
+		spans []*Span
+	}
+
+	// key is the private type used to store the span in the context.
+	key int
+)
+
+const spanKey key = 0
+
+// ParseTraceparent parses the value of a traceparent header. ok is false if the value is not
+// a valid traceparent.
+func ParseTraceparent(h string) (sc SpanContext, ok bool) {
+	parts := strings.Split(strings.TrimSpace(h), "-")
+	if len(parts) < 4 {
+		return
+	}
+	version, traceID, spanID, flags := parts[0], parts[1], parts[2], parts[3]
+	if !isHex(version, 2) || version == "ff" || (version == "00" && len(parts) != 4) {
+		return
+	}
+	if !isHex(traceID, 32) || traceID == strings.Repeat("0", 32) {
+		return
+	}
+	if !isHex(spanID, 16) || spanID == strings.Repeat("0", 16) {
+		return
+	}
+	if !isHex(flags, 2) {
+		return
+	}
+	b, _ := hex.DecodeString(flags)
+	return SpanContext{TraceID: traceID, SpanID: spanID, Sampled: b[0]&1 == 1}, true
+}
+
+// Traceparent returns the value of the traceparent header that propagates the span context.
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return fmt.Sprintf("00-%s-%s-%s", sc.TraceID, sc.SpanID, flags)
+}
+
+// WithSpan returns a context that contains the given span.
+func WithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanKey, span)
+}
+
+// ContextSpan returns the span stored in the context if any, nil otherwise.
+func ContextSpan(ctx context.Context) *Span {
+	if s := ctx.Value(spanKey); s != nil {
+		return s.(*Span)
+	}
+	return nil
+}
+
+// StartSpan starts a child span of the span stored in ctx and returns a context that contains
+// it. The span is exported by the exporter of its parent when it ends. StartSpan starts a new
+// trace whose spans are not exported if ctx does not contain a span.
+func StartSpan(ctx context.Context, name string) (context.Context, *Span) {
+	var span *Span
+	if parent := ContextSpan(ctx); parent != nil {
+		span = newSpan(name, SpanKindInternal, &parent.SpanContext, parent.exporter)
+	} else {
+		span = newSpan(name, SpanKindInternal, nil, nil)
+	}
+	return WithSpan(ctx, span), span
+}
+
+// SetAttribute sets the value of a span attribute.
+func (s *Span) SetAttribute(key string, val interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Attributes == nil {
+		s.Attributes = make(map[string]interface{})
+	}
+	s.Attributes[key] = val
+}
+
+// End records the span end time and exports it if it is sampled. End returns the error returned
+// by the exporter if any.
+func (s *Span) End() error {
+	s.mu.Lock()
+	s.EndTime = time.Now()
+	s.mu.Unlock()
+	if !s.Sampled || s.exporter == nil {
+		return nil
+	}
+	return s.exporter.Export(s)
+}
+
+// Duration returns the duration of the span, zero if it did not end yet.
+func (s *Span) Duration() time.Duration {
+	if s.EndTime.IsZero() {
+		return 0
+	}
+	return s.EndTime.Sub(s.StartTime)
+}
+
+// NewInMemoryExporter returns an exporter that keeps the exported spans in memory.
+func NewInMemoryExporter() *InMemoryExporter {
+	return &InMemoryExporter{}
+}
+
+// Export records the span.
+func (e *InMemoryExporter) Export(span *Span) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, span)
+	return nil
+}
+
+// Spans returns the exported spans in the order they ended.
+func (e *InMemoryExporter) Spans() []*Span {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	spans := make([]*Span, len(e.spans))
+	copy(spans, e.spans)
+	return spans
+}
+
+// Reset discards the exported spans.
+func (e *InMemoryExporter) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = nil
+}
+
+// newSpan starts a span, parent is the context of the parent span if any.
+func newSpan(name, kind string, parent *SpanContext, exporter Exporter) *Span {
+	s := &Span{
+		Name:      name,
+		Kind:      kind,
+		StartTime: time.Now(),
+		exporter:  exporter,
+	}
+	s.SpanID = randomID(8)
+	if parent != nil {
+		s.TraceID = parent.TraceID
+		s.ParentSpanID = parent.SpanID
+		s.Sampled = parent.Sampled
+	} else {
+		s.TraceID = randomID(16)
+		s.Sampled = true
+	}
+	return s
+}
+
+// randomID returns a random hex encoded identifier of n bytes.
+func randomID(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// isHex returns true if s consists of n lower case hexadecimal digits.
+func isHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, c := range s {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
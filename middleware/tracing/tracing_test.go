@@ -0,0 +1,44 @@
+package tracing_test
+
+import (
+	"golang.org/x/net/context"
+
+	"github.com/goadesign/goa/middleware/tracing"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ParseTraceparent", func() {
+	It("parses valid headers", func() {
+		sc, ok := tracing.ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		Ω(ok).Should(BeTrue())
+		Ω(sc.TraceID).Should(Equal("4bf92f3577b34da6a3ce929d0e0e4736"))
+		Ω(sc.SpanID).Should(Equal("00f067aa0ba902b7"))
+		Ω(sc.Sampled).Should(BeTrue())
+		Ω(sc.Traceparent()).Should(Equal("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"))
+	})
+
+	It("rejects invalid headers", func() {
+		for _, h := range []string{
+			"",
+			"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+			"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+			"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+			"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		} {
+			_, ok := tracing.ParseTraceparent(h)
+			Ω(ok).Should(BeFalse(), h)
+		}
+	})
+})
+
+var _ = Describe("StartSpan", func() {
+	It("starts unexported root spans without a parent", func() {
+		_, span := tracing.StartSpan(context.Background(), "work")
+		Ω(span.TraceID).Should(HaveLen(32))
+		Ω(span.ParentSpanID).Should(BeEmpty())
+		Ω(span.End()).ShouldNot(HaveOccurred())
+	})
+
+})
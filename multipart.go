@@ -0,0 +1,377 @@
+package goa
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/goadesign/goa/uuid"
+)
+
+// MultipartFormContentType is the content type of multipart form request bodies.
+const MultipartFormContentType = "multipart/form-data"
+
+type (
+	// MultipartDecoder decodes multipart/form-data request bodies. It is registered with the
+	// service HTTPDecoder by the generated code for APIs that define actions using the
+	// MultipartForm DSL.
+	//
+	// The decoder decodes into structs or into maps of type map[string]interface{}. The parts
+	// of the body are matched to the struct fields using the field "form" tag or the field name
+	// if there is no tag. Fields of type *multipart.FileHeader or []*multipart.FileHeader receive
+	// the file parts. Other parts are converted to the type of the field, parts that are decoded
+	// into structs, maps or interfaces must contain JSON.
+	//
+	// The parts are read one at a time and their length is checked as they are read. File parts
+	// are kept in memory up to MaxMemory bytes, the remainder is written to temporary files that
+	// are removed once the request has been handled. Bodies decoded outside of
+	// Service.DecodeRequest cannot use temporary files, Decode fails if their file parts exceed
+	// MaxMemory bytes. The values of the non-file parts are kept in memory up to MaxMemory plus
+	// 10 MB. The length of the entire body is limited by the controller MaxRequestBodyLength.
+	MultipartDecoder struct {
+		// MaxPartLength is the maximum length of a single part of the body. 0 means that
+		// parts are limited by the MaxRequestBodyLength of the controller handling the
+		// request. Decode returns an error of class ErrRequestBodyTooLarge if a part is
+		// longer than MaxPartLength.
+		MaxPartLength int64
+		// MaxMemory is the maximum number of bytes of the file parts kept in memory.
+		MaxMemory int64
+
+		r        io.Reader
+		boundary string
+		req      *http.Request
+	}
+
+	// multipartForm holds the decoded parts indexed by form field name.
+	multipartForm struct {
+		values map[string][]string
+		files  map[string][]*multipart.FileHeader
+	}
+)
+
+// maxMultipartValueMemory is the number of bytes of the non-file parts kept in memory in addition
+// to MaxMemory, it matches the allowance of the mime/multipart package.
+const maxMultipartValueMemory = 10 << 20
+
+var (
+	// MaxMultipartMemory is the MaxMemory of the decoders created with NewMultipartDecoder.
+	MaxMultipartMemory int64 = 32 << 20 // 32 MB
+
+	fileHeaderType  = reflect.TypeOf(&multipart.FileHeader{})
+	uuidType        = reflect.TypeOf(uuid.UUID{})
+	textUnmarshaler = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// NewMultipartDecoder returns a decoder that reads a multipart/form-data body from r.
+func NewMultipartDecoder(r io.Reader) Decoder {
+	return &MultipartDecoder{MaxMemory: MaxMultipartMemory, r: r}
+}
+
+// SetParams sets the boundary used to read the body from the Content-Type parameters.
+func (d *MultipartDecoder) SetParams(params map[string]string) {
+	d.boundary = params["boundary"]
+}
+
+// SetRequest sets the decoded request. The decoded form is recorded in the request
+// MultipartForm field so that its temporary files are removed once the request has been handled.
+func (d *MultipartDecoder) SetRequest(req *http.Request) {
+	d.req = req
+}
+
+// Decode reads the body parts and sets them in v which must be a pointer to a struct or to a map
+// of type map[string]interface{}.
+func (d *MultipartDecoder) Decode(v interface{}) error {
+	if d.boundary == "" {
+		return fmt.Errorf("missing multipart boundary")
+	}
+	form, err := d.read()
+	if err != nil {
+		return err
+	}
+	if m, ok := v.(*map[string]interface{}); ok {
+		*m = form.toMap()
+		return nil
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("cannot decode multipart body into %T", v)
+	}
+	return form.decodeStruct(rv.Elem())
+}
+
+// read reads the parts of the body one at a time enforcing the maximum part length and memory
+// usage as the parts are read.
+func (d *MultipartDecoder) read() (*multipartForm, error) {
+	maxPart := d.MaxPartLength
+	if maxPart <= 0 && d.req != nil {
+		maxPart, _ = d.req.Context().Value(maxBodyLengthKey).(int64)
+	}
+	fileMemory := d.MaxMemory
+	if fileMemory < 0 {
+		fileMemory = 0
+	}
+	valueMemory := fileMemory + maxMultipartValueMemory
+	form := &multipart.Form{Value: make(map[string][]string), File: make(map[string][]*multipart.FileHeader)}
+	fail := func(err error) (*multipartForm, error) {
+		form.RemoveAll()
+		return nil, err
+	}
+	mr := multipart.NewReader(d.r, d.boundary)
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fail(multipartError(err))
+		}
+		name := part.FormName()
+		if name == "" {
+			continue
+		}
+		if part.FileName() == "" {
+			limit := minLength(maxPart, valueMemory)
+			b, err := ioutil.ReadAll(io.LimitReader(part, limit+1))
+			if err != nil {
+				return fail(multipartError(err))
+			}
+			if int64(len(b)) > limit {
+				return fail(partTooLarge(name, limit))
+			}
+			valueMemory -= int64(len(b))
+			form.Value[name] = append(form.Value[name], string(b))
+			continue
+		}
+		limit, memory := maxPart, fileMemory
+		if d.req == nil {
+			// No temporary files without a request to remove them once handled.
+			limit, memory = minLength(maxPart, fileMemory), math.MaxInt64
+		}
+		fh, err := readFilePart(part, limit, memory)
+		if err != nil {
+			return fail(err)
+		}
+		if fh.Size <= fileMemory {
+			fileMemory -= fh.Size
+		}
+		form.File[name] = append(form.File[name], fh)
+	}
+	if d.req != nil {
+		d.req.MultipartForm = form
+	}
+	return &multipartForm{values: form.Value, files: form.File}, nil
+}
+
+// readFilePart reads the file part p into a FileHeader whose content is kept in memory up to
+// maxMemory bytes and written to a temporary file otherwise. readFilePart returns an error of
+// class ErrRequestBodyTooLarge if the part is longer than limit, 0 means no limit.
+func readFilePart(p *multipart.Part, limit, maxMemory int64) (*multipart.FileHeader, error) {
+	// Only the mime/multipart package can create file headers, copy the part to a form with a
+	// single part that it reads as the part is streamed.
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	tooLarge := false
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		w, err := mw.CreatePart(p.Header)
+		if err == nil {
+			var r io.Reader = p
+			if limit > 0 {
+				r = io.LimitReader(p, limit+1)
+			}
+			var n int64
+			n, err = io.Copy(w, r)
+			if err == nil && limit > 0 && n > limit {
+				tooLarge = true
+				err = errors.New("part too large")
+			}
+		}
+		if err == nil {
+			err = mw.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+	form, err := multipart.NewReader(pr, mw.Boundary()).ReadForm(maxMemory)
+	pr.Close()
+	<-done
+	if err != nil {
+		if tooLarge {
+			return nil, partTooLarge(p.FormName(), limit)
+		}
+		return nil, multipartError(err)
+	}
+	fhs := form.File[p.FormName()]
+	if len(fhs) != 1 {
+		form.RemoveAll()
+		return nil, fmt.Errorf("invalid file part %#v", p.FormName())
+	}
+	return fhs[0], nil
+}
+
+// partTooLarge returns the error produced when the part with the given name exceeds limit.
+func partTooLarge(name string, limit int64) error {
+	return ErrRequestBodyTooLarge("length of part %#v exceeds %d bytes", name, limit).Meta("part", name)
+}
+
+// minLength returns the smallest of the given lengths, a zero or negative max means no limit.
+func minLength(max, n int64) int64 {
+	if max > 0 && max < n {
+		return max
+	}
+	return n
+}
+
+// multipartError converts errors caused by request bodies exceeding the controller
+// MaxRequestBodyLength into ErrRequestBodyTooLarge errors.
+func multipartError(err error) error {
+	if strings.Contains(err.Error(), "http: request body too large") {
+		return ErrRequestBodyTooLarge(err)
+	}
+	return err
+}
+
+// toMap returns the parts indexed by name. Parts that appear once are set as a string or a
+// *multipart.FileHeader, repeated parts as a slice.
+func (f *multipartForm) toMap() map[string]interface{} {
+	m := make(map[string]interface{}, len(f.values)+len(f.files))
+	for n, vals := range f.values {
+		if len(vals) == 1 {
+			m[n] = vals[0]
+			continue
+		}
+		elems := make([]interface{}, len(vals))
+		for i, v := range vals {
+			elems[i] = v
+		}
+		m[n] = elems
+	}
+	for n, fhs := range f.files {
+		if len(fhs) == 1 {
+			m[n] = fhs[0]
+			continue
+		}
+		m[n] = fhs
+	}
+	return m
+}
+
+// decodeStruct sets the fields of the struct value rv from the corresponding parts.
+func (f *multipartForm) decodeStruct(rv reflect.Value) error {
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue // unexported
+		}
+		name := formFieldName(field)
+		if name == "-" {
+			continue
+		}
+		fv := rv.Field(i)
+		switch field.Type {
+		case fileHeaderType:
+			if fhs := f.files[name]; len(fhs) > 0 {
+				fv.Set(reflect.ValueOf(fhs[0]))
+			}
+			continue
+		case reflect.SliceOf(fileHeaderType):
+			if fhs := f.files[name]; len(fhs) > 0 {
+				fv.Set(reflect.ValueOf(fhs))
+			}
+			continue
+		}
+		vals, ok := f.values[name]
+		if !ok {
+			continue
+		}
+		if err := setFormValue(fv, vals); err != nil {
+			return fmt.Errorf("invalid value for part %#v: %s", name, err)
+		}
+	}
+	return nil
+}
+
+// formFieldName returns the name of the form field corresponding to the given struct field.
+func formFieldName(field reflect.StructField) string {
+	if tag := field.Tag.Get("form"); tag != "" {
+		return strings.Split(tag, ",")[0]
+	}
+	return field.Name
+}
+
+// setFormValue converts the given part values to the type of v and sets v.
+func setFormValue(v reflect.Value, vals []string) error {
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8 {
+		s := reflect.MakeSlice(v.Type(), len(vals), len(vals))
+		for i, val := range vals {
+			if err := setFormValue(s.Index(i), []string{val}); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+		return nil
+	}
+	val := vals[0]
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return setFormValue(v.Elem(), vals)
+	}
+	if v.Type() == uuidType {
+		u, err := uuid.FromString(val)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(u))
+		return nil
+	}
+	if reflect.PtrTo(v.Type()).Implements(textUnmarshaler) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(val))
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(val)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(val, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(val, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(val, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		v.SetBytes([]byte(val))
+	case reflect.Interface:
+		v.Set(reflect.ValueOf(val))
+	default:
+		return json.Unmarshal([]byte(val), v.Addr().Interface())
+	}
+	return nil
+}
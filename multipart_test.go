@@ -0,0 +1,186 @@
+package goa_test
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("MultipartDecoder", func() {
+	type payload struct {
+		Name  *string                 `form:"name,omitempty"`
+		Count *int                    `form:"count,omitempty"`
+		Tags  []string                `form:"tags,omitempty"`
+		File  *multipart.FileHeader   `form:"file,omitempty"`
+		Files []*multipart.FileHeader `form:"files,omitempty"`
+	}
+
+	var body bytes.Buffer
+	var contentType string
+	var decoder *goa.HTTPDecoder
+
+	BeforeEach(func() {
+		body.Reset()
+		w := multipart.NewWriter(&body)
+		w.WriteField("name", "report")
+		w.WriteField("count", "3")
+		w.WriteField("tags", "a")
+		w.WriteField("tags", "b")
+		fw, _ := w.CreateFormFile("file", "report.txt")
+		fw.Write([]byte("file content"))
+		w.Close()
+		contentType = w.FormDataContentType()
+		decoder = goa.NewHTTPDecoder()
+		decoder.Register(goa.NewMultipartDecoder, goa.MultipartFormContentType)
+	})
+
+	It("decodes the parts into a struct", func() {
+		var p payload
+		Ω(decoder.Decode(&p, &body, contentType)).ShouldNot(HaveOccurred())
+		Ω(*p.Name).Should(Equal("report"))
+		Ω(*p.Count).Should(Equal(3))
+		Ω(p.Tags).Should(Equal([]string{"a", "b"}))
+		Ω(p.Files).Should(BeNil())
+		Ω(p.File).ShouldNot(BeNil())
+		Ω(p.File.Filename).Should(Equal("report.txt"))
+		f, err := p.File.Open()
+		Ω(err).ShouldNot(HaveOccurred())
+		content, _ := ioutil.ReadAll(f)
+		Ω(string(content)).Should(Equal("file content"))
+	})
+
+	It("decodes the parts into a map", func() {
+		var m map[string]interface{}
+		Ω(decoder.Decode(&m, &body, contentType)).ShouldNot(HaveOccurred())
+		Ω(m).Should(HaveKeyWithValue("name", "report"))
+		Ω(m).Should(HaveKeyWithValue("tags", []interface{}{"a", "b"}))
+		Ω(m["file"]).Should(BeAssignableToTypeOf(&multipart.FileHeader{}))
+	})
+
+	It("rejects invalid values", func() {
+		var p struct {
+			Count int `form:"name"`
+		}
+		Ω(decoder.Decode(&p, &body, contentType)).Should(HaveOccurred())
+	})
+
+	Context("with a maximum part length", func() {
+		BeforeEach(func() {
+			decoder.Register(func(r io.Reader) goa.Decoder {
+				d := goa.NewMultipartDecoder(r).(*goa.MultipartDecoder)
+				d.MaxPartLength = 8
+				return d
+			}, goa.MultipartFormContentType)
+		})
+
+		It("rejects parts that are too long", func() {
+			var p payload
+			err := decoder.Decode(&p, &body, contentType)
+			Ω(err).Should(HaveOccurred())
+			Ω(err.(*goa.Error).Status).Should(Equal(413))
+			Ω(err.(*goa.Error).MetaValues).Should(HaveKeyWithValue("part", "file"))
+		})
+
+		It("stops reading parts that are too long", func() {
+			head := "--b\r\nContent-Disposition: form-data; name=\"file\"; filename=\"big.bin\"\r\n\r\n"
+			r := io.MultiReader(strings.NewReader(head), endlessReader{})
+			var p payload
+			err := decoder.Decode(&p, r, "multipart/form-data; boundary=b")
+			Ω(err).Should(HaveOccurred())
+			Ω(err.(*goa.Error).Status).Should(Equal(413))
+		})
+	})
+
+	Context("with file parts exceeding the memory limit and no request", func() {
+		BeforeEach(func() {
+			decoder.Register(func(r io.Reader) goa.Decoder {
+				d := goa.NewMultipartDecoder(r).(*goa.MultipartDecoder)
+				d.MaxMemory = 4
+				return d
+			}, goa.MultipartFormContentType)
+		})
+
+		It("rejects the parts rather than writing temporary files", func() {
+			var p payload
+			err := decoder.Decode(&p, &body, contentType)
+			Ω(err).Should(HaveOccurred())
+			Ω(err.(*goa.Error).Status).Should(Equal(413))
+			Ω(err.(*goa.Error).MetaValues).Should(HaveKeyWithValue("part", "file"))
+		})
+	})
+
+	Context("used by a controller", func() {
+		var service *goa.Service
+		var tmpfile string
+		var handlerErr error
+
+		BeforeEach(func() {
+			tmpfile, handlerErr = "", nil
+			service = goa.New("test")
+			service.Decoder.Register(func(r io.Reader) goa.Decoder {
+				d := goa.NewMultipartDecoder(r).(*goa.MultipartDecoder)
+				d.MaxMemory = 4
+				return d
+			}, goa.MultipartFormContentType)
+		})
+
+		JustBeforeEach(func() {
+			ctrl := service.NewController("test")
+			unm := func(ctx context.Context, service *goa.Service, req *http.Request) error {
+				var p payload
+				if err := service.DecodeRequest(req, &p); err != nil {
+					return err
+				}
+				goa.ContextRequest(ctx).Payload = &p
+				return nil
+			}
+			handler := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+				p := goa.ContextRequest(ctx).Payload.(*payload)
+				f, err := p.File.Open()
+				if err != nil {
+					handlerErr = err
+					return nil
+				}
+				defer f.Close()
+				if file, ok := f.(*os.File); ok {
+					tmpfile = file.Name()
+				}
+				return nil
+			}
+			req, _ := http.NewRequest("POST", "/upload", &body)
+			req.Header.Set("Content-Type", contentType)
+			req.ContentLength = int64(body.Len())
+			ctrl.MuxHandler("upload", handler, unm)(httptest.NewRecorder(), req, nil)
+		})
+
+		It("stores the file parts exceeding the memory limit in temporary files", func() {
+			Ω(handlerErr).ShouldNot(HaveOccurred())
+			Ω(tmpfile).ShouldNot(BeEmpty())
+		})
+
+		It("removes the temporary files once the request has been handled", func() {
+			_, err := os.Stat(tmpfile)
+			Ω(os.IsNotExist(err)).Should(BeTrue())
+		})
+	})
+})
+
+// endlessReader is a reader that never runs out of data.
+type endlessReader struct{}
+
+func (endlessReader) Read(b []byte) (int, error) {
+	for i := range b {
+		b[i] = 'x'
+	}
+	return len(b), nil
+}
@@ -3,6 +3,8 @@ package goa
 import (
 	"net/http"
 	"net/url"
+	"sort"
+	"strings"
 
 	"github.com/dimfeld/httptreemux"
 )
@@ -12,6 +14,11 @@ type (
 	// The values argument includes both the querystring and path parameter values.
 	MuxHandler func(http.ResponseWriter, *http.Request, url.Values)
 
+	// MethodNotAllowedHandler provides the low level implementation for requests whose path
+	// matches registered handlers but whose method does not. The methods argument lists the
+	// HTTP methods allowed for the request path in alphabetical order.
+	MethodNotAllowedHandler func(http.ResponseWriter, *http.Request, []string)
+
 	// ServeMux is the interface implemented by the service request muxes.
 	// It implements http.Handler and makes it possible to register request handlers for
 	// specific HTTP methods and request path via the Handle method.
@@ -27,6 +34,45 @@ type (
 		Lookup(method, path string) MuxHandler
 	}
 
+	// MethodNotAllowedMux is the interface implemented by the ServeMux implementations that
+	// tell requests whose method is not allowed apart from requests whose path does not match
+	// any handler. All the muxes provided by goa implement it.
+	MethodNotAllowedMux interface {
+		// HandleMethodNotAllowed sets the MethodNotAllowedHandler invoked for requests whose
+		// path matches handlers registered with Handle but whose method does not. By
+		// default such requests get a 405 response with the Allow header set.
+		HandleMethodNotAllowed(handle MethodNotAllowedHandler)
+	}
+
+	// RouteMux is the interface implemented by the ServeMux implementations that describe the
+	// routes they serve. All the muxes provided by goa implement it.
+	RouteMux interface {
+		// HandleRoute sets the MuxHandler for the route HTTP method and path like Handle
+		// does and records the route controller and action names.
+		HandleRoute(route *Route, handle MuxHandler)
+		// IterateRoutes calls the given iterator on each route registered with Handle or
+		// HandleRoute in registration order. Iteration stops if an iterator call returns
+		// an error and that error is returned.
+		IterateRoutes(it RouteIterator) error
+	}
+
+	// Route describes a route registered with a ServeMux.
+	Route struct {
+		// Method is the route HTTP method.
+		Method string `json:"method" xml:"method" form:"method"`
+		// Path is the route request path as given to Handle.
+		Path string `json:"path" xml:"path" form:"path"`
+		// Controller is the name of the controller that handles the route requests if the
+		// route was registered with HandleRoute.
+		Controller string `json:"controller,omitempty" xml:"controller,omitempty" form:"controller,omitempty"`
+		// Action is the name of the action that handles the route requests if the route was
+		// registered with HandleRoute.
+		Action string `json:"action,omitempty" xml:"action,omitempty" form:"action,omitempty"`
+	}
+
+	// RouteIterator is the type of functions given to IterateRoutes.
+	RouteIterator func(r *Route) error
+
 	// Muxer implements an adapter that given a request handler can produce a mux handler.
 	Muxer interface {
 		MuxHandler(string, Handler, Unmarshaler) MuxHandler
@@ -34,17 +80,26 @@ type (
 
 	// mux is the default ServeMux implementation.
 	mux struct {
-		router  *httptreemux.TreeMux
+		*routeTable
+		router *httptreemux.TreeMux
+	}
+
+	// routeTable records the handlers registered with a mux. It implements the ServeMux Lookup
+	// and RouteMux IterateRoutes methods.
+	routeTable struct {
 		handles map[string]MuxHandler
+		routes  []*Route
 	}
 )
 
 // NewMux returns a Mux.
 func NewMux() ServeMux {
-	return &mux{
-		router:  httptreemux.New(),
-		handles: make(map[string]MuxHandler),
+	m := &mux{
+		routeTable: newRouteTable(),
+		router:     httptreemux.New(),
 	}
+	m.HandleMethodNotAllowed(methodNotAllowed)
+	return m
 }
 
 // Handle sets the handler for the given verb and path.
@@ -56,10 +111,16 @@ func (m *mux) Handle(method, path string, handle MuxHandler) {
 		}
 		handle(rw, req, params)
 	}
-	m.handles[method+path] = handle
+	m.add(method, path, handle)
 	m.router.Handle(method, path, hthandle)
 }
 
+// HandleRoute sets the handler for the given route and records the route.
+func (m *mux) HandleRoute(route *Route, handle MuxHandler) {
+	m.Handle(route.Method, route.Path, handle)
+	m.describe(route)
+}
+
 // HandleNotFound sets the MuxHandler invoked for requests that don't match any
 // handler registered with Handle.
 func (m *mux) HandleNotFound(handle MuxHandler) {
@@ -67,18 +128,91 @@ func (m *mux) HandleNotFound(handle MuxHandler) {
 		handle(rw, req, nil)
 	}
 	m.router.NotFoundHandler = nfh
+}
+
+// HandleMethodNotAllowed sets the MethodNotAllowedHandler invoked for requests whose path
+// matches handlers registered with Handle but whose method does not.
+func (m *mux) HandleMethodNotAllowed(handle MethodNotAllowedHandler) {
 	mna := func(rw http.ResponseWriter, req *http.Request, methods map[string]httptreemux.HandlerFunc) {
-		handle(rw, req, nil)
+		registered := make([]string, 0, len(methods))
+		for meth := range methods {
+			registered = append(registered, meth)
+		}
+		handle(rw, req, allowedMethods(registered))
 	}
 	m.router.MethodNotAllowedHandler = mna
 }
 
-// Lookup returns the MuxHandler associated with the given method and path.
-func (m *mux) Lookup(method, path string) MuxHandler {
-	return m.handles[method+path]
-}
-
 // ServeHTTP is the function called back by the underlying HTTP server to handle incoming requests.
 func (m *mux) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 	m.router.ServeHTTP(rw, req)
 }
+
+// allowedMethods returns the sorted list of methods allowed for a path given the methods of the
+// handlers registered for it. HEAD is allowed whenever GET is as all muxes serve HEAD requests
+// with GET handlers.
+func allowedMethods(registered []string) []string {
+	methods := make([]string, 0, len(registered)+1)
+	var get, head bool
+	for _, meth := range registered {
+		get = get || meth == "GET"
+		head = head || meth == "HEAD"
+		methods = append(methods, meth)
+	}
+	if get && !head {
+		methods = append(methods, "HEAD")
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// methodNotAllowed is the default MethodNotAllowedHandler. It writes a 405 response with the Allow
+// header set to the allowed methods.
+func methodNotAllowed(rw http.ResponseWriter, req *http.Request, methods []string) {
+	rw.Header().Set("Allow", strings.Join(methods, ", "))
+	rw.WriteHeader(http.StatusMethodNotAllowed)
+}
+
+// newRouteTable returns an empty route table.
+func newRouteTable() *routeTable {
+	return &routeTable{handles: make(map[string]MuxHandler)}
+}
+
+// add records the handler registered for the given method and path.
+func (t *routeTable) add(method, path string, handle MuxHandler) {
+	r := &Route{Method: method, Path: path}
+	if _, ok := t.handles[method+path]; ok {
+		for i, e := range t.routes {
+			if e.Method == method && e.Path == path {
+				t.routes[i] = r
+			}
+		}
+	} else {
+		t.routes = append(t.routes, r)
+	}
+	t.handles[method+path] = handle
+}
+
+// describe records the controller and action names of the given route.
+func (t *routeTable) describe(route *Route) {
+	for _, r := range t.routes {
+		if r.Method == route.Method && r.Path == route.Path {
+			r.Controller, r.Action = route.Controller, route.Action
+		}
+	}
+}
+
+// Lookup returns the MuxHandler associated with the given method and path.
+func (t *routeTable) Lookup(method, path string) MuxHandler {
+	return t.handles[method+path]
+}
+
+// IterateRoutes calls the given iterator on each registered route in registration order.
+func (t *routeTable) IterateRoutes(it RouteIterator) error {
+	for _, r := range t.routes {
+		if err := it(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
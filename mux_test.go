@@ -5,8 +5,10 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"testing"
 
 	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/goatest"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
@@ -67,3 +69,72 @@ var _ = Describe("Mux", func() {
 	})
 
 })
+
+var _ = Describe("RadixMux", func() {
+	var mux goa.ServeMux
+	var called string
+	var params url.Values
+
+	handler := func(name string) goa.MuxHandler {
+		return func(rw http.ResponseWriter, req *http.Request, vals url.Values) {
+			called = name
+			params = vals
+		}
+	}
+
+	BeforeEach(func() {
+		called = ""
+		params = nil
+		mux = goa.NewRadixMux()
+		mux.Handle("GET", "/bottles/:id([0-9]+)", handler("id"))
+		mux.Handle("GET", "/bottles/:name", handler("name"))
+		mux.Handle("GET", "/bottles/:year(\\d{4})/vintage", handler("year"))
+		mux.HandleNotFound(handler("notfound"))
+	})
+
+	serve := func(path string) {
+		req, err := http.NewRequest("GET", path, nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		mux.ServeHTTP(&TestResponseWriter{ParentHeader: http.Header{}}, req)
+	}
+
+	It("matches constrained parameters first", func() {
+		serve("/bottles/42")
+		Ω(called).Should(Equal("id"))
+		Ω(params.Get("id")).Should(Equal("42"))
+	})
+
+	It("falls back to unconstrained parameters", func() {
+		serve("/bottles/merlot")
+		Ω(called).Should(Equal("name"))
+		Ω(params.Get("name")).Should(Equal("merlot"))
+	})
+
+	It("backtracks when a constrained parameter subtree does not match", func() {
+		serve("/bottles/2012/vintage")
+		Ω(called).Should(Equal("year"))
+		Ω(params.Get("year")).Should(Equal("2012"))
+		serve("/bottles/12/vintage")
+		Ω(called).Should(Equal("notfound"))
+	})
+
+	It("panics on invalid constraints", func() {
+		Ω(func() { mux.Handle("GET", "/foo/:id([0-9]+", handler("bad")) }).Should(Panic())
+	})
+})
+
+func TestMuxConformance(t *testing.T) {
+	goatest.TestServeMux(t, goa.NewMux)
+}
+
+func TestMuxMethodNotAllowed(t *testing.T) {
+	for _, mux := range []goa.ServeMux{goa.NewMux(), goa.NewRadixMux()} {
+		if _, ok := mux.(goa.MethodNotAllowedMux); !ok {
+			t.Errorf("%T does not implement goa.MethodNotAllowedMux", mux)
+		}
+	}
+}
+
+func TestRadixMuxConformance(t *testing.T) {
+	goatest.TestServeMux(t, goa.NewRadixMux)
+}
@@ -0,0 +1,111 @@
+package goa
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// ProblemMediaIdentifier is the media type identifier of RFC 7807 problem details responses.
+const ProblemMediaIdentifier = "application/problem+json"
+
+type (
+	// ErrorFormatter produces the bodies of error responses. The service ErrorFormatter is used
+	// by Send and thus by the ErrorHandler middleware and the generated response methods to
+	// render instances of Error. Send encodes the formatted errors with the encoder registered
+	// for the formatter content type regardless of the request Accept header, JSON based
+	// content types that have no registered encoder are encoded with the JSON encoder.
+	ErrorFormatter interface {
+		// ContentType returns the value of the Content-Type header of error responses.
+		ContentType() string
+		// Format returns the value encoded in the body of the response for the given error.
+		Format(e *Error) interface{}
+	}
+
+	// Problem is the RFC 7807 representation of an error. The error metadata is rendered as
+	// extension members of the problem object.
+	Problem struct {
+		// Type is a URI reference that identifies the problem type.
+		Type string `json:"type" xml:"type" form:"type"`
+		// Title is a short, human-readable summary of the problem type.
+		Title string `json:"title,omitempty" xml:"title,omitempty" form:"title,omitempty"`
+		// Status is the HTTP status code of the response.
+		Status int `json:"status" xml:"status" form:"status"`
+		// Detail is a human-readable explanation specific to this occurrence of the problem.
+		Detail string `json:"detail,omitempty" xml:"detail,omitempty" form:"detail,omitempty"`
+		// Instance is a URI reference that identifies the specific occurrence of the problem.
+		Instance string `json:"instance,omitempty" xml:"instance,omitempty" form:"instance,omitempty"`
+		// Extensions contains the problem extension members.
+		Extensions map[string]interface{} `json:"-" xml:"-" form:"-"`
+	}
+
+	// defaultErrorFormatter renders errors using the application/vnd.api.error+json media type.
+	defaultErrorFormatter struct{}
+
+	// problemErrorFormatter renders errors as RFC 7807 problem details.
+	problemErrorFormatter struct {
+		typeBase string
+	}
+)
+
+// DefaultErrorFormatter is the error formatter used by services created with New. It renders
+// errors as is using the ErrorMediaIdentifier content type.
+var DefaultErrorFormatter ErrorFormatter = defaultErrorFormatter{}
+
+// NewProblemErrorFormatter returns an error formatter that renders errors as RFC 7807 problem
+// details (application/problem+json). The error code is appended to typeBase to produce the
+// problem type, the error detail is used as problem detail and the error metadata keys become
+// extension members. Metadata keys that clash with the standard members are ignored. The problems
+// are encoded to JSON unless an encoder is registered for application/problem+json.
+func NewProblemErrorFormatter(typeBase string) ErrorFormatter {
+	return &problemErrorFormatter{typeBase: typeBase}
+}
+
+// ContentType returns ErrorMediaIdentifier.
+func (defaultErrorFormatter) ContentType() string { return ErrorMediaIdentifier }
+
+// Format returns e.
+func (defaultErrorFormatter) Format(e *Error) interface{} { return e }
+
+// ContentType returns ProblemMediaIdentifier.
+func (f *problemErrorFormatter) ContentType() string { return ProblemMediaIdentifier }
+
+// Format returns the Problem corresponding to e.
+func (f *problemErrorFormatter) Format(e *Error) interface{} {
+	p := &Problem{
+		Type:   f.typeBase + e.Code,
+		Title:  http.StatusText(e.Status),
+		Status: e.Status,
+		Detail: e.Detail,
+	}
+	if len(e.MetaValues) > 0 {
+		p.Extensions = make(map[string]interface{}, len(e.MetaValues))
+		for k, v := range e.MetaValues {
+			p.Extensions[k] = v
+		}
+	}
+	return p
+}
+
+// MarshalJSON renders the problem standard members followed by its extension members.
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	type problem Problem
+	std, err := json.Marshal((*problem)(p))
+	if err != nil || len(p.Extensions) == 0 {
+		return std, err
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(std, &m); err != nil {
+		return nil, err
+	}
+	for k, v := range p.Extensions {
+		if _, ok := m[k]; ok {
+			continue
+		}
+		switch k {
+		case "type", "title", "status", "detail", "instance":
+			continue
+		}
+		m[k] = v
+	}
+	return json.Marshal(m)
+}
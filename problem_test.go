@@ -0,0 +1,45 @@
+package goa_test
+
+import (
+	"encoding/json"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ProblemErrorFormatter", func() {
+	var formatter goa.ErrorFormatter
+	var gerr *goa.Error
+
+	BeforeEach(func() {
+		formatter = goa.NewProblemErrorFormatter("https://errors.goa.design/")
+		gerr = goa.ErrInvalidRequest("invalid id").Meta("param", "id", "status", "ignored")
+	})
+
+	It("uses the problem media type", func() {
+		Ω(formatter.ContentType()).Should(Equal(goa.ProblemMediaIdentifier))
+	})
+
+	It("maps the error to a problem", func() {
+		p, ok := formatter.Format(gerr).(*goa.Problem)
+		Ω(ok).Should(BeTrue())
+		Ω(p.Type).Should(Equal("https://errors.goa.design/invalid_request"))
+		Ω(p.Title).Should(Equal("Bad Request"))
+		Ω(p.Status).Should(Equal(400))
+		Ω(p.Detail).Should(Equal("invalid id"))
+		Ω(p.Extensions).Should(HaveKeyWithValue("param", "id"))
+	})
+
+	It("renders the metadata as extension members", func() {
+		b, err := json.Marshal(formatter.Format(gerr))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(b)).Should(MatchJSON(`{
+			"type": "https://errors.goa.design/invalid_request",
+			"title": "Bad Request",
+			"status": 400,
+			"detail": "invalid id",
+			"param": "id"
+		}`))
+	})
+})
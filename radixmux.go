@@ -0,0 +1,252 @@
+package goa
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+type (
+	// radixMux is a ServeMux implementation backed by a radix tree that supports regular
+	// expression constraints on path parameters.
+	radixMux struct {
+		*routeTable
+		root             *radixNode
+		notFound         MuxHandler
+		methodNotAllowed MethodNotAllowedHandler
+	}
+
+	// radixNode is a node of the radix tree. The path of a node is the concatenation of the
+	// prefixes of its ancestors and of its own prefix.
+	radixNode struct {
+		prefix   string
+		children []*radixNode
+		params   []*radixParam
+		wildcard *radixParam
+		handlers map[string]MuxHandler
+	}
+
+	// radixParam is a path parameter or wildcard edge of the radix tree.
+	radixParam struct {
+		name    string
+		pattern string
+		re      *regexp.Regexp
+		node    *radixNode
+	}
+)
+
+// NewRadixMux returns a ServeMux backed by a radix tree. In addition to the path parameters
+// (e.g. ":id") and trailing wildcards (e.g. "*filepath") supported by all muxes, path parameters
+// may be constrained with a regular expression that must match the entire path segment. The
+// regular expression is given in parenthesis after the parameter name, for example:
+//
+//	mux.Handle("GET", "/bottles/:id([0-9]+)", handler)
+//
+// Static segments take precedence over path parameters, constrained path parameters over
+// unconstrained ones and path parameters over wildcards. Use Service.SetMux to make a service use
+// the mux.
+func NewRadixMux() ServeMux {
+	return &radixMux{
+		routeTable:       newRouteTable(),
+		root:             &radixNode{},
+		methodNotAllowed: methodNotAllowed,
+	}
+}
+
+// Handle sets the handler for the given verb and path. It panics if path contains an invalid
+// regular expression constraint.
+func (m *radixMux) Handle(method, path string, handle MuxHandler) {
+	n := m.root
+	static := ""
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		if i > 0 {
+			static += "/"
+		}
+		switch {
+		case strings.HasPrefix(s, ":"):
+			n = n.insert(static)
+			static = ""
+			n = n.param(radixSegment(s[1:]))
+		case strings.HasPrefix(s, "*"):
+			if i < len(segments)-1 {
+				panic(fmt.Sprintf("goa: wildcard %#v must be at the end of path %#v", s, path))
+			}
+			n = n.insert(static)
+			static = ""
+			if n.wildcard == nil {
+				n.wildcard = &radixParam{name: s[1:], node: &radixNode{}}
+			}
+			n = n.wildcard.node
+		default:
+			static += s
+		}
+	}
+	n = n.insert(static)
+	if n.handlers == nil {
+		n.handlers = make(map[string]MuxHandler)
+	}
+	n.handlers[method] = handle
+	m.add(method, path, handle)
+}
+
+// HandleRoute sets the handler for the given route and records the route.
+func (m *radixMux) HandleRoute(route *Route, handle MuxHandler) {
+	m.Handle(route.Method, route.Path, handle)
+	m.describe(route)
+}
+
+// HandleNotFound sets the MuxHandler invoked for requests that don't match any
+// handler registered with Handle.
+func (m *radixMux) HandleNotFound(handle MuxHandler) {
+	m.notFound = handle
+}
+
+// HandleMethodNotAllowed sets the MethodNotAllowedHandler invoked for requests whose path
+// matches handlers registered with Handle but whose method does not.
+func (m *radixMux) HandleMethodNotAllowed(handle MethodNotAllowedHandler) {
+	m.methodNotAllowed = handle
+}
+
+// ServeHTTP is the function called back by the underlying HTTP server to handle incoming requests.
+func (m *radixMux) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	params := req.URL.Query()
+	if n := m.root.match(req.URL.Path, params); n != nil {
+		h, ok := n.handlers[req.Method]
+		if !ok && req.Method == "HEAD" {
+			h, ok = n.handlers["GET"]
+		}
+		if ok {
+			h(rw, req, params)
+			return
+		}
+		registered := make([]string, 0, len(n.handlers))
+		for meth := range n.handlers {
+			registered = append(registered, meth)
+		}
+		m.methodNotAllowed(rw, req, allowedMethods(registered))
+		return
+	}
+	if m.notFound == nil {
+		http.NotFound(rw, req)
+		return
+	}
+	m.notFound(rw, req, nil)
+}
+
+// radixSegment parses a path parameter segment of the form "name" or "name(regexp)".
+func radixSegment(s string) *radixParam {
+	idx := strings.Index(s, "(")
+	if idx == -1 {
+		return &radixParam{name: s}
+	}
+	if !strings.HasSuffix(s, ")") {
+		panic(fmt.Sprintf("goa: invalid path parameter constraint %#v", s))
+	}
+	pattern := s[idx+1 : len(s)-1]
+	return &radixParam{
+		name:    s[:idx],
+		pattern: pattern,
+		re:      regexp.MustCompile("^(?:" + pattern + ")$"),
+	}
+}
+
+// insert adds the given static path below n splitting existing nodes as needed and returns the
+// node whose path ends with it.
+func (n *radixNode) insert(path string) *radixNode {
+	if path == "" {
+		return n
+	}
+	for i, c := range n.children {
+		if c.prefix[0] != path[0] {
+			continue
+		}
+		l := commonPrefix(c.prefix, path)
+		if l < len(c.prefix) {
+			mid := &radixNode{prefix: c.prefix[:l], children: []*radixNode{c}}
+			c.prefix = c.prefix[l:]
+			n.children[i] = mid
+			c = mid
+		}
+		return c.insert(path[l:])
+	}
+	c := &radixNode{prefix: path}
+	n.children = append(n.children, c)
+	return c
+}
+
+// param returns the node that follows the given path parameter creating it if needed.
+func (n *radixNode) param(p *radixParam) *radixNode {
+	for _, e := range n.params {
+		if e.name == p.name && e.pattern == p.pattern {
+			return e.node
+		}
+	}
+	p.node = &radixNode{}
+	// Keep constrained parameters first so they get a chance to match before the others.
+	idx := len(n.params)
+	if p.re != nil {
+		for idx > 0 && n.params[idx-1].re == nil {
+			idx--
+		}
+	}
+	n.params = append(n.params, nil)
+	copy(n.params[idx+1:], n.params[idx:])
+	n.params[idx] = p
+	return p.node
+}
+
+// match returns the node with handlers matching path if any. n prefix must have already been
+// matched. The values of the matched path parameters are set in params.
+func (n *radixNode) match(path string, params url.Values) *radixNode {
+	if path == "" {
+		if n.handlers != nil {
+			return n
+		}
+		if n.wildcard != nil && n.wildcard.node.handlers != nil {
+			params.Set(n.wildcard.name, "")
+			return n.wildcard.node
+		}
+		return nil
+	}
+	for _, c := range n.children {
+		if strings.HasPrefix(path, c.prefix) {
+			if m := c.match(path[len(c.prefix):], params); m != nil {
+				return m
+			}
+		}
+	}
+	if len(n.params) > 0 {
+		seg := path
+		if idx := strings.Index(path, "/"); idx > -1 {
+			seg = path[:idx]
+		}
+		if seg != "" {
+			for _, p := range n.params {
+				if p.re != nil && !p.re.MatchString(seg) {
+					continue
+				}
+				if m := p.node.match(path[len(seg):], params); m != nil {
+					params.Set(p.name, seg)
+					return m
+				}
+			}
+		}
+	}
+	if n.wildcard != nil && n.wildcard.node.handlers != nil {
+		params.Set(n.wildcard.name, path)
+		return n.wildcard.node
+	}
+	return nil
+}
+
+// commonPrefix returns the length of the longest common prefix of a and b.
+func commonPrefix(a, b string) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
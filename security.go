@@ -27,6 +27,29 @@ func WithRequiredScopes(ctx context.Context, scopes []string) context.Context {
 	return context.WithValue(ctx, securityScopesKey, scopes)
 }
 
+// WithUser builds a context containing the identity of the authenticated user, e.g. the basic
+// auth username or the subject of a JWT. Security middlewares call WithUser once the request is
+// authenticated. The identity is also recorded in the request data so that it is available to
+// the middlewares mounted above the security middleware such as AccessLog.
+func WithUser(ctx context.Context, user string) context.Context {
+	if req := ContextRequest(ctx); req != nil {
+		req.user = user
+	}
+	return context.WithValue(ctx, userKey, user)
+}
+
+// ContextUser extracts the identity of the authenticated user from the given context. It returns
+// the empty string if the request was not authenticated.
+func ContextUser(ctx context.Context) string {
+	if u := ctx.Value(userKey); u != nil {
+		return u.(string)
+	}
+	if req := ContextRequest(ctx); req != nil {
+		return req.user
+	}
+	return ""
+}
+
 // OAuth2Security represents the `oauth2` security scheme. It is instantiated by the generated code
 // accordingly to the use of the different `*Security()` DSL functions and `Security()` in the
 // design.
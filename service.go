@@ -10,6 +10,9 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"golang.org/x/net/context"
 )
@@ -17,6 +20,9 @@ import (
 const (
 	// ErrorMediaIdentifier is the media type identifier used for error responses.
 	ErrorMediaIdentifier = "application/vnd.api.error+json"
+
+	// DefaultMaxRequestBodyLength is the default MaxRequestBodyLength of controllers.
+	DefaultMaxRequestBodyLength int64 = 1073741824 // 1 GB
 )
 
 type (
@@ -29,7 +35,7 @@ type (
 	Service struct {
 		// Name of service used for logging, tracing etc.
 		Name string
-		// Mux is the service request mux
+		// Mux is the service request mux, use SetMux to replace it.
 		Mux ServeMux
 		// Context is the root context from which all request contexts are derived.
 		// Set values in the root context prior to starting the server to make these values
@@ -39,9 +45,43 @@ type (
 		Decoder *HTTPDecoder
 		// Response body encoder
 		Encoder *HTTPEncoder
-
-		middleware []Middleware       // Middleware chain
-		cancel     context.CancelFunc // Service context cancel signal trigger
+		// ErrorFormatter renders the Error values sent by Send, defaults to
+		// DefaultErrorFormatter. Use NewProblemErrorFormatter to render RFC 7807 problem
+		// details instead.
+		ErrorFormatter ErrorFormatter
+		// Translator localizes the messages of the validation errors sent by Send using
+		// the request Accept-Language header, defaults to a MessageCatalog that contains the
+		// English messages. Set to nil to disable localization.
+		Translator Translator
+		// Server is the HTTP server used by ListenAndServe and ListenAndServeTLS. The server
+		// Handler defaults to Mux and its Addr field is overridden by the listen address.
+		Server *http.Server
+		// DrainTimeout is the maximum amount of time Shutdown waits for in-flight requests
+		// to complete before cancelling the root context. Defaults to 30 seconds. A zero or
+		// negative value disables draining: Shutdown cancels the in-flight requests right
+		// away.
+		DrainTimeout time.Duration
+		// ReadinessDelay is the amount of time Shutdown keeps serving requests after the
+		// readiness endpoint starts reporting the service as shutting down so that load
+		// balancers stop routing requests to the service before it stops accepting
+		// connections. Defaults to 5 seconds.
+		ReadinessDelay time.Duration
+		// ShutdownHookTimeout is the maximum amount of time given to each hook registered
+		// with OnShutdown to complete. Defaults to 10 seconds. A zero or negative value
+		// removes the limit.
+		ShutdownHookTimeout time.Duration
+		// HealthCheckTimeout is the maximum amount of time given to each health check
+		// registered with AddHealthCheck to complete. Defaults to 5 seconds.
+		HealthCheckTimeout time.Duration
+
+		middleware    []Middleware       // Middleware chain
+		metrics       Metrics            // Metrics set with UseMetrics if any
+		cancel        context.CancelFunc // Service context cancel signal trigger
+		inflight      *requestTracker    // In-flight requests counter
+		shutdownHooks []ShutdownHook     // Hooks run by Shutdown
+		shuttingDown  int32              // Set to 1 once Shutdown has been called
+		healthChecks  []*healthCheck     // Health checks run by the readiness endpoint
+		healthMu      sync.Mutex         // Protects healthChecks
 	}
 
 	// Controller defines the common fields and behavior of generated controllers.
@@ -53,16 +93,29 @@ type (
 		// Controller root context
 		Context context.Context
 		// MaxRequestBodyLength is the maximum length read from request bodies.
-		// Set to 0 to remove the limit altogether. Defaults to 1GB.
+		// Set to 0 to remove the limit altogether. Defaults to 1GB. Streamed request bodies are not
+		// limited, see StreamMuxHandler.
 		MaxRequestBodyLength int64
 
-		middleware []Middleware // Controller specific middleware if any
+		middleware       []Middleware             // Controller specific middleware if any
+		actionMiddleware map[string][]Middleware  // Action specific middleware if any
+		actionTimeouts   map[string]time.Duration // Action specific timeouts if any
+		fileActions      map[string]bool          // Actions that serve files
 	}
 
 	// FileServer is the interface implemented by controllers that can serve static files.
 	FileServer interface {
 		// FileHandler returns a handler that serves files under the given request path.
 		FileHandler(path, filename string) Handler
+		// FileMuxHandler wraps a handler returned by FileHandler into a MuxHandler.
+		FileMuxHandler(name string, hdlr Handler) MuxHandler
+	}
+
+	// ActionTimeoutSetter is the interface implemented by controllers that accept per action
+	// timeouts.
+	ActionTimeoutSetter interface {
+		// SetActionTimeout sets the timeout of the action with the given name.
+		SetActionTimeout(action string, timeout time.Duration)
 	}
 
 	// Handler defines the request handler signatures.
@@ -73,6 +126,34 @@ type (
 
 	// DecodeFunc is the function that initialize the unmarshaled payload from the request body.
 	DecodeFunc func(context.Context, io.ReadCloser, interface{}) error
+
+	// ShutdownHook is a function run by Shutdown once all in-flight requests have completed,
+	// typically used to release resources such as database connections or to flush metrics.
+	ShutdownHook func(context.Context) error
+
+	// Metrics is the interface used to record the metrics emitted by goa. It is implemented by
+	// the github.com/armon/go-metrics Metrics struct.
+	Metrics interface {
+		// IncrCounter increments the counter named by key.
+		IncrCounter(key []string, val float32)
+		// MeasureSince records the duration elapsed since start.
+		MeasureSince(key []string, start time.Time)
+	}
+
+	// requestTracker keeps count of the requests being handled by a service.
+	requestTracker struct {
+		sync.Mutex
+		count int
+		idle  chan struct{}
+	}
+
+	// requestHold delays the release of the resources of a request until all the holders
+	// are done with it, see HoldRequest.
+	requestHold struct {
+		sync.Mutex
+		count   int
+		release func()
+	}
 )
 
 // New instantiates a service with the given name.
@@ -81,24 +162,45 @@ func New(name string) *Service {
 		stdlog       = log.New(os.Stderr, "", log.LstdFlags)
 		ctx          = WithLogger(context.Background(), NewLogger(stdlog))
 		cctx, cancel = context.WithCancel(ctx)
-		mux          = NewMux()
 		service      = &Service{
 			Name:    name,
 			Context: cctx,
-			Mux:     mux,
 			Decoder: NewHTTPDecoder(),
 			Encoder: NewHTTPEncoder(),
+			Server:  &http.Server{},
+
+			ErrorFormatter: DefaultErrorFormatter,
+			Translator:     NewMessageCatalog(),
+
+			DrainTimeout:        30 * time.Second,
+			ReadinessDelay:      5 * time.Second,
+			ShutdownHookTimeout: 10 * time.Second,
+			HealthCheckTimeout:  5 * time.Second,
 
-			cancel: cancel,
+			cancel:   cancel,
+			inflight: newRequestTracker(),
 		}
-		notFoundHandler Handler
 	)
+	service.SetMux(NewMux())
+
+	return service
+}
+
+// SetMux sets the service mux. SetMux sets up the mux so that requests that do not match any
+// route get a 404 response with an ErrNotFound error in the body. If the mux implements
+// MethodNotAllowedMux requests whose method does not match get a 405 response with an
+// ErrMethodNotAllowed error in the body and OPTIONS requests get a response listing the allowed
+// methods unless a handler (e.g. CORS preflight) is registered for them. SetMux also mounts the
+// liveness and readiness endpoints. The routes registered with the previous mux are not copied,
+// call SetMux before mounting the controllers.
+func (service *Service) SetMux(mux ServeMux) {
+	var (
+		notFoundHandler         Handler
+		methodNotAllowedHandler Handler
+	)
+	service.Mux = mux
 
-	// Setup default NotFound handler
 	mux.HandleNotFound(func(rw http.ResponseWriter, req *http.Request, params url.Values) {
-		if resp := ContextResponse(ctx); resp != nil && resp.Written() {
-			return
-		}
 		// Use closure to do lazy computation of middleware chain so all middlewares are
 		// registered.
 		if notFoundHandler == nil {
@@ -118,7 +220,37 @@ func New(name string) *Service {
 		}
 	})
 
-	return service
+	if mna, ok := mux.(MethodNotAllowedMux); ok {
+		mna.HandleMethodNotAllowed(func(rw http.ResponseWriter, req *http.Request, methods []string) {
+			allow := strings.Join(methods, ", ")
+			if !strings.Contains(allow, "OPTIONS") {
+				allow += ", OPTIONS"
+			}
+			rw.Header().Set("Allow", allow)
+			if methodNotAllowedHandler == nil {
+				methodNotAllowedHandler = func(_ context.Context, rw http.ResponseWriter, req *http.Request) error {
+					if req.Method == "OPTIONS" {
+						rw.WriteHeader(200)
+						return nil
+					}
+					allow := rw.Header().Get("Allow")
+					return ErrMethodNotAllowed("method %s must be one of %s", req.Method, allow).Meta("allow", allow)
+				}
+				chain := service.middleware
+				ml := len(chain)
+				for i := range chain {
+					methodNotAllowedHandler = chain[ml-i-1](methodNotAllowedHandler)
+				}
+			}
+			ctx := NewContext(service.Context, rw, req, nil)
+			err := methodNotAllowedHandler(ctx, ContextResponse(ctx), req)
+			if !ContextResponse(ctx).Written() {
+				service.Send(ctx, 405, err)
+			}
+		})
+	}
+
+	service.mountHealth()
 }
 
 // CancelAll sends a cancel signals to all request handlers via the context.
@@ -139,26 +271,170 @@ func (service *Service) WithLogger(logger LogAdapter) {
 	service.Context = WithLogger(service.Context, logger)
 }
 
+// WithLogLevel sets the minimum level of the messages logged by the service. Messages with a
+// lower level are discarded unless the request context raises the verbosity, see the
+// RequestLogLevel middleware. The default minimum level is LevelInfo.
+func (service *Service) WithLogLevel(level LogLevel) {
+	service.Context = WithLogLevel(service.Context, level)
+}
+
+// UseMetrics sets the metrics instance used to record the metrics emitted by the service: the
+// durations of the body encodings and decodings, the response status counters and the format
+// validation error counters. The metrics are otherwise recorded using the package-level instance
+// initialized with NewMetrics.
+func (service *Service) UseMetrics(m Metrics) {
+	service.metrics = m
+	service.Encoder.Metrics = m
+	service.Decoder.Metrics = m
+}
+
+// LogDebug logs the debug message and values at odd indeces using the keys at even indeces of the keyvals slice.
+func (service *Service) LogDebug(msg string, keyvals ...interface{}) {
+	LogDebug(service.Context, msg, keyvals...)
+}
+
 // LogInfo logs the message and values at odd indeces using the keys at even indeces of the keyvals slice.
 func (service *Service) LogInfo(msg string, keyvals ...interface{}) {
 	LogInfo(service.Context, msg, keyvals...)
 }
 
+// LogWarn logs the warning and values at odd indeces using the keys at even indeces of the keyvals slice.
+func (service *Service) LogWarn(msg string, keyvals ...interface{}) {
+	LogWarn(service.Context, msg, keyvals...)
+}
+
 // LogError logs the error and values at odd indeces using the keys at even indeces of the keyvals slice.
 func (service *Service) LogError(msg string, keyvals ...interface{}) {
 	LogError(service.Context, msg, keyvals...)
 }
 
 // ListenAndServe starts a HTTP server and sets up a listener on the given host/port.
+// It returns nil as soon as Shutdown is called, that is before the in-flight requests complete and
+// the shutdown hooks run. Callers must wait for Shutdown to return before exiting the process.
 func (service *Service) ListenAndServe(addr string) error {
 	service.LogInfo("listen", "transport", "http", "addr", addr)
-	return http.ListenAndServe(addr, service.Mux)
+	err := service.server(addr).ListenAndServe()
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
 }
 
 // ListenAndServeTLS starts a HTTPS server and sets up a listener on the given host/port.
+// It returns nil as soon as Shutdown is called, see ListenAndServe.
 func (service *Service) ListenAndServeTLS(addr, certFile, keyFile string) error {
 	service.LogInfo("listen", "transport", "https", "addr", addr)
-	return http.ListenAndServeTLS(addr, certFile, keyFile, service.Mux)
+	err := service.server(addr).ListenAndServeTLS(certFile, keyFile)
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
+
+// OnShutdown registers a hook that Shutdown runs after all in-flight requests have completed.
+// Hooks run in the order in which they were registered. Each hook is given a context of its own
+// that expires after ShutdownHookTimeout so that hooks run even when the context given to Shutdown
+// is already done.
+func (service *Service) OnShutdown(hook ShutdownHook) {
+	service.shutdownHooks = append(service.shutdownHooks, hook)
+}
+
+// Shutdown gracefully shuts down the service. It first makes the readiness endpoint report the
+// service as shutting down and keeps serving requests for ReadinessDelay. It then stops the
+// server from accepting new connections and waits for in-flight requests to complete. If
+// requests are still running once DrainTimeout has elapsed Shutdown calls CancelAll to signal
+// the request handlers and waits for them to return. Shutdown finally runs the hooks registered
+// with OnShutdown. Shutdown cancels the in-flight requests without waiting if DrainTimeout is
+// zero or negative.
+//
+// ListenAndServe returns as soon as Shutdown is called so programs should wait for Shutdown to
+// return before exiting:
+//
+//	done := make(chan struct{})
+//	go func() {
+//		<-sigc // signal channel
+//		service.Shutdown(context.Background())
+//		close(done)
+//	}()
+//	if err := service.ListenAndServe(":8080"); err != nil {
+//		service.LogError("startup", "err", err)
+//	}
+//	<-done
+//
+// If the given context is done before all requests complete Shutdown stops waiting, runs the
+// hooks and returns the context error. Otherwise it returns the first error returned by the
+// server or by the hooks.
+func (service *Service) Shutdown(ctx context.Context) error {
+	if !atomic.CompareAndSwapInt32(&service.shuttingDown, 0, 1) {
+		return fmt.Errorf("service %s is already shutting down", service.Name)
+	}
+	service.LogInfo("shutdown", "delay", service.ReadinessDelay, "drain", service.DrainTimeout)
+
+	if service.ReadinessDelay > 0 {
+		timer := time.NewTimer(service.ReadinessDelay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+		timer.Stop()
+	}
+
+	srvErr := make(chan error, 1)
+	go func() { srvErr <- service.Server.Shutdown(ctx) }()
+
+	if service.DrainTimeout > 0 {
+		timer := time.NewTimer(service.DrainTimeout)
+		defer timer.Stop()
+		select {
+		case <-service.inflight.wait():
+		case <-timer.C:
+			service.LogWarn("drain deadline exceeded", "inflight", service.inflight.len())
+		case <-ctx.Done():
+		}
+	}
+	service.CancelAll()
+
+	var err error
+	select {
+	case <-service.inflight.wait():
+	case <-ctx.Done():
+		err = ctx.Err()
+		service.LogError("shutdown incomplete", "inflight", service.inflight.len(), "err", err)
+	}
+	if e := <-srvErr; e != nil && err == nil {
+		err = e
+	}
+	for _, hook := range service.shutdownHooks {
+		if e := service.runShutdownHook(hook); e != nil {
+			service.LogError("shutdown hook failed", "err", e)
+			if err == nil {
+				err = e
+			}
+		}
+	}
+	return err
+}
+
+// runShutdownHook runs hook with a context that is independent of the Shutdown context and
+// expires after ShutdownHookTimeout.
+func (service *Service) runShutdownHook(hook ShutdownHook) error {
+	ctx := context.Background()
+	if service.ShutdownHookTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, service.ShutdownHookTimeout)
+		defer cancel()
+	}
+	return hook(ctx)
+}
+
+// server returns the service HTTP server configured to listen on the given address.
+func (service *Service) server(addr string) *http.Server {
+	srv := service.Server
+	srv.Addr = addr
+	if srv.Handler == nil {
+		srv.Handler = service.Mux
+	}
+	return srv
 }
 
 // NewController returns a controller for the given resource. This method is mainly intended for
@@ -168,19 +444,79 @@ func (service *Service) NewController(name string) *Controller {
 		Name:                 name,
 		Service:              service,
 		Context:              context.WithValue(service.Context, ctrlKey, name),
-		MaxRequestBodyLength: 1073741824, // 1 GB
+		MaxRequestBodyLength: DefaultMaxRequestBodyLength,
 	}
 }
 
-// Send serializes the given body matching the request Accept header against the service
-// encoders. It uses the default service encoder if no match is found.
+// Send serializes the given body matching the request Accept and Accept-Charset headers against
+// the service encoders, see HTTPEncoder.Negotiate for details. Send writes a 406 response with an
+// ErrNotAcceptable error in the body instead if none of the encoders is acceptable. Error values
+// are localized with the service Translator, rendered with the service ErrorFormatter and encoded
+// with the encoder matching the formatter content type, see ErrorFormatter.
 func (service *Service) Send(ctx context.Context, code int, body interface{}) error {
 	r := ContextResponse(ctx)
 	if r == nil {
 		return fmt.Errorf("no response data in context")
 	}
+	req := ContextRequest(ctx)
+	contentType, err := service.Encoder.Negotiate(req.Header.Get("Accept"), req.Header.Get("Accept-Charset"))
+	if err != nil {
+		if _, ok := err.(*Error); !ok {
+			r.WriteHeader(code)
+			return err
+		}
+		code, body, contentType = 406, err, ""
+		r.ErrorCode = err.(*Error).Code
+		r.Header().Del("Content-Type")
+	}
+	if e, ok := body.(*Error); ok {
+		service.countValidationErrors(e)
+		formatter := service.errorFormatter()
+		// Generated code sets the error media type explicitly, keep other content types.
+		switch r.Header().Get("Content-Type") {
+		case "", ErrorMediaIdentifier, ProblemMediaIdentifier:
+			r.Header().Set("Content-Type", formatter.ContentType())
+		}
+		// Use the encoder matching the error content type rather than the negotiated one.
+		if contentType == "" || r.Header().Get("Content-Type") == formatter.ContentType() {
+			contentType = formatter.ContentType()
+		}
+		body = service.formatError(req.Request, e)
+	}
+	if len(service.Encoder.contentTypes) > 1 {
+		r.Header().Add("Vary", "Accept")
+	}
+	if contentType != "*/*" && r.Header().Get("Content-Type") == "" {
+		r.Header().Set("Content-Type", contentType)
+	}
 	r.WriteHeader(code)
-	return service.EncodeResponse(ctx, body)
+	return service.Encoder.encode(body, r, contentType)
+}
+
+// errorFormatter returns the service ErrorFormatter or DefaultErrorFormatter if nil.
+func (service *Service) errorFormatter() ErrorFormatter {
+	if service.ErrorFormatter == nil {
+		return DefaultErrorFormatter
+	}
+	return service.ErrorFormatter
+}
+
+// formatError localizes e using the request Accept-Language header and renders it with the
+// service ErrorFormatter. Localizing also strips the message key and arguments from the error
+// metadata.
+func (service *Service) formatError(req *http.Request, e *Error) interface{} {
+	e = e.Localize(service.Translator, ParseAcceptLanguage(req.Header.Get("Accept-Language")))
+	return service.errorFormatter().Format(e)
+}
+
+// countValidationErrors increments the "goa.validation.error.<format>" counters of the formats
+// that the fields of e fail to satisfy using the service metrics, see UseMetrics.
+func (service *Service) countValidationErrors(e *Error) {
+	for _, fe := range e.FieldErrors() {
+		if f, ok := fe.Expected.(string); ok && fe.Rule == "format" {
+			go incrCounter(service.metrics, []string{"goa", "validation", "error", f}, 1.0)
+		}
+	}
 }
 
 // ServeFiles create a "FileServer" controller and calls ServerFiles on it.
@@ -189,13 +525,49 @@ func (service *Service) ServeFiles(path, filename string) error {
 	return ctrl.ServeFiles(path, filename)
 }
 
+// HandleRoute registers handle with the service mux for the route HTTP method and path. The route
+// controller and action names are recorded if the mux implements RouteMux, see ServeRoutes.
+func (service *Service) HandleRoute(route *Route, handle MuxHandler) {
+	if m, ok := service.Mux.(RouteMux); ok {
+		m.HandleRoute(route, handle)
+		return
+	}
+	service.Mux.Handle(route.Method, route.Path, handle)
+}
+
+// ServeRoutes mounts a debug endpoint under the given path that lists the routes registered
+// with the service mux together with the names of the controllers and actions that handle them.
+// The routes are sorted by path and method. The service mux must implement RouteMux.
+func (service *Service) ServeRoutes(path string) {
+	ctrl := service.NewController("Routes")
+	handler := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		m, ok := service.Mux.(RouteMux)
+		if !ok {
+			return fmt.Errorf("mux %T does not implement goa.RouteMux", service.Mux)
+		}
+		var routes []*Route
+		m.IterateRoutes(func(r *Route) error {
+			routes = append(routes, r)
+			return nil
+		})
+		sort.Sort(byRoute(routes))
+		return service.Send(ctx, 200, routes)
+	}
+	route := &Route{Method: "GET", Path: path, Controller: ctrl.Name, Action: "list"}
+	service.HandleRoute(route, ctrl.MuxHandler("list", handler, nil))
+	LogInfo(ctrl.Context, "mount routes", "route", fmt.Sprintf("GET %s", path))
+}
+
 // DecodeRequest uses the HTTP decoder to unmarshal the request body into the provided value based
 // on the request Content-Type header.
 func (service *Service) DecodeRequest(req *http.Request, v interface{}) error {
 	body, contentType := req.Body, req.Header.Get("Content-Type")
 	defer body.Close()
 
-	if err := service.Decoder.Decode(v, body, contentType); err != nil {
+	if err := service.Decoder.decode(v, body, contentType, req); err != nil {
+		if gerr, ok := err.(*Error); ok {
+			return gerr
+		}
 		return fmt.Errorf("failed to decode request body with content type %#v: %s", contentType, err)
 	}
 
@@ -203,10 +575,14 @@ func (service *Service) DecodeRequest(req *http.Request, v interface{}) error {
 }
 
 // EncodeResponse uses the HTTP encoder to marshal and write the response body based on the request
-// Accept header.
+// Accept and Accept-Charset headers.
 func (service *Service) EncodeResponse(ctx context.Context, v interface{}) error {
-	accept := ContextRequest(ctx).Header.Get("Accept")
-	return service.Encoder.Encode(v, ContextResponse(ctx), accept)
+	req := ContextRequest(ctx)
+	contentType, err := service.Encoder.Negotiate(req.Header.Get("Accept"), req.Header.Get("Accept-Charset"))
+	if err != nil {
+		return err
+	}
+	return service.Encoder.encode(v, ContextResponse(ctx), contentType)
 }
 
 // ServeFiles replies to the request with the contents of the named file or directory. See
@@ -222,7 +598,8 @@ func (ctrl *Controller) ServeFiles(path, filename string) error {
 		}
 		return nil
 	}
-	ctrl.Service.Mux.Handle("GET", path, ctrl.MuxHandler("serve", handler, nil))
+	route := &Route{Method: "GET", Path: path, Controller: ctrl.Name, Action: "serve"}
+	ctrl.Service.HandleRoute(route, ctrl.FileMuxHandler("serve", handler))
 	return nil
 }
 
@@ -232,17 +609,75 @@ func (ctrl *Controller) Use(m Middleware) {
 	ctrl.middleware = append(ctrl.middleware, m)
 }
 
+// UseAction adds a middleware to the action with the given name. Action middleware runs after
+// the service and controller middleware and only for requests handled by the action.
+func (ctrl *Controller) UseAction(action string, m Middleware) {
+	if ctrl.actionMiddleware == nil {
+		ctrl.actionMiddleware = make(map[string][]Middleware)
+	}
+	ctrl.actionMiddleware[action] = append(ctrl.actionMiddleware[action], m)
+}
+
+// SetActionTimeout sets the timeout of the action with the given name. The timeout is stored in
+// the context of the action requests where middleware such as the middleware package StrictTimeout
+// can retrieve it with ContextActionTimeout. The generated code calls SetActionTimeout for the
+// actions whose design defines the "timeout" metadata.
+func (ctrl *Controller) SetActionTimeout(action string, timeout time.Duration) {
+	if ctrl.actionTimeouts == nil {
+		ctrl.actionTimeouts = make(map[string]time.Duration)
+	}
+	ctrl.actionTimeouts[action] = timeout
+}
+
 // MuxHandler wraps a request handler into a MuxHandler. The MuxHandler initializes the request
 // context by loading the request state, invokes the handler and in case of error invokes the
 // controller (if there is one) or Service error handler.
 // This function is intended for the controller generated code. User code should not need to call
 // it directly.
 func (ctrl *Controller) MuxHandler(name string, hdlr Handler, unm Unmarshaler) MuxHandler {
+	return ctrl.muxHandler(name, hdlr, unm, false)
+}
+
+// FileMuxHandler wraps a request handler that serves files into a MuxHandler like MuxHandler does.
+// The contexts of the action requests record that the request is handled by a file server so that
+// middleware may tell them apart, see ContextFileServer. This function is intended for the
+// controller generated code and ServeFiles. User code should not need to call it directly.
+func (ctrl *Controller) FileMuxHandler(name string, hdlr Handler) MuxHandler {
+	if ctrl.fileActions == nil {
+		ctrl.fileActions = make(map[string]bool)
+	}
+	ctrl.fileActions[name] = true
+	return ctrl.muxHandler(name, hdlr, nil, false)
+}
+
+// StreamMuxHandler wraps a request handler into a MuxHandler like MuxHandler does for actions
+// whose request body is streamed. The MuxHandler neither decodes the request body nor limits its
+// length to MaxRequestBodyLength, the handler reads it incrementally from the request instead.
+// This function is intended for the controller generated code. User code should not need to call
+// it directly.
+func (ctrl *Controller) StreamMuxHandler(name string, hdlr Handler) MuxHandler {
+	return ctrl.muxHandler(name, hdlr, nil, true)
+}
+
+// muxHandler implements MuxHandler and StreamMuxHandler.
+func (ctrl *Controller) muxHandler(name string, hdlr Handler, unm Unmarshaler, stream bool) MuxHandler {
 	// Use closure to enable late computation of handlers to ensure all middleware has been
 	// registered.
 	var handler Handler
 
 	return func(rw http.ResponseWriter, req *http.Request, params url.Values) {
+		// Keep track of in-flight requests so Shutdown may wait for them and remove the
+		// temporary files of multipart bodies once the request has been handled. Middleware
+		// that keep running the handler after returning delay both with HoldRequest.
+		ctrl.Service.inflight.add()
+		hold := &requestHold{count: 1, release: func() {
+			if req.MultipartForm != nil {
+				req.MultipartForm.RemoveAll()
+			}
+			ctrl.Service.inflight.done()
+		}}
+		defer hold.done()
+
 		// Build handler middleware chains on first invocation
 		if handler == nil {
 			handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
@@ -251,28 +686,50 @@ func (ctrl *Controller) MuxHandler(name string, hdlr Handler, unm Unmarshaler) M
 				}
 				return nil
 			}
-			chain := append(ctrl.Service.middleware, ctrl.middleware...)
+			var chain []Middleware
+			chain = append(chain, ctrl.Service.middleware...)
+			chain = append(chain, ctrl.middleware...)
+			chain = append(chain, ctrl.actionMiddleware[name]...)
 			ml := len(chain)
 			for i := range chain {
 				handler = chain[ml-i-1](handler)
 			}
 		}
 
+		// Record the body length limit so that decoders may apply it to parts of the body
+		if ctrl.MaxRequestBodyLength > 0 && !stream {
+			req = req.WithContext(context.WithValue(req.Context(), maxBodyLengthKey, ctrl.MaxRequestBodyLength))
+		}
+
 		// Build context
 		ctx := NewContext(WithAction(ctrl.Context, name), rw, req, params)
+		ctx = context.WithValue(ctx, holdKey, hold)
+		ContextResponse(ctx).metrics = ctrl.Service.metrics
+		if timeout, ok := ctrl.actionTimeouts[name]; ok {
+			ctx = WithActionTimeout(ctx, timeout)
+		}
+		if ctrl.fileActions[name] {
+			ctx = WithFileServer(ctx)
+		}
 
-		// Protect against request bodies with unreasonable length
-		if ctrl.MaxRequestBodyLength > 0 {
+		// Protect against request bodies with unreasonable length, streams are read by the
+		// handler and may be arbitrarily long.
+		if ctrl.MaxRequestBodyLength > 0 && !stream {
 			req.Body = http.MaxBytesReader(rw, req.Body, ctrl.MaxRequestBodyLength)
 		}
 
 		// Load body if any
 		if req.ContentLength > 0 && unm != nil {
 			if err := unm(ctx, ctrl.Service, req); err != nil {
-				if err.Error() == "http: request body too large" {
+				gerr, ok := err.(*Error)
+				switch {
+				case ok && gerr.Status == http.StatusRequestEntityTooLarge:
+					// Decoder already reported the body or one of its parts as too large
+				case err.Error() == "http: request body too large":
 					err = ErrRequestBodyTooLarge("request body length exceeds %d bytes", ctrl.MaxRequestBodyLength)
-				} else {
-					err = ErrBadRequest(err)
+				default:
+					// Decoding errors describe the request body, the client may see them.
+					err = ErrBadRequest("%s", err).WithCause(err)
 				}
 				ctx = WithError(ctx, err)
 			}
@@ -281,6 +738,9 @@ func (ctrl *Controller) MuxHandler(name string, hdlr Handler, unm Unmarshaler) M
 		// Invoke handler
 		if err := handler(ctx, ContextResponse(ctx), req); err != nil {
 			LogError(ctx, "uncaught error", "err", err)
+			if e, ok := err.(*Error); ok {
+				ctrl.Service.countValidationErrors(e)
+			}
 			respBody := fmt.Sprintf("Internal error: %s", err) // Sprintf catches panics
 			ctrl.Service.Send(ctx, 500, respBody)
 		}
@@ -293,7 +753,7 @@ func (ctrl *Controller) MuxHandler(name string, hdlr Handler, unm Unmarshaler) M
 // of the URL (e.g. *filepath). If it does the matching path is appended to filename to form the
 // full file path, so:
 //
-// 	c.FileHandler("/index.html", "/www/data/index.html")
+//	c.FileHandler("/index.html", "/www/data/index.html")
 //
 // Returns the content of the file "/www/data/index.html" when requests are sent to "/index.html"
 // and:
@@ -301,7 +761,7 @@ func (ctrl *Controller) MuxHandler(name string, hdlr Handler, unm Unmarshaler) M
 //	c.FileHandler("/assets/*filepath", "/www/data/assets")
 //
 // returns the content of the file "/www/data/assets/x/y/z" when requests are sent to
-// "/assets/x/y/z".
+// "/assets/x/y/z". The handler should be mounted with FileMuxHandler.
 func (ctrl *Controller) FileHandler(path, filename string) Handler {
 	var wc string
 	if idx := strings.Index(path, "*"); idx > -1 && idx < len(path)-1 {
@@ -351,6 +811,75 @@ func (ctrl *Controller) FileHandler(path, filename string) Handler {
 	}
 }
 
+// HoldRequest delays the release of the resources of the request until the returned function is
+// called: Shutdown keeps counting the request as in-flight and the temporary files of multipart
+// bodies are kept until then. Middleware that keep running the handler in the background after
+// returning such as the middleware package StrictTimeout use it. The returned function must be
+// called exactly once, HoldRequest returns a function that does nothing if the context was not
+// created by a controller.
+func HoldRequest(ctx context.Context) func() {
+	hold, ok := ctx.Value(holdKey).(*requestHold)
+	if !ok {
+		return func() {}
+	}
+	hold.Lock()
+	hold.count++
+	hold.Unlock()
+	return hold.done
+}
+
+// done releases the request resources once all the holders are done.
+func (h *requestHold) done() {
+	h.Lock()
+	h.count--
+	last := h.count == 0
+	h.Unlock()
+	if last {
+		h.release()
+	}
+}
+
+// newRequestTracker returns a tracker with no in-flight requests.
+func newRequestTracker() *requestTracker {
+	idle := make(chan struct{})
+	close(idle)
+	return &requestTracker{idle: idle}
+}
+
+// add records the start of a request.
+func (t *requestTracker) add() {
+	t.Lock()
+	defer t.Unlock()
+	if t.count == 0 {
+		t.idle = make(chan struct{})
+	}
+	t.count++
+}
+
+// done records the completion of a request.
+func (t *requestTracker) done() {
+	t.Lock()
+	defer t.Unlock()
+	t.count--
+	if t.count == 0 {
+		close(t.idle)
+	}
+}
+
+// len returns the number of in-flight requests.
+func (t *requestTracker) len() int {
+	t.Lock()
+	defer t.Unlock()
+	return t.count
+}
+
+// wait returns a channel that is closed once there are no in-flight requests.
+func (t *requestTracker) wait() <-chan struct{} {
+	t.Lock()
+	defer t.Unlock()
+	return t.idle
+}
+
 var replacer = strings.NewReplacer(
 	"&", "&amp;",
 	"<", "&lt;",
@@ -385,6 +914,17 @@ func dirList(w http.ResponseWriter, f http.File) error {
 	return nil
 }
 
+type byRoute []*Route
+
+func (s byRoute) Len() int { return len(s) }
+func (s byRoute) Less(i, j int) bool {
+	if s[i].Path == s[j].Path {
+		return s[i].Method < s[j].Method
+	}
+	return s[i].Path < s[j].Path
+}
+func (s byRoute) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+
 type byName []os.FileInfo
 
 func (s byName) Len() int           { return len(s) }
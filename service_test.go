@@ -2,10 +2,15 @@ package goa_test
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strings"
+	"sync"
+	"time"
 
 	"golang.org/x/net/context"
 
@@ -83,6 +88,334 @@ var _ = Describe("Service", func() {
 		})
 	})
 
+	Describe("Send", func() {
+		var rw *TestResponseWriter
+		var req *http.Request
+		var code int
+		var body interface{}
+
+		BeforeEach(func() {
+			s.Encoder.Register(goa.NewJSONEncoder, "application/json")
+			rw = &TestResponseWriter{ParentHeader: make(http.Header)}
+			req, _ = http.NewRequest("GET", "/foo", nil)
+			code = 200
+			body = "ok"
+		})
+
+		JustBeforeEach(func() {
+			ctx := goa.NewContext(nil, rw, req, nil)
+			Ω(s.Send(ctx, code, body)).ShouldNot(HaveOccurred())
+		})
+
+		It("encodes the response and sets the Vary header", func() {
+			Ω(rw.Status).Should(Equal(200))
+			Ω(string(rw.Body)).Should(Equal(`"ok"` + "\n"))
+			Ω(rw.ParentHeader.Get("Vary")).Should(Equal("Accept"))
+		})
+
+		Context("with an Accept header that only the default encoder satisfies", func() {
+			BeforeEach(func() {
+				req.Header.Set("Accept", "application/xml")
+			})
+
+			It("uses the default encoder", func() {
+				Ω(rw.Status).Should(Equal(200))
+				Ω(string(rw.Body)).Should(Equal(`"ok"` + "\n"))
+			})
+		})
+
+		Context("with an Accept header that cannot be satisfied", func() {
+			BeforeEach(func() {
+				s.Encoder = goa.NewHTTPEncoder()
+				s.Encoder.Register(goa.NewXMLEncoder, "application/xml")
+				req.Header.Set("Accept", "application/json")
+			})
+
+			It("responds with 406", func() {
+				var e map[string]interface{}
+				Ω(rw.Status).Should(Equal(406))
+				Ω(rw.ParentHeader.Get("Content-Type")).Should(Equal(goa.ErrorMediaIdentifier))
+				Ω(json.Unmarshal(rw.Body, &e)).ShouldNot(HaveOccurred())
+				Ω(e).Should(HaveKeyWithValue("code", "not_acceptable"))
+			})
+
+			Context("and a problem error formatter", func() {
+				BeforeEach(func() {
+					s.ErrorFormatter = goa.NewProblemErrorFormatter("")
+				})
+
+				It("responds with a problem", func() {
+					var problem map[string]interface{}
+					Ω(rw.Status).Should(Equal(406))
+					Ω(rw.ParentHeader.Get("Content-Type")).Should(Equal(goa.ProblemMediaIdentifier))
+					Ω(json.Unmarshal(rw.Body, &problem)).ShouldNot(HaveOccurred())
+					Ω(problem).Should(HaveKeyWithValue("type", "not_acceptable"))
+				})
+			})
+		})
+
+		Context("with a validation error body", func() {
+			BeforeEach(func() {
+				code = 400
+				body = goa.MissingParamError("id")
+				catalog := goa.NewMessageCatalog()
+				catalog.Add("fr", map[string]string{goa.MsgMissingParam: "paramètre %#v manquant"})
+				s.Translator = catalog
+				req.Header.Set("Accept-Language", "fr")
+			})
+
+			It("localizes the error and strips the message metadata", func() {
+				var e map[string]interface{}
+				Ω(rw.Status).Should(Equal(400))
+				Ω(json.Unmarshal(rw.Body, &e)).ShouldNot(HaveOccurred())
+				Ω(e).Should(HaveKeyWithValue("detail", `paramètre "id" manquant`))
+				Ω(e["meta"]).ShouldNot(HaveKey(goa.MessageKeyMeta))
+				Ω(e["meta"]).ShouldNot(HaveKey(goa.MessageArgsMeta))
+			})
+		})
+
+		Context("with an error body", func() {
+			BeforeEach(func() {
+				code = 400
+				body = goa.ErrBadRequest("bad").Meta("param", "id")
+			})
+
+			It("uses the error media type", func() {
+				Ω(rw.Status).Should(Equal(400))
+				Ω(rw.ParentHeader.Get("Content-Type")).Should(Equal(goa.ErrorMediaIdentifier))
+				Ω(string(rw.Body)).Should(ContainSubstring(`"code":"bad_request"`))
+			})
+
+			Context("and the problem media type set by generated code", func() {
+				BeforeEach(func() {
+					rw.ParentHeader.Set("Content-Type", goa.ProblemMediaIdentifier)
+				})
+
+				It("uses the content type of the error formatter", func() {
+					Ω(rw.ParentHeader.Get("Content-Type")).Should(Equal(goa.ErrorMediaIdentifier))
+					Ω(string(rw.Body)).Should(ContainSubstring(`"code":"bad_request"`))
+				})
+			})
+
+			Context("and a problem error formatter", func() {
+				BeforeEach(func() {
+					s.ErrorFormatter = goa.NewProblemErrorFormatter("https://errors.goa.design/")
+				})
+
+				It("renders the error as a problem", func() {
+					var problem map[string]interface{}
+					Ω(rw.Status).Should(Equal(400))
+					Ω(rw.ParentHeader.Get("Content-Type")).Should(Equal(goa.ProblemMediaIdentifier))
+					Ω(json.Unmarshal(rw.Body, &problem)).ShouldNot(HaveOccurred())
+					Ω(problem).Should(Equal(map[string]interface{}{
+						"type":   "https://errors.goa.design/bad_request",
+						"title":  "Bad Request",
+						"status": 400.0,
+						"detail": "bad",
+						"param":  "id",
+					}))
+				})
+
+				Context("and a default encoder that is not JSON", func() {
+					BeforeEach(func() {
+						s.Encoder = goa.NewHTTPEncoder()
+						s.Encoder.Register(goa.NewXMLEncoder, "*/*")
+					})
+
+					It("encodes the problem to JSON", func() {
+						var problem map[string]interface{}
+						Ω(rw.Status).Should(Equal(400))
+						Ω(rw.ParentHeader.Get("Content-Type")).Should(Equal(goa.ProblemMediaIdentifier))
+						Ω(json.Unmarshal(rw.Body, &problem)).ShouldNot(HaveOccurred())
+						Ω(problem).Should(HaveKeyWithValue("type", "https://errors.goa.design/bad_request"))
+					})
+				})
+			})
+		})
+	})
+
+	Describe("MethodNotAllowed", func() {
+		var rw *TestResponseWriter
+		var req *http.Request
+
+		BeforeEach(func() {
+			rw = &TestResponseWriter{ParentHeader: make(http.Header)}
+			ctrl := s.NewController("test")
+			handler := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+				return s.Send(ctx, 200, "ok")
+			}
+			s.Mux.Handle("GET", "/foo", ctrl.MuxHandler("show", handler, nil))
+			s.Mux.Handle("PUT", "/foo", ctrl.MuxHandler("update", handler, nil))
+		})
+
+		JustBeforeEach(func() {
+			s.Mux.ServeHTTP(rw, req)
+		})
+
+		Context("with a request using a method with no registered handler", func() {
+			BeforeEach(func() {
+				req, _ = http.NewRequest("POST", "/foo", nil)
+			})
+
+			It("responds with 405 and the Allow header", func() {
+				Ω(rw.Status).Should(Equal(405))
+				Ω(rw.ParentHeader.Get("Allow")).Should(Equal("GET, HEAD, PUT, OPTIONS"))
+				Ω(string(rw.Body)).Should(ContainSubstring(`"code":"method_not_allowed"`))
+			})
+		})
+
+		Context("with an OPTIONS request", func() {
+			BeforeEach(func() {
+				req, _ = http.NewRequest("OPTIONS", "/foo", nil)
+			})
+
+			It("responds with the allowed methods", func() {
+				Ω(rw.Status).Should(Equal(200))
+				Ω(rw.ParentHeader.Get("Allow")).Should(Equal("GET, HEAD, PUT, OPTIONS"))
+				Ω(rw.Body).Should(BeEmpty())
+			})
+		})
+	})
+
+	Describe("ServeRoutes", func() {
+		var rw *TestResponseWriter
+
+		BeforeEach(func() {
+			rw = &TestResponseWriter{ParentHeader: make(http.Header)}
+			ctrl := s.NewController("bottle")
+			show := &goa.Route{Method: "GET", Path: "/bottles/:id", Controller: "bottle", Action: "show"}
+			s.HandleRoute(show, ctrl.MuxHandler("show", nil, nil))
+			s.Mux.Handle("DELETE", "/bottles/:id", ctrl.MuxHandler("delete", nil, nil))
+			s.ServeRoutes("/debug/routes")
+			req, _ := http.NewRequest("GET", "/debug/routes", nil)
+			s.Mux.ServeHTTP(rw, req)
+		})
+
+		It("lists the registered routes", func() {
+			Ω(rw.Status).Should(Equal(200))
+			Ω(string(rw.Body)).Should(Equal(`[{"method":"DELETE","path":"/bottles/:id"},` +
+				`{"method":"GET","path":"/bottles/:id","controller":"bottle","action":"show"},` +
+				`{"method":"GET","path":"/debug/routes","controller":"Routes","action":"list"},` +
+				`{"method":"GET","path":"/healthz","controller":"Health","action":"liveness"},` +
+				`{"method":"GET","path":"/readyz","controller":"Health","action":"readiness"}]` + "\n"))
+		})
+	})
+
+	Describe("UseMetrics", func() {
+		var metrics *testMetrics
+
+		BeforeEach(func() {
+			metrics = &testMetrics{}
+			s.UseMetrics(metrics)
+			ctrl := s.NewController("bottle")
+			handler := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+				return s.Send(ctx, 200, "ok")
+			}
+			invalid := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+				err := goa.InvalidFormatError("id", "x", goa.FormatUUID, errors.New("invalid"))
+				return s.Send(ctx, 400, err)
+			}
+			s.Mux.Handle("GET", "/bottles/:id", ctrl.MuxHandler("show", handler, nil))
+			s.Mux.Handle("GET", "/bottles", ctrl.MuxHandler("list", invalid, nil))
+			req, _ := http.NewRequest("GET", "/bottles/1", nil)
+			s.Mux.ServeHTTP(&TestResponseWriter{ParentHeader: make(http.Header)}, req)
+			req, _ = http.NewRequest("GET", "/bottles", nil)
+			s.Mux.ServeHTTP(&TestResponseWriter{ParentHeader: make(http.Header)}, req)
+		})
+
+		It("records the service metrics with the given instance", func() {
+			Eventually(metrics.Keys).Should(ContainElement("goa.response.200"))
+			Ω(metrics.Keys()).Should(ContainElement("goa.encode.*/*"))
+		})
+
+		It("records the validation errors with the given instance", func() {
+			Eventually(metrics.Keys).Should(ContainElement("goa.validation.error.uuid"))
+		})
+	})
+
+	Describe("Shutdown", func() {
+		var started, released chan struct{}
+		var handlerCtxErr error
+		var hookCalled bool
+		var hookCtxErr error
+		var hookHasDeadline bool
+		var shutdownTimeout time.Duration
+		var shutdownErr error
+
+		BeforeEach(func() {
+			started = make(chan struct{})
+			released = make(chan struct{})
+			handlerCtxErr = nil
+			hookCalled = false
+			shutdownTimeout = time.Second
+			s.DrainTimeout = 10 * time.Millisecond
+			s.ReadinessDelay = 0
+			s.OnShutdown(func(ctx context.Context) error {
+				hookCalled = true
+				hookCtxErr = ctx.Err()
+				_, hookHasDeadline = ctx.Deadline()
+				return nil
+			})
+			ctrl := s.NewController("test")
+			handler := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+				close(started)
+				<-ctx.Done()
+				handlerCtxErr = ctx.Err()
+				close(released)
+				return nil
+			}
+			muxHandler := ctrl.MuxHandler("slow", handler, nil)
+			req, _ := http.NewRequest("GET", "/slow", nil)
+			go muxHandler(&TestResponseWriter{ParentHeader: make(http.Header)}, req, nil)
+			<-started
+		})
+
+		JustBeforeEach(func() {
+			ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+			defer cancel()
+			shutdownErr = s.Shutdown(ctx)
+		})
+
+		It("cancels in-flight requests after the drain deadline and waits for them", func() {
+			Ω(shutdownErr).ShouldNot(HaveOccurred())
+			Eventually(released).Should(BeClosed())
+			Ω(handlerCtxErr).Should(Equal(context.Canceled))
+		})
+
+		It("runs the shutdown hooks", func() {
+			Ω(hookCalled).Should(BeTrue())
+		})
+
+		It("refuses to shut down twice", func() {
+			Ω(s.Shutdown(context.Background())).Should(HaveOccurred())
+		})
+
+		Context("with a context that is done before the requests complete", func() {
+			BeforeEach(func() {
+				shutdownTimeout = 0
+			})
+
+			It("runs the shutdown hooks with a context of their own", func() {
+				Ω(shutdownErr).Should(Equal(context.DeadlineExceeded))
+				Ω(hookCalled).Should(BeTrue())
+				Ω(hookCtxErr).ShouldNot(HaveOccurred())
+				Ω(hookHasDeadline).Should(BeTrue())
+			})
+		})
+
+		Context("with no drain timeout", func() {
+			BeforeEach(func() {
+				s.DrainTimeout = 0
+			})
+
+			It("cancels in-flight requests right away", func() {
+				Ω(shutdownErr).ShouldNot(HaveOccurred())
+				Eventually(released).Should(BeClosed())
+				Ω(handlerCtxErr).Should(Equal(context.Canceled))
+			})
+		})
+	})
+
 	Describe("MaxRequestBodyLength", func() {
 		var rw *TestResponseWriter
 		var req *http.Request
@@ -115,6 +448,32 @@ var _ = Describe("Service", func() {
 		})
 	})
 
+	Describe("StreamMuxHandler", func() {
+		var rw *TestResponseWriter
+		var read string
+
+		BeforeEach(func() {
+			req, _ := http.NewRequest("POST", "/foo", bytes.NewBufferString("0123456789"))
+			rw = &TestResponseWriter{ParentHeader: make(http.Header)}
+			ctrl := s.NewController("test")
+			ctrl.MaxRequestBodyLength = 4
+			handler := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+				Ω(goa.ContextError(ctx)).ShouldNot(HaveOccurred())
+				Ω(goa.ContextRequest(ctx).Payload).Should(BeNil())
+				b, err := ioutil.ReadAll(req.Body)
+				read = string(b)
+				rw.WriteHeader(204)
+				return err
+			}
+			ctrl.StreamMuxHandler("upload", handler)(rw, req, nil)
+		})
+
+		It("lets the handler read the entire request body", func() {
+			Ω(rw.Status).Should(Equal(204))
+			Ω(read).Should(Equal("0123456789"))
+		})
+	})
+
 	Describe("MuxHandler", func() {
 		var handler goa.Handler
 		var unmarshaler goa.Unmarshaler
@@ -236,6 +595,45 @@ var _ = Describe("Service", func() {
 				})
 			})
 
+			Context("and action middleware", func() {
+				serviceCalled := false
+				actionCalled := false
+				otherCalled := false
+
+				BeforeEach(func() {
+					serviceCalled = false
+					actionCalled = false
+					otherCalled = false
+					s.Use(TMiddleware(&serviceCalled))
+				})
+
+				It("calls the middleware of the action only", func() {
+					ctrl := s.NewController("test")
+					ctrl.UseAction("testAct", SecondMiddleware(&serviceCalled, &actionCalled))
+					ctrl.UseAction("other", TMiddleware(&otherCalled))
+					ctrl.MuxHandler("testAct", handler, unmarshaler)(rw, r, p)
+					Ω(actionCalled).Should(BeTrue())
+					Ω(otherCalled).Should(BeFalse())
+				})
+			})
+
+			Context("and action timeouts", func() {
+				It("sets the timeout of the action in the context", func() {
+					ctrl := s.NewController("test")
+					ctrl.SetActionTimeout("testAct", time.Second)
+					ctrl.SetActionTimeout("other", time.Minute)
+					ctrl.MuxHandler("testAct", handler, unmarshaler)(rw, r, p)
+					timeout, ok := goa.ContextActionTimeout(ctx)
+					Ω(ok).Should(BeTrue())
+					Ω(timeout).Should(Equal(time.Second))
+				})
+
+				It("does not set a timeout for the other actions", func() {
+					_, ok := goa.ContextActionTimeout(ctx)
+					Ω(ok).Should(BeFalse())
+				})
+			})
+
 			Context("with a handler that fails", func() {
 				errorHandlerCalled := false
 
@@ -308,6 +706,30 @@ var _ = Describe("Service", func() {
 	})
 })
 
+// testMetrics records the keys of the metrics it receives.
+type testMetrics struct {
+	sync.Mutex
+	keys []string
+}
+
+func (m *testMetrics) IncrCounter(key []string, val float32) {
+	m.Lock()
+	defer m.Unlock()
+	m.keys = append(m.keys, strings.Join(key, "."))
+}
+
+func (m *testMetrics) MeasureSince(key []string, start time.Time) {
+	m.Lock()
+	defer m.Unlock()
+	m.keys = append(m.keys, strings.Join(key, "."))
+}
+
+func (m *testMetrics) Keys() []string {
+	m.Lock()
+	defer m.Unlock()
+	return append([]string{}, m.keys...)
+}
+
 func TErrorHandler(witness *bool) goa.Middleware {
 	return func(h goa.Handler) goa.Handler {
 		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
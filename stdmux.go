@@ -0,0 +1,142 @@
+//go:build go1.22
+// +build go1.22
+
+package goa
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// stdMuxPatterns is true if http.ServeMux supports the method and wildcard patterns introduced in
+// Go 1.22. It is false if the http.ServeMux Go 1.21 behavior is restored with the
+// GODEBUG=httpmuxgo121=1 setting, either explicitly or because the go directive of the main module
+// go.mod file is older than go 1.22.
+var stdMuxPatterns bool
+
+func init() {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /goa/{id}", func(http.ResponseWriter, *http.Request) {})
+	_, pattern := mux.Handler(&http.Request{Method: "GET", URL: &url.URL{Path: "/goa/id"}})
+	stdMuxPatterns = pattern == "GET /goa/{id}"
+}
+
+type (
+	// stdMux is a ServeMux implementation backed by the standard library http.ServeMux.
+	stdMux struct {
+		*routeTable
+		router           *http.ServeMux
+		methods          map[string]bool
+		notFound         MuxHandler
+		methodNotAllowed MethodNotAllowedHandler
+	}
+)
+
+// NewStdMux returns a ServeMux backed by the standard library http.ServeMux. Path parameters
+// (e.g. ":id") and trailing wildcards (e.g. "*filepath") are translated into the equivalent
+// http.ServeMux patterns. NewStdMux is only available with Go 1.22 or later, it panics if the
+// http.ServeMux patterns are disabled by the GODEBUG=httpmuxgo121=1 setting. The setting is on by
+// default when the go directive of the main module go.mod file is older than go 1.22. Note that
+// http.ServeMux panics when registering two paths that match the same requests without one being
+// more specific than the other. Use Service.SetMux to make a service use the mux.
+func NewStdMux() ServeMux {
+	if !stdMuxPatterns {
+		panic("goa: NewStdMux requires the Go 1.22 http.ServeMux patterns, they are disabled by GODEBUG=httpmuxgo121=1 or by a go.mod go directive older than go 1.22")
+	}
+	m := &stdMux{
+		routeTable:       newRouteTable(),
+		router:           http.NewServeMux(),
+		methods:          make(map[string]bool),
+		methodNotAllowed: methodNotAllowed,
+	}
+	m.router.HandleFunc("/", func(rw http.ResponseWriter, req *http.Request) {
+		if methods := m.allowed(req); len(methods) > 0 {
+			m.methodNotAllowed(rw, req, methods)
+			return
+		}
+		if m.notFound == nil {
+			http.NotFound(rw, req)
+			return
+		}
+		m.notFound(rw, req, nil)
+	})
+	return m
+}
+
+// Handle sets the handler for the given verb and path.
+func (m *stdMux) Handle(method, path string, handle MuxHandler) {
+	pattern, names := stdPattern(path)
+	hthandle := func(rw http.ResponseWriter, req *http.Request) {
+		params := req.URL.Query()
+		for _, n := range names {
+			params.Set(n, req.PathValue(n))
+		}
+		handle(rw, req, params)
+	}
+	m.add(method, path, handle)
+	m.methods[method] = true
+	m.router.HandleFunc(method+" "+pattern, hthandle)
+}
+
+// HandleRoute sets the handler for the given route and records the route.
+func (m *stdMux) HandleRoute(route *Route, handle MuxHandler) {
+	m.Handle(route.Method, route.Path, handle)
+	m.describe(route)
+}
+
+// HandleNotFound sets the MuxHandler invoked for requests that don't match any
+// handler registered with Handle.
+func (m *stdMux) HandleNotFound(handle MuxHandler) {
+	m.notFound = handle
+}
+
+// HandleMethodNotAllowed sets the MethodNotAllowedHandler invoked for requests whose path
+// matches handlers registered with Handle but whose method does not.
+func (m *stdMux) HandleMethodNotAllowed(handle MethodNotAllowedHandler) {
+	m.methodNotAllowed = handle
+}
+
+// ServeHTTP is the function called back by the underlying HTTP server to handle incoming requests.
+func (m *stdMux) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	m.router.ServeHTTP(rw, req)
+}
+
+// allowed returns the methods of the handlers registered for the request path if any.
+func (m *stdMux) allowed(req *http.Request) []string {
+	var registered []string
+	for meth := range m.methods {
+		r := *req
+		r.Method = meth
+		if _, pattern := m.router.Handler(&r); pattern != "/" {
+			registered = append(registered, meth)
+		}
+	}
+	if len(registered) == 0 {
+		return nil
+	}
+	return allowedMethods(registered)
+}
+
+// stdPattern translates a goa request path into a http.ServeMux pattern and returns the pattern
+// together with the names of the path parameters.
+func stdPattern(path string) (string, []string) {
+	var names []string
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		switch {
+		case strings.HasPrefix(s, ":"):
+			names = append(names, s[1:])
+			segments[i] = "{" + s[1:] + "}"
+		case strings.HasPrefix(s, "*"):
+			names = append(names, s[1:])
+			segments[i] = "{" + s[1:] + "...}"
+		}
+	}
+	pattern := strings.Join(segments, "/")
+	if strings.HasSuffix(pattern, "/") {
+		// http.ServeMux treats patterns ending with a slash as prefixes.
+		pattern += "{$}"
+	}
+	return pattern, names
+}
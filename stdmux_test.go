@@ -0,0 +1,21 @@
+//go:build go1.22
+// +build go1.22
+
+package goa_test
+
+import (
+	"testing"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/goatest"
+)
+
+func TestStdMuxConformance(t *testing.T) {
+	goatest.TestServeMux(t, goa.NewStdMux)
+}
+
+func TestStdMuxMethodNotAllowed(t *testing.T) {
+	if _, ok := goa.NewStdMux().(goa.MethodNotAllowedMux); !ok {
+		t.Errorf("std mux does not implement goa.MethodNotAllowedMux")
+	}
+}
@@ -0,0 +1,59 @@
+package goa
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// NDJSONContentType is the content type of newline delimited JSON streams.
+const NDJSONContentType = "application/x-ndjson"
+
+type (
+	// ElementDecoder reads the elements of a newline delimited JSON stream such as a streamed
+	// request body.
+	ElementDecoder struct {
+		dec *json.Decoder
+	}
+
+	// ElementEncoder writes the elements of a newline delimited JSON stream such as a streamed
+	// response body. Each element is flushed to the client as soon as it is written if the
+	// underlying writer is a http.Flusher.
+	ElementEncoder struct {
+		w   io.Writer
+		enc *json.Encoder
+	}
+)
+
+// NewElementDecoder returns a decoder that reads newline delimited JSON elements from r.
+func NewElementDecoder(r io.Reader) *ElementDecoder {
+	return &ElementDecoder{dec: json.NewDecoder(r)}
+}
+
+// Decode reads the next element of the stream into v. It returns io.EOF once all the elements
+// have been read and a bad request error if the element cannot be decoded.
+func (d *ElementDecoder) Decode(v interface{}) error {
+	if err := d.dec.Decode(v); err != nil {
+		if err == io.EOF {
+			return err
+		}
+		return ErrBadRequest("%s", err).WithCause(err)
+	}
+	return nil
+}
+
+// NewElementEncoder returns an encoder that writes newline delimited JSON elements to w.
+func NewElementEncoder(w io.Writer) *ElementEncoder {
+	return &ElementEncoder{w: w, enc: json.NewEncoder(w)}
+}
+
+// Encode writes v to the stream followed by a newline and flushes it.
+func (e *ElementEncoder) Encode(v interface{}) error {
+	if err := e.enc.Encode(v); err != nil {
+		return err
+	}
+	if f, ok := e.w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package goa_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ElementDecoder", func() {
+	var body string
+	var elems []map[string]interface{}
+	var err error
+
+	JustBeforeEach(func() {
+		elems = nil
+		dec := goa.NewElementDecoder(strings.NewReader(body))
+		for {
+			var elem map[string]interface{}
+			if err = dec.Decode(&elem); err != nil {
+				break
+			}
+			elems = append(elems, elem)
+		}
+	})
+
+	Context("with newline delimited JSON", func() {
+		BeforeEach(func() {
+			body = "{\"id\":1}\n{\"id\":2}\n"
+		})
+
+		It("decodes all the elements", func() {
+			Ω(err).Should(Equal(io.EOF))
+			Ω(elems).Should(HaveLen(2))
+			Ω(elems[1]).Should(HaveKeyWithValue("id", 2.0))
+		})
+	})
+
+	Context("with an invalid element", func() {
+		BeforeEach(func() {
+			body = "{\"id\":1}\n{\"id\"\n"
+		})
+
+		It("returns a bad request error", func() {
+			Ω(elems).Should(HaveLen(1))
+			Ω(err).Should(HaveOccurred())
+			Ω(err.(*goa.Error).Status).Should(Equal(400))
+		})
+	})
+})
+
+var _ = Describe("ElementEncoder", func() {
+	It("writes newline delimited JSON and flushes", func() {
+		var buf bytes.Buffer
+		w := &flushRecorder{Writer: &buf}
+		enc := goa.NewElementEncoder(w)
+		Ω(enc.Encode(map[string]int{"id": 1})).ShouldNot(HaveOccurred())
+		Ω(enc.Encode(map[string]int{"id": 2})).ShouldNot(HaveOccurred())
+		Ω(buf.String()).Should(Equal("{\"id\":1}\n{\"id\":2}\n"))
+		Ω(w.flushed).Should(Equal(2))
+	})
+})
+
+type flushRecorder struct {
+	io.Writer
+	flushed int
+}
+
+func (f *flushRecorder) Flush() { f.flushed++ }